@@ -0,0 +1,194 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// failbackInterval is the minimum amount of time between two health
+	// checks of the addresses preferred over the active one.
+	failbackInterval = time.Minute
+	// healthPath is the Vault endpoint used to check the health of an
+	// address.
+	healthPath = "/v1/sys/health"
+)
+
+// failoverTransport sends Vault requests to the first of an ordered list of
+// addresses. When the active address fails, the request is retried once
+// against the first other address whose health endpoint reports an unsealed
+// active or standby node. Preferred addresses are taken back into use once
+// they are healthy again.
+type failoverTransport struct {
+	addresses          []*url.URL
+	currentTimeFactory func() time.Time
+	failbackInterval   time.Duration
+	transport          http.RoundTripper
+
+	mutex        sync.Mutex
+	active       int
+	lastFailback time.Time
+}
+
+func newFailoverTransport(addresses []*url.URL, transport http.RoundTripper) *failoverTransport {
+	t := &failoverTransport{
+		addresses:          addresses,
+		currentTimeFactory: time.Now,
+		failbackInterval:   failbackInterval,
+		transport:          transport,
+	}
+
+	return t
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The body has to be buffered, because it is sent again when the request
+	// is retried against another address.
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	active := t.activeAddress(req.Context())
+
+	res, err := t.roundTrip(req, body, active)
+	if !isUnavailable(res, err) || req.Context().Err() != nil {
+		return res, err
+	}
+
+	for i := range t.addresses {
+		if i == active || !t.isHealthy(req.Context(), i) {
+			continue
+		}
+
+		closeBody(res)
+		t.setActive(i)
+
+		return t.roundTrip(req, body, i)
+	}
+
+	return res, err
+}
+
+// activeAddress returns the index of the address requests are sent to. In
+// case a standby address is active, the preferred addresses are checked once
+// per failback interval and the first healthy one becomes active again.
+func (t *failoverTransport) activeAddress(ctx context.Context) int {
+	t.mutex.Lock()
+	active := t.active
+	now := t.currentTimeFactory()
+	check := active != 0 && now.Sub(t.lastFailback) >= t.failbackInterval
+	if check {
+		t.lastFailback = now
+	}
+	t.mutex.Unlock()
+
+	if !check {
+		return active
+	}
+
+	for i := 0; i < active; i++ {
+		if t.isHealthy(ctx, i) {
+			t.setActive(i)
+			return i
+		}
+	}
+
+	return active
+}
+
+func (t *failoverTransport) isHealthy(ctx context.Context, i int) bool {
+	u := *t.addresses[i]
+	u.Path = healthPath
+	u.RawQuery = ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false
+	}
+
+	res, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	closeBody(res)
+
+	// Vault answers with 200 on the active node, 429 on standby nodes and
+	// 473 on performance standby nodes. Standby nodes forward requests to
+	// the active node. Sealed and uninitialized nodes, as well as disaster
+	// recovery secondaries, do not serve requests.
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusTooManyRequests, 473:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *failoverTransport) roundTrip(req *http.Request, body []byte, i int) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = t.addresses[i].Scheme
+	r.URL.Host = t.addresses[i].Host
+	r.Host = ""
+
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		r.ContentLength = int64(len(body))
+	}
+
+	return t.transport.RoundTrip(r)
+}
+
+func (t *failoverTransport) setActive(i int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.active == 0 && i != 0 {
+		t.lastFailback = t.currentTimeFactory()
+	}
+	t.active = i
+}
+
+func closeBody(res *http.Response) {
+	if res != nil && res.Body != nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+}
+
+// isUnavailable returns whether the Vault at the address a request was sent
+// to could not serve it, e.g. because it is unreachable or sealed.
+func isUnavailable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
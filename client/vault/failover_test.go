@@ -0,0 +1,169 @@
+package vault
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeVault struct {
+	server *httptest.Server
+
+	health   int
+	status   int
+	requests []string
+}
+
+func newFakeVault(t *testing.T, health, status int) *fakeVault {
+	v := &fakeVault{
+		health: health,
+		status: status,
+	}
+
+	v.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == healthPath {
+			w.WriteHeader(v.health)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v.requests = append(v.requests, r.Method+" "+r.URL.Path+" "+string(body))
+
+		w.WriteHeader(v.status)
+	}))
+	t.Cleanup(v.server.Close)
+
+	return v
+}
+
+func newTestFailoverTransport(t *testing.T, now *time.Time, servers ...*fakeVault) *failoverTransport {
+	var addresses []*url.URL
+	for _, s := range servers {
+		u, err := url.Parse(s.server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addresses = append(addresses, u)
+	}
+
+	f := newFailoverTransport(addresses, http.DefaultTransport)
+	f.currentTimeFactory = func() time.Time { return *now }
+
+	return f
+}
+
+func sendRequest(t *testing.T, f *failoverTransport, body string) int {
+	// The request is addressed to a host unknown to the transport in order
+	// to verify it is rewritten to the active address.
+	req, err := http.NewRequest(http.MethodPut, "http://vault.invalid/v1/pki/issue/role", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := f.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeBody(res)
+
+	return res.StatusCode
+}
+
+func Test_FailoverTransport_PreferredHealthy(t *testing.T) {
+	now := time.Unix(0, 0)
+	preferred := newFakeVault(t, http.StatusOK, http.StatusOK)
+	standby := newFakeVault(t, http.StatusOK, http.StatusOK)
+
+	f := newTestFailoverTransport(t, &now, preferred, standby)
+
+	if status := sendRequest(t, f, "foo"); status != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, status)
+	}
+	if len(preferred.requests) != 1 || preferred.requests[0] != "PUT /v1/pki/issue/role foo" {
+		t.Fatalf("expected request to be sent to the preferred address, got %v", preferred.requests)
+	}
+	if len(standby.requests) != 0 {
+		t.Fatalf("expected no request to be sent to the standby address, got %v", standby.requests)
+	}
+}
+
+func Test_FailoverTransport_FailoverAndFailback(t *testing.T) {
+	now := time.Unix(0, 0)
+	preferred := newFakeVault(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	sealed := newFakeVault(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	standby := newFakeVault(t, http.StatusTooManyRequests, http.StatusOK)
+
+	f := newTestFailoverTransport(t, &now, preferred, sealed, standby)
+
+	// The preferred Vault is sealed, so the request is retried with its body
+	// against the first healthy address.
+	if status := sendRequest(t, f, "foo"); status != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, status)
+	}
+	if len(sealed.requests) != 0 {
+		t.Fatalf("expected no request to be sent to the sealed address, got %v", sealed.requests)
+	}
+	if len(standby.requests) != 1 || standby.requests[0] != "PUT /v1/pki/issue/role foo" {
+		t.Fatalf("expected request to be retried against the standby address, got %v", standby.requests)
+	}
+
+	// Subsequent requests go straight to the standby address, even once the
+	// preferred Vault is healthy again, until the failback interval passed.
+	preferred.health = http.StatusOK
+	preferred.status = http.StatusOK
+
+	sendRequest(t, f, "bar")
+	if len(preferred.requests) != 1 || len(standby.requests) != 2 {
+		t.Fatalf("expected request to be sent to the standby address, got %v and %v", preferred.requests, standby.requests)
+	}
+
+	now = now.Add(failbackInterval)
+
+	sendRequest(t, f, "baz")
+	if len(preferred.requests) != 2 || preferred.requests[1] != "PUT /v1/pki/issue/role baz" {
+		t.Fatalf("expected request to be sent to the preferred address again, got %v", preferred.requests)
+	}
+	if len(standby.requests) != 2 {
+		t.Fatalf("expected no further request to be sent to the standby address, got %v", standby.requests)
+	}
+}
+
+func Test_FailoverTransport_Unreachable(t *testing.T) {
+	now := time.Unix(0, 0)
+	preferred := newFakeVault(t, http.StatusOK, http.StatusOK)
+	standby := newFakeVault(t, http.StatusOK, http.StatusOK)
+
+	f := newTestFailoverTransport(t, &now, preferred, standby)
+	preferred.server.Close()
+
+	if status := sendRequest(t, f, "foo"); status != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, status)
+	}
+	if len(standby.requests) != 1 {
+		t.Fatalf("expected request to be sent to the standby address, got %v", standby.requests)
+	}
+}
+
+func Test_FailoverTransport_NoHealthyAddress(t *testing.T) {
+	now := time.Unix(0, 0)
+	preferred := newFakeVault(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	standby := newFakeVault(t, http.StatusNotImplemented, http.StatusServiceUnavailable)
+
+	f := newTestFailoverTransport(t, &now, preferred, standby)
+
+	// The response of the preferred address is returned when no other
+	// address is healthy.
+	if status := sendRequest(t, f, "foo"); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d got %d", http.StatusServiceUnavailable, status)
+	}
+	if len(preferred.requests) != 1 || len(standby.requests) != 0 {
+		t.Fatalf("expected request to be sent to the preferred address only, got %v and %v", preferred.requests, standby.requests)
+	}
+}
@@ -1,6 +1,8 @@
 package vault
 
 import (
+	"context"
+	"net/http"
 	"net/url"
 
 	"github.com/giantswarm/microerror"
@@ -8,9 +10,19 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/giantswarm/cert-operator/v3/flag"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultauth"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultmetrics"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultnamespace"
 )
 
 type Config struct {
+	// Dependencies.
+	//
+	// VaultBreaker is optional. All requests of the client are sent through
+	// it in case it is given.
+	VaultBreaker *vaultbreaker.Breaker
+
 	// Settings.
 	Flag  *flag.Flag
 	Viper *viper.Viper
@@ -18,30 +30,140 @@ type Config struct {
 
 func NewClient(config Config) (*vaultapi.Client, error) {
 	address := config.Viper.GetString(config.Flag.Service.Vault.Config.Address)
+	method := config.Viper.GetString(config.Flag.Service.Vault.Config.Auth.Method)
 	token := config.Viper.GetString(config.Flag.Service.Vault.Config.Token)
 
 	if address == "" {
 		return nil, microerror.Maskf(invalidConfigError, "vault address must not be empty")
 	}
 
-	// Check Vault address is valid.
-	_, err := url.ParseRequestURI(address)
-	if err != nil {
-		return nil, microerror.Mask(err)
+	// The preferred address is always tried first. The other addresses are
+	// only used when the Vault at the preferred address is unhealthy.
+	var addresses []*url.URL
+	for _, a := range uniqueAddresses(address, config.Viper.GetStringSlice(config.Flag.Service.Vault.Config.Addresses)) {
+		// Check Vault address is valid.
+		u, err := url.ParseRequestURI(a)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if u.Host == "" {
+			return nil, microerror.Maskf(invalidConfigError, "vault address %#q must contain a host", a)
+		}
+
+		addresses = append(addresses, u)
 	}
 
-	if token == "" {
+	// Tokens of the other auth methods are acquired by vaultauth after the
+	// client has been created.
+	if (method == "" || method == vaultauth.MethodToken) && token == "" {
 		return nil, microerror.Maskf(invalidConfigError, "vault token must not be empty")
 	}
 
+	// Templated namespaces are applied per Tenant Cluster by vaultnamespace.
+	// The client itself uses the base namespace, in which tokens are managed.
+	var namespace *vaultnamespace.Namespace
+	{
+		var err error
+
+		c := vaultnamespace.Config{
+			Format: config.Viper.GetString(config.Flag.Service.Vault.Config.Namespace),
+		}
+
+		namespace, err = vaultnamespace.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	newClientConfig := vaultapi.DefaultConfig()
 	newClientConfig.Address = address
+	// Clones of the client keep using the namespace.
+	newClientConfig.CloneHeaders = true
+
+	{
+		t := &vaultapi.TLSConfig{
+			CACert:        config.Viper.GetString(config.Flag.Service.Vault.Config.TLS.CAFile),
+			ClientCert:    config.Viper.GetString(config.Flag.Service.Vault.Config.TLS.CrtFile),
+			ClientKey:     config.Viper.GetString(config.Flag.Service.Vault.Config.TLS.KeyFile),
+			TLSServerName: config.Viper.GetString(config.Flag.Service.Vault.Config.TLS.ServerName),
+		}
+
+		if (t.ClientCert == "") != (t.ClientKey == "") {
+			return nil, microerror.Maskf(invalidConfigError, "vault client certificate and key must be given together")
+		}
+		if method == vaultauth.MethodCert && t.ClientCert == "" {
+			return nil, microerror.Maskf(invalidConfigError, "vault client certificate must not be empty for the %#q auth method", method)
+		}
+
+		err := newClientConfig.ConfigureTLS(t)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "failed to configure vault TLS: %s", err)
+		}
+	}
+
+	if timeout := config.Viper.GetDuration(config.Flag.Service.Vault.Config.Timeout); timeout < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "vault timeout must not be negative")
+	} else if timeout > 0 {
+		newClientConfig.Timeout = timeout
+	}
+
+	if maxRetries := config.Viper.GetInt(config.Flag.Service.Vault.Config.MaxRetries); maxRetries < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "vault max retries must not be negative")
+	} else if maxRetries > 0 {
+		newClientConfig.MaxRetries = maxRetries
+	}
+
+	// The failover transport has to wrap the transport after the TLS
+	// configuration, because the Vault client only configures TLS on its own
+	// *http.Transport.
+	if len(addresses) > 1 {
+		newClientConfig.HttpClient.Transport = newFailoverTransport(addresses, newClientConfig.HttpClient.Transport)
+	}
+
+	// Every request sent to Vault is measured, including the ones failing
+	// over. Requests rejected by the circuit breaker are not sent.
+	newClientConfig.HttpClient.Transport = vaultmetrics.NewTransport(newClientConfig.HttpClient.Transport)
+
+	// The circuit breaker observes requests after they failed over. Requests
+	// it rejects are not retried, as they would only be rejected again.
+	if config.VaultBreaker != nil {
+		newClientConfig.HttpClient.Transport = config.VaultBreaker.Wrap(newClientConfig.HttpClient.Transport)
+		newClientConfig.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+			if vaultbreaker.IsCircuitOpen(err) {
+				return false, nil
+			}
+
+			return vaultapi.DefaultRetryPolicy(ctx, resp, err)
+		}
+	}
 
 	newVaultClient, err := vaultapi.NewClient(newClientConfig)
 	if err != nil {
 		return nil, err
 	}
-	newVaultClient.SetToken(token)
+	if token != "" {
+		newVaultClient.SetToken(token)
+	}
+	if namespace.Base() != "" {
+		newVaultClient.SetNamespace(namespace.Base())
+	}
 
 	return newVaultClient, nil
 }
+
+// uniqueAddresses returns the preferred address followed by the standby
+// addresses in their given order, without empty and duplicate entries.
+func uniqueAddresses(preferred string, standby []string) []string {
+	seen := map[string]bool{}
+
+	var addresses []string
+	for _, a := range append([]string{preferred}, standby...) {
+		if a == "" || seen[a] {
+			continue
+		}
+		seen[a] = true
+		addresses = append(addresses, a)
+	}
+
+	return addresses
+}
@@ -2,18 +2,29 @@ package vault
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/giantswarm/micrologger/microloggertest"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/giantswarm/cert-operator/v3/flag"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
 )
 
 func TestNewClient(t *testing.T) {
 	tests := []struct {
 		name          string
 		address       string
+		addresses     []string
+		authMethod    string
+		caFile        string
+		crtFile       string
+		maxRetries    int
+		timeout       time.Duration
 		token         string
 		expectedError bool
 	}{
@@ -41,6 +52,57 @@ func TestNewClient(t *testing.T) {
 			token:         "auth-token",
 			expectedError: true,
 		},
+		{
+			name:          "Specify standby vault addresses, a timeout and retries. It should return a vault client.",
+			address:       "http://localhost:8200",
+			addresses:     []string{"http://localhost:8200", "http://localhost:8201", "http://localhost:8202"},
+			maxRetries:    5,
+			timeout:       10 * time.Second,
+			token:         "auth-token",
+			expectedError: false,
+		},
+		{
+			name:          "Specify an invalid standby vault address. It should return an error.",
+			address:       "http://localhost:8200",
+			addresses:     []string{"localhost:8201"},
+			token:         "auth-token",
+			expectedError: true,
+		},
+		{
+			name:          "Specify a client certificate but no key. It should return an error.",
+			address:       "https://localhost:8200",
+			crtFile:       "/does/not/exist.crt",
+			token:         "auth-token",
+			expectedError: true,
+		},
+		{
+			name:          "Specify a missing CA file. It should return an error.",
+			address:       "https://localhost:8200",
+			caFile:        "/does/not/exist.crt",
+			token:         "auth-token",
+			expectedError: true,
+		},
+		{
+			name:          "Specify the approle auth method but no token. It should return a vault client.",
+			address:       "http://localhost:8200",
+			authMethod:    "approle",
+			token:         "",
+			expectedError: false,
+		},
+		{
+			name:          "Specify the cert auth method but no client certificate. It should return an error.",
+			address:       "https://localhost:8200",
+			authMethod:    "cert",
+			token:         "",
+			expectedError: true,
+		},
+		{
+			name:          "Specify a negative timeout. It should return an error.",
+			address:       "http://localhost:8200",
+			timeout:       -time.Second,
+			token:         "auth-token",
+			expectedError: true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -49,6 +111,12 @@ func TestNewClient(t *testing.T) {
 
 		v.Set(f.Service.Vault.Config.Address, tc.address)
 		v.Set(f.Service.Vault.Config.Token, tc.token)
+		v.Set(f.Service.Vault.Config.Addresses, tc.addresses)
+		v.Set(f.Service.Vault.Config.Auth.Method, tc.authMethod)
+		v.Set(f.Service.Vault.Config.MaxRetries, tc.maxRetries)
+		v.Set(f.Service.Vault.Config.Timeout, tc.timeout)
+		v.Set(f.Service.Vault.Config.TLS.CAFile, tc.caFile)
+		v.Set(f.Service.Vault.Config.TLS.CrtFile, tc.crtFile)
 
 		config := Config{
 			Flag:  f,
@@ -65,3 +133,47 @@ func TestNewClient(t *testing.T) {
 		}
 	}
 }
+
+func TestNewClient_VaultBreaker(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	vaultBreaker, err := vaultbreaker.New(vaultbreaker.Config{
+		Logger: microloggertest.New(),
+
+		FailureThreshold: 2,
+		MaxOpenDuration:  time.Minute,
+		OpenDuration:     time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := flag.New()
+	v := viper.New()
+
+	v.Set(f.Service.Vault.Config.Address, server.URL)
+	v.Set(f.Service.Vault.Config.MaxRetries, 5)
+	v.Set(f.Service.Vault.Config.Token, "auth-token")
+
+	vaultClient, err := NewClient(Config{
+		VaultBreaker: vaultBreaker,
+
+		Flag:  f,
+		Viper: v,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaultClient.SetMinRetryWait(time.Millisecond)
+	vaultClient.SetMaxRetryWait(time.Millisecond)
+
+	// The retries of the Vault client stop once the circuit opened.
+	_, err = vaultClient.Logical().Read("sys/mounts")
+	assert.True(t, vaultbreaker.IsCircuitOpen(err), "Circuit open error was expected")
+	assert.Equal(t, 2, requests, "Requests should not be sent while the circuit is open")
+}
@@ -0,0 +1,400 @@
+// Package migrate implements the migrate command, which exports the PKI of
+// tenant clusters managed by cert-operator into cert-manager or Cluster API
+// resources.
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/k8sclient/v7/pkg/k8sclient"
+	"github.com/giantswarm/k8sclient/v7/pkg/k8srestconfig"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/vaultpki"
+	vaultpkikey "github.com/giantswarm/vaultpki/key"
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	clientvault "github.com/giantswarm/cert-operator/v3/client/vault"
+	"github.com/giantswarm/cert-operator/v3/flag"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultnamespace"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+type Config struct {
+	Flag   *flag.Flag
+	Logger micrologger.Logger
+	Viper  *viper.Viper
+}
+
+type Command struct {
+	cobraCommand *cobra.Command
+
+	flag   *flag.Flag
+	logger micrologger.Logger
+	viper  *viper.Viper
+
+	caKeyDir  string
+	outputDir string
+	plan      bool
+	target    string
+}
+
+func New(config Config) (*Command, error) {
+	if config.Flag == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Flag must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Viper == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Viper must not be empty", config)
+	}
+
+	c := &Command{
+		flag:   config.Flag,
+		logger: config.Logger,
+		viper:  config.Viper,
+	}
+
+	c.cobraCommand = &cobra.Command{
+		Use:   "migrate",
+		Short: "Export the PKI of tenant clusters into cert-manager or Cluster API resources.",
+		Long: `Export the PKI of tenant clusters into cert-manager or Cluster API resources.
+
+For every PKI backend in Vault the CA is written as secret in the format of the
+target. In case the private key of the CA is known, a cert-manager CA issuer
+and a certificate for every paused CertConfig issued by the PKI backend are
+written as well. The certificates take over the secrets of the CertConfigs,
+so the CertConfigs have to be paused first. Vault does not hand out the
+private keys of existing CAs. Unless the operator stored the private key when
+creating the CA, it has to be provided as <PKI backend ID>.key file in the
+directory given by --ca-key-dir, e.g. al9qy.key or al9qy-etcd.key for the etcd
+trust domain. Without private key only the CA certificate is exported.
+
+By default the command only prints what it would write. Use --plan=false to
+write the manifests to the directory given by --output-dir.`,
+		Run: c.Execute,
+	}
+
+	flags := c.cobraCommand.Flags()
+
+	flags.StringVar(&c.caKeyDir, "ca-key-dir", "", "Directory containing the private keys of the CAs as <PKI backend ID>.key files.")
+	flags.StringVar(&c.outputDir, "output-dir", "migrate", "Directory to write the manifests to, one file per tenant cluster.")
+	flags.BoolVar(&c.plan, "plan", true, "Whether to only print what would be written.")
+	flags.StringVar(&c.target, "target", TargetCertManager, fmt.Sprintf("Format of the CA secrets, either %q or %q.", TargetCertManager, TargetCAPI))
+
+	flags.String(c.flag.Service.Kubernetes.Address, "", "Address used to connect to Kubernetes. When empty in-cluster config is created.")
+	flags.Bool(c.flag.Service.Kubernetes.InCluster, false, "Whether to use the in-cluster config to authenticate with Kubernetes.")
+	flags.String(c.flag.Service.Kubernetes.KubeConfig, "", "KubeConfig used to connect to Kubernetes. When empty other settings are used.")
+	flags.String(c.flag.Service.Vault.Config.Address, "", "Address used to connect to Vault. Defaults to VAULT_ADDR.")
+	flags.String(c.flag.Service.Vault.Config.Token, "", "Token used to authenticate against Vault. Defaults to VAULT_TOKEN.")
+	flags.String(c.flag.Service.Vault.Config.PKI.TrustDomains, "", "YAML map of cluster components to trust domains, as given to the operator. The PKI backends of the trust domains are migrated along with the ones of the clusters.")
+	flags.String(c.flag.Service.Vault.Config.Namespace, "", "Vault Enterprise namespace of the PKI backends. %s in its last path element is replaced by the cluster ID. Defaults to VAULT_NAMESPACE.")
+	flags.String(c.flag.Service.Vault.Config.TLS.CAFile, "", "Certificate authority file path used to verify the certificate of Vault. Defaults to VAULT_CACERT.")
+	flags.String(c.flag.Service.Vault.Config.TLS.CrtFile, "", "Certificate file path presented to Vault as client certificate. Defaults to VAULT_CLIENT_CERT.")
+	flags.String(c.flag.Service.Vault.Config.TLS.KeyFile, "", "Key file path of the client certificate presented to Vault. Defaults to VAULT_CLIENT_KEY.")
+
+	// The Vault settings fall back to the environment variables of the Vault
+	// CLI. They are not used as flag defaults in order to not print the token
+	// with the usage.
+	c.viper.SetDefault(c.flag.Service.Vault.Config.Address, os.Getenv("VAULT_ADDR"))
+	c.viper.SetDefault(c.flag.Service.Vault.Config.Token, os.Getenv("VAULT_TOKEN"))
+	c.viper.SetDefault(c.flag.Service.Vault.Config.Namespace, os.Getenv("VAULT_NAMESPACE"))
+	c.viper.SetDefault(c.flag.Service.Vault.Config.TLS.CAFile, os.Getenv("VAULT_CACERT"))
+	c.viper.SetDefault(c.flag.Service.Vault.Config.TLS.CrtFile, os.Getenv("VAULT_CLIENT_CERT"))
+	c.viper.SetDefault(c.flag.Service.Vault.Config.TLS.KeyFile, os.Getenv("VAULT_CLIENT_KEY"))
+
+	return c, nil
+}
+
+func (c *Command) CobraCommand() *cobra.Command {
+	return c.cobraCommand
+}
+
+func (c *Command) Execute(cmd *cobra.Command, args []string) {
+	err := c.viper.BindPFlags(cmd.Flags())
+	if err != nil {
+		c.logger.Errorf(context.Background(), err, "failed to bind flags")
+		os.Exit(1)
+	}
+
+	err = c.execute(context.Background())
+	if err != nil {
+		c.logger.Errorf(context.Background(), err, "failed to migrate")
+		os.Exit(1)
+	}
+}
+
+func (c *Command) execute(ctx context.Context) error {
+	if c.target != TargetCAPI && c.target != TargetCertManager {
+		return microerror.Maskf(invalidFlagError, "--target must be one of %#q and %#q, got %#q", TargetCAPI, TargetCertManager, c.target)
+	}
+
+	clusters, err := c.readClusters(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, cl := range clusters {
+		p, err := newPlan(c.target, cl)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		fmt.Printf("cluster %s, PKI backend %s\n", p.ClusterID, p.PKIID)
+		for _, n := range p.Notes {
+			fmt.Printf("  %s\n", n)
+		}
+
+		if c.plan {
+			continue
+		}
+
+		err = c.writePlan(p)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if c.plan {
+		fmt.Printf("\nplan mode, nothing got written, run with --plan=false to write the manifests to %#q\n", c.outputDir)
+	}
+
+	return nil
+}
+
+// readClusters reads the PKI backends from Vault and the cert configs from
+// Kubernetes and groups them by tenant cluster.
+func (c *Command) readClusters(ctx context.Context) ([]cluster, error) {
+	vaultClient, err := clientvault.NewClient(clientvault.Config{Flag: c.flag, Viper: c.viper})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	trustDomains, err := trustdomain.Parse(c.viper.GetString(c.flag.Service.Vault.Config.PKI.TrustDomains))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	vaultNamespace, err := vaultnamespace.New(vaultnamespace.Config{
+		Format:       c.viper.GetString(c.flag.Service.Vault.Config.Namespace),
+		TrustDomains: trustDomains,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var vaultPKI vaultpki.Interface
+	{
+		vaultPKI, err = vaultnamespace.NewPKI(vaultnamespace.WrapConfig[vaultpki.Interface]{
+			Namespace:   vaultNamespace,
+			VaultClient: vaultClient,
+			New: func(vaultClient *vaultapi.Client) (vaultpki.Interface, error) {
+				return vaultpki.New(vaultpki.Config{
+					Logger:      c.logger,
+					VaultClient: vaultClient,
+
+					// The CA TTL and common name format are only used to create
+					// CAs, which the migration never does.
+					CATTL:            "-",
+					CommonNameFormat: "-",
+				})
+			},
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var k8sClient k8sclient.Interface
+	{
+		restConfig, err := k8srestconfig.New(k8srestconfig.Config{
+			Logger: c.logger,
+
+			Address:    c.viper.GetString(c.flag.Service.Kubernetes.Address),
+			InCluster:  c.viper.GetBool(c.flag.Service.Kubernetes.InCluster),
+			KubeConfig: c.viper.GetString(c.flag.Service.Kubernetes.KubeConfig),
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		k8sClient, err = k8sclient.NewClients(k8sclient.ClientsConfig{
+			SchemeBuilder: k8sclient.SchemeBuilder{
+				v1alpha1.AddToScheme,
+			},
+			Logger: c.logger,
+
+			RestConfig: restConfig,
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	certConfigs := map[string][]v1alpha1.CertConfig{}
+	{
+		list := &v1alpha1.CertConfigList{}
+		err := k8sClient.CtrlClient().List(ctx, list)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		// The cert configs are grouped by the PKI backend issuing their
+		// certificates, so that e.g. the etcd certificates are mapped to the
+		// issuer of the etcd CA.
+		for _, cc := range list.Items {
+			pkiID := key.PKIID(cc, trustDomains)
+			certConfigs[pkiID] = append(certConfigs[pkiID], cc)
+		}
+	}
+
+	mounts, err := vaultPKI.ListBackends()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var ids []string
+	for k := range mounts {
+		ids = append(ids, vaultpkikey.ClusterIDFromMountPath(k))
+	}
+	sort.Strings(ids)
+
+	var clusters []cluster
+	for _, id := range ids {
+		// The PKI backends of trust domains are not listed as backends, so
+		// they are looked up along with the one of the cluster.
+		pkiIDs := []string{id}
+		for _, d := range trustDomains.Domains() {
+			pkiIDs = append(pkiIDs, trustdomain.PKIID(id, d))
+		}
+
+		for _, pkiID := range pkiIDs {
+			ca, err := vaultPKI.GetCACertificate(pkiID)
+			if vaultpki.IsNotFound(err) {
+				c.logger.Debugf(ctx, "skipping PKI backend %#q of cluster %#q without CA", pkiID, id)
+				continue
+			} else if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			cl := newCluster(id, pkiID, ca.Certificate, ca.PrivateKey, certConfigs[pkiID])
+
+			if cl.CAPrivateKey == "" {
+				cl.CAPrivateKey, err = c.readCAPrivateKey(pkiID)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+			}
+			if cl.CAPrivateKey == "" {
+				cl.CAPrivateKey, err = readCAKeySecret(ctx, k8sClient, cl)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+			}
+
+			clusters = append(clusters, cl)
+		}
+	}
+
+	return clusters, nil
+}
+
+func (c *Command) readCAPrivateKey(pkiID string) (string, error) {
+	if c.caKeyDir == "" {
+		return "", nil
+	}
+
+	b, err := os.ReadFile(filepath.Join(c.caKeyDir, pkiID+".key"))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return string(b), nil
+}
+
+// readCAKeySecret returns the private key of the CA of the given PKI backend
+// stored by the operator in case it got exported when the CA was created, see
+// key.CAKeySecretName.
+func readCAKeySecret(ctx context.Context, k8sClient k8sclient.Interface, cl cluster) (string, error) {
+	secret, err := k8sClient.K8sClient().CoreV1().Secrets(cl.Namespace).Get(ctx, key.CAKeySecretName(cl.PKIID), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return string(secret.Data[corev1.TLSPrivateKeyKey]), nil
+}
+
+// writePlan writes the manifests of the given plan as multi document YAML
+// file. The file contains private keys and is therefore only readable by its
+// owner.
+func (c *Command) writePlan(p plan) error {
+	var buf bytes.Buffer
+	for _, m := range p.Manifests {
+		b, err := yaml.Marshal(m)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		buf.WriteString("---\n")
+		buf.Write(b)
+	}
+
+	err := os.MkdirAll(c.outputDir, 0700)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	path := filepath.Join(c.outputDir, p.PKIID+".yaml")
+	err = os.WriteFile(path, buf.Bytes(), 0600)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	fmt.Printf("  wrote %s\n", path)
+
+	return nil
+}
+
+// newCluster groups the given PKI backend of a tenant cluster and the cert
+// configs it issues. The migrated resources are placed in the namespace of
+// the cert configs, or the default namespace in case there are none.
+func newCluster(id, pkiID, caCertificate, caPrivateKey string, certConfigs []v1alpha1.CertConfig) cluster {
+	namespace := metav1.NamespaceDefault
+	if len(certConfigs) != 0 {
+		namespace = certConfigs[0].Namespace
+	}
+
+	sort.Slice(certConfigs, func(i, j int) bool {
+		return strings.Compare(certConfigs[i].Name, certConfigs[j].Name) < 0
+	})
+
+	return cluster{
+		ID:        id,
+		PKIID:     pkiID,
+		Namespace: namespace,
+
+		CACertificate: caCertificate,
+		CAPrivateKey:  caPrivateKey,
+
+		CertConfigs: certConfigs,
+	}
+}
@@ -0,0 +1,239 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/annotation"
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+const (
+	TargetCAPI        = "capi"
+	TargetCertManager = "cert-manager"
+)
+
+const (
+	certManagerAPIVersion = "cert-manager.io/v1"
+	certManagerGroup      = "cert-manager.io"
+)
+
+// cluster is a PKI backend of a tenant cluster as read from Vault.
+type cluster struct {
+	ID string
+	// PKIID is the ID of the PKI backend. It is the cluster ID for the
+	// default trust domain, see trustdomain.PKIID.
+	PKIID     string
+	Namespace string
+
+	CACertificate string
+	// CAPrivateKey is only known in case it got exported when the CA was
+	// created, or it is given to the command. Vault does not hand out the
+	// private key of existing CAs.
+	CAPrivateKey string
+
+	CertConfigs []v1alpha1.CertConfig
+}
+
+// plan is the outcome of the migration of a single PKI backend of a tenant
+// cluster.
+type plan struct {
+	ClusterID string
+	PKIID     string
+	// Manifests are the objects to write, in the order they have to be
+	// applied.
+	Manifests []interface{}
+	// Notes are human readable explanations of what is migrated and what is
+	// not.
+	Notes []string
+}
+
+// newPlan computes the manifests replacing the PKI of the given cluster for
+// the given target. The CA is written as secret in the format of the target.
+// The issuer and the certificates replacing the cert configs require the
+// private key of the CA, since cert-manager has no way to sign with a CA whose
+// private key is kept in Vault. Without private key they are skipped and the
+// cert configs have to stay with cert-operator. The certificates write the
+// secrets of the cert configs, so that workloads keep using them. They are
+// therefore only written for paused cert configs, which cert-operator does not
+// touch anymore.
+func newPlan(target string, c cluster) (plan, error) {
+	p := plan{
+		ClusterID: c.ID,
+		PKIID:     c.PKIID,
+	}
+
+	var caSecret *corev1.Secret
+	switch target {
+	case TargetCAPI:
+		caSecret = newCAPICASecret(c)
+	case TargetCertManager:
+		caSecret = newCertManagerCASecret(c)
+	default:
+		return plan{}, microerror.Maskf(invalidFlagError, "target must be one of %#q and %#q, got %#q", TargetCAPI, TargetCertManager, target)
+	}
+
+	p.Manifests = append(p.Manifests, caSecret)
+	p.Notes = append(p.Notes, fmt.Sprintf("CA secret %s/%s", caSecret.Namespace, caSecret.Name))
+
+	if c.CAPrivateKey == "" {
+		p.Notes = append(p.Notes, "the private key of the CA is not known, the secret only contains the CA certificate")
+		for _, cc := range c.CertConfigs {
+			p.Notes = append(p.Notes, fmt.Sprintf("skipping certificate %s/%s because there is no issuer without the private key of the CA, provide it with --ca-key-dir", cc.Namespace, cc.Name))
+		}
+
+		return p, nil
+	}
+
+	issuer := newIssuer(c, caSecret.Name)
+	p.Manifests = append(p.Manifests, issuer)
+	p.Notes = append(p.Notes, fmt.Sprintf("issuer %s/%s", c.Namespace, issuerName(c)))
+
+	for _, cc := range c.CertConfigs {
+		if !key.IsPaused(cc) {
+			p.Notes = append(p.Notes, fmt.Sprintf("skipping certificate %s/%s because cert-operator still manages secret %#q, pause the cert config with the %#q annotation first", cc.Namespace, cc.Name, key.SecretName(cc), annotation.Paused))
+			continue
+		}
+
+		p.Manifests = append(p.Manifests, newCertificate(c, cc))
+		p.Notes = append(p.Notes, fmt.Sprintf("certificate %s/%s writing secret %#q", cc.Namespace, cc.Name, key.SecretName(cc)))
+	}
+
+	return p, nil
+}
+
+func caSecretName(c cluster) string {
+	return fmt.Sprintf("%s-ca", c.PKIID)
+}
+
+func issuerName(c cluster) string {
+	return fmt.Sprintf("%s-ca", c.PKIID)
+}
+
+// newCAPICASecret returns the CA secret in the format Cluster API expects.
+// Without private key Cluster API treats the CA as external CA.
+func newCAPICASecret(c cluster) *corev1.Secret {
+	data := map[string][]byte{
+		corev1.TLSCertKey: []byte(c.CACertificate),
+	}
+	if c.CAPrivateKey != "" {
+		data[corev1.TLSPrivateKeyKey] = []byte(c.CAPrivateKey)
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      caSecretName(c),
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				capi.ClusterLabelName: c.ID,
+				label.Cluster:         c.ID,
+			},
+		},
+		Type: capi.ClusterSecretType,
+		Data: data,
+	}
+}
+
+// newCertManagerCASecret returns the CA secret in the format the CA issuer of
+// cert-manager expects. Without private key the secret can only be used to
+// distribute the CA certificate.
+func newCertManagerCASecret(c cluster) *corev1.Secret {
+	s := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      caSecretName(c),
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				label.Cluster: c.ID,
+			},
+		},
+	}
+
+	if c.CAPrivateKey != "" {
+		s.Type = corev1.SecretTypeTLS
+		s.Data = map[string][]byte{
+			corev1.TLSCertKey:       []byte(c.CACertificate),
+			corev1.TLSPrivateKeyKey: []byte(c.CAPrivateKey),
+		}
+	} else {
+		s.Type = corev1.SecretTypeOpaque
+		s.Data = map[string][]byte{
+			"ca.crt": []byte(c.CACertificate),
+		}
+	}
+
+	return s
+}
+
+func newIssuer(c cluster, secretName string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": certManagerAPIVersion,
+		"kind":       "Issuer",
+		"metadata": map[string]interface{}{
+			"name":      issuerName(c),
+			"namespace": c.Namespace,
+			"labels": map[string]string{
+				label.Cluster: c.ID,
+			},
+		},
+		"spec": map[string]interface{}{
+			"ca": map[string]interface{}{
+				"secretName": secretName,
+			},
+		},
+	}
+}
+
+// newCertificate maps the given cert config to a cert-manager certificate
+// writing the secret of the same name. Note that cert-manager uses the
+// tls.crt, tls.key and ca.crt keys instead of crt, key and ca.
+func newCertificate(c cluster, cc v1alpha1.CertConfig) map[string]interface{} {
+	dnsNames := []string{key.CommonName(cc)}
+	for _, n := range key.AltNames(cc) {
+		if n != key.CommonName(cc) {
+			dnsNames = append(dnsNames, n)
+		}
+	}
+
+	spec := map[string]interface{}{
+		"secretName": key.SecretName(cc),
+		"commonName": key.CommonName(cc),
+		"dnsNames":   dnsNames,
+		"issuerRef": map[string]interface{}{
+			"group": certManagerGroup,
+			"kind":  "Issuer",
+			"name":  issuerName(c),
+		},
+	}
+	// Without TTL the certificate falls back to the default duration of
+	// cert-manager.
+	if key.CrtTTL(cc) != "" {
+		spec["duration"] = key.CrtTTL(cc)
+	}
+	if len(key.IPSANs(cc)) != 0 {
+		spec["ipAddresses"] = key.IPSANs(cc)
+	}
+	if len(key.Organizations(cc)) != 0 {
+		spec["subject"] = map[string]interface{}{
+			"organizations": key.Organizations(cc),
+		}
+	}
+
+	return map[string]interface{}{
+		"apiVersion": certManagerAPIVersion,
+		"kind":       "Certificate",
+		"metadata": map[string]interface{}{
+			"name":      cc.Name,
+			"namespace": cc.Namespace,
+			"labels":    key.SecretLabels(cc),
+		},
+		"spec": spec,
+	}
+}
@@ -0,0 +1,219 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/annotation"
+)
+
+func Test_newPlan(t *testing.T) {
+	certConfigs := []v1alpha1.CertConfig{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "al9qy-api",
+				Namespace:   "org-giantswarm",
+				Annotations: map[string]string{annotation.Paused: "true"},
+			},
+			Spec: v1alpha1.CertConfigSpec{
+				Cert: v1alpha1.CertConfigSpecCert{
+					AltNames:         []string{"kubernetes", "api.al9qy.k8s.gigantic.io"},
+					ClusterComponent: "api",
+					ClusterID:        "al9qy",
+					CommonName:       "api.al9qy.k8s.gigantic.io",
+					IPSANs:           []string{"127.0.0.1"},
+					Organizations:    []string{"system:masters"},
+					TTL:              "4320h",
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "al9qy-etcd", Namespace: "org-giantswarm"},
+			Spec: v1alpha1.CertConfigSpec{
+				Cert: v1alpha1.CertConfigSpecCert{
+					ClusterComponent: "etcd",
+					ClusterID:        "al9qy",
+					CommonName:       "etcd.al9qy.k8s.gigantic.io",
+					TTL:              "720h",
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		target             string
+		caPrivateKey       string
+		expectedManifests  int
+		expectedSecretType corev1.SecretType
+		errorMatcher       func(error) bool
+	}{
+		{
+			name:               "case 0: cert-manager target with private key",
+			target:             TargetCertManager,
+			caPrivateKey:       "key",
+			expectedManifests:  3,
+			expectedSecretType: corev1.SecretTypeTLS,
+		},
+		{
+			name:               "case 1: cert-manager target without private key",
+			target:             TargetCertManager,
+			expectedManifests:  1,
+			expectedSecretType: corev1.SecretTypeOpaque,
+		},
+		{
+			name:               "case 2: CAPI target with private key",
+			target:             TargetCAPI,
+			caPrivateKey:       "key",
+			expectedManifests:  3,
+			expectedSecretType: capi.ClusterSecretType,
+		},
+		{
+			name:               "case 3: CAPI target without private key",
+			target:             TargetCAPI,
+			expectedManifests:  1,
+			expectedSecretType: capi.ClusterSecretType,
+		},
+		{
+			name:         "case 4: unknown target",
+			target:       "vault",
+			errorMatcher: IsInvalidFlag,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newCluster("al9qy", "al9qy", "ca", tc.caPrivateKey, append([]v1alpha1.CertConfig{}, certConfigs...))
+
+			p, err := newPlan(tc.target, c)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected error matcher to match, got %#v", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("expected nil error, got %#v", err)
+			}
+
+			if len(p.Manifests) != tc.expectedManifests {
+				t.Fatalf("expected %d manifests, got %d", tc.expectedManifests, len(p.Manifests))
+			}
+
+			secret, ok := p.Manifests[0].(*corev1.Secret)
+			if !ok {
+				t.Fatalf("expected the CA secret first, got %T", p.Manifests[0])
+			}
+			if secret.Name != "al9qy-ca" || secret.Namespace != "org-giantswarm" {
+				t.Fatalf("expected CA secret %#q, got %#q", "org-giantswarm/al9qy-ca", secret.Namespace+"/"+secret.Name)
+			}
+			if secret.Type != tc.expectedSecretType {
+				t.Fatalf("expected secret type %#q, got %#q", tc.expectedSecretType, secret.Type)
+			}
+			if (tc.caPrivateKey != "") != (len(secret.Data[corev1.TLSPrivateKeyKey]) != 0) {
+				t.Fatalf("expected private key %#q, got %#q", tc.caPrivateKey, secret.Data[corev1.TLSPrivateKeyKey])
+			}
+		})
+	}
+}
+
+func Test_newPlan_trustDomain(t *testing.T) {
+	certConfigs := []v1alpha1.CertConfig{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "al9qy-etcd",
+				Namespace:   "org-giantswarm",
+				Annotations: map[string]string{annotation.Paused: "true"},
+			},
+			Spec: v1alpha1.CertConfigSpec{
+				Cert: v1alpha1.CertConfigSpecCert{
+					ClusterComponent: "etcd",
+					ClusterID:        "al9qy",
+					CommonName:       "etcd.al9qy.k8s.gigantic.io",
+				},
+			},
+		},
+	}
+
+	p, err := newPlan(TargetCertManager, newCluster("al9qy", "al9qy-etcd", "ca", "key", certConfigs))
+	if err != nil {
+		t.Fatalf("expected nil error, got %#v", err)
+	}
+
+	if p.ClusterID != "al9qy" || p.PKIID != "al9qy-etcd" {
+		t.Fatalf("expected plan of PKI backend %#q of cluster %#q, got %#q of %#q", "al9qy-etcd", "al9qy", p.PKIID, p.ClusterID)
+	}
+
+	secret := p.Manifests[0].(*corev1.Secret)
+	if secret.Name != "al9qy-etcd-ca" {
+		t.Fatalf("expected CA secret %#q, got %#q", "al9qy-etcd-ca", secret.Name)
+	}
+
+	certificate := p.Manifests[2].(map[string]interface{})
+	issuerRef := certificate["spec"].(map[string]interface{})["issuerRef"].(map[string]interface{})
+	if issuerRef["name"] != "al9qy-etcd-ca" {
+		t.Fatalf("expected issuer %#q, got %#q", "al9qy-etcd-ca", issuerRef["name"])
+	}
+}
+
+func Test_newCertificate(t *testing.T) {
+	c := newCluster("al9qy", "al9qy", "ca", "key", nil)
+	cc := v1alpha1.CertConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "al9qy-api", Namespace: "org-giantswarm"},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				AltNames:         []string{"kubernetes", "api.al9qy.k8s.gigantic.io"},
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				CommonName:       "api.al9qy.k8s.gigantic.io",
+				IPSANs:           []string{"127.0.0.1"},
+				Organizations:    []string{"system:masters"},
+				TTL:              "4320h",
+			},
+		},
+	}
+
+	expectedSpec := map[string]interface{}{
+		"secretName": "al9qy-api",
+		"commonName": "api.al9qy.k8s.gigantic.io",
+		"dnsNames":   []string{"api.al9qy.k8s.gigantic.io", "kubernetes"},
+		"duration":   "4320h",
+		"issuerRef": map[string]interface{}{
+			"group": "cert-manager.io",
+			"kind":  "Issuer",
+			"name":  "al9qy-ca",
+		},
+		"ipAddresses": []string{"127.0.0.1"},
+		"subject": map[string]interface{}{
+			"organizations": []string{"api", "system:masters"},
+		},
+	}
+
+	certificate := newCertificate(c, cc)
+	if !reflect.DeepEqual(certificate["spec"], expectedSpec) {
+		t.Fatalf("expected %#v got %#v", expectedSpec, certificate["spec"])
+	}
+}
+
+func Test_newCertificate_noTTL(t *testing.T) {
+	c := newCluster("al9qy", "al9qy", "ca", "key", nil)
+	cc := v1alpha1.CertConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "al9qy-worker", Namespace: "org-giantswarm"},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: "worker",
+				ClusterID:        "al9qy",
+				CommonName:       "worker.al9qy.k8s.gigantic.io",
+			},
+		},
+	}
+
+	certificate := newCertificate(c, cc)
+	if d, ok := certificate["spec"].(map[string]interface{})["duration"]; ok {
+		t.Fatalf("expected no duration, got %#q", d)
+	}
+}
@@ -0,0 +1,11 @@
+package capi
+
+import (
+	"github.com/giantswarm/cert-operator/v3/flag/service/capi/certconfigs"
+)
+
+type CAPI struct {
+	CertConfigs certconfigs.CertConfigs
+	CleanupPKI  string
+	Secrets     string
+}
@@ -0,0 +1,6 @@
+package certconfigs
+
+type CertConfigs struct {
+	Create   string
+	Template string
+}
@@ -0,0 +1,5 @@
+package issuer
+
+type Issuer struct {
+	Enabled string
+}
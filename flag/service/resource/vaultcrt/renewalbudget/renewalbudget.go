@@ -0,0 +1,6 @@
+package renewalbudget
+
+type RenewalBudget struct {
+	PerCluster string
+	PerMinute  string
+}
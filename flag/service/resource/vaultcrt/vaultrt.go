@@ -1,6 +1,14 @@
 package vaultcrt
 
+import (
+	"github.com/giantswarm/cert-operator/v3/flag/service/resource/vaultcrt/renewalbudget"
+)
+
 type VaultCrt struct {
 	ExpirationThreshold string
 	Namespace           string
+	ReloadWorkloads     string
+	RenewalBudget       renewalbudget.RenewalBudget
+	RenewalJitter       string
+	RenewalOverlap      string
 }
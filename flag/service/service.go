@@ -4,14 +4,18 @@ import (
 	"github.com/giantswarm/operatorkit/v7/pkg/flag/service/kubernetes"
 
 	"github.com/giantswarm/cert-operator/v3/flag/service/app"
+	"github.com/giantswarm/cert-operator/v3/flag/service/capi"
 	"github.com/giantswarm/cert-operator/v3/flag/service/crd"
+	"github.com/giantswarm/cert-operator/v3/flag/service/issuer"
 	"github.com/giantswarm/cert-operator/v3/flag/service/resource"
 	"github.com/giantswarm/cert-operator/v3/flag/service/vault"
 )
 
 type Service struct {
 	App        app.App
+	CAPI       capi.CAPI
 	CRD        crd.CRD
+	Issuer     issuer.Issuer
 	Kubernetes kubernetes.Kubernetes
 	Resource   resource.Resource
 	Vault      vault.Vault
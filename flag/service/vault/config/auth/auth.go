@@ -0,0 +1,12 @@
+package auth
+
+import (
+	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/auth/approle"
+	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/auth/cert"
+)
+
+type Auth struct {
+	AppRole approle.AppRole
+	Cert    cert.Cert
+	Method  string
+}
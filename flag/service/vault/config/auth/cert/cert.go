@@ -0,0 +1,6 @@
+package cert
+
+type Cert struct {
+	MountPath string
+	Name      string
+}
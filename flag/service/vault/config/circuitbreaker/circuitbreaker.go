@@ -0,0 +1,7 @@
+package circuitbreaker
+
+type CircuitBreaker struct {
+	FailureThreshold string
+	MaxOpenDuration  string
+	OpenDuration     string
+}
@@ -1,12 +1,24 @@
 package config
 
 import (
+	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/auth"
+	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/cache"
+	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/circuitbreaker"
 	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/pki"
+	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/tls"
 )
 
 type Config struct {
-	Address string
-	Token   string
+	Address        string
+	Addresses      string
+	Auth           auth.Auth
+	Cache          cache.Cache
+	CircuitBreaker circuitbreaker.CircuitBreaker
+	MaxRetries     string
+	Namespace      string
+	Timeout        string
+	TLS            tls.TLS
+	Token          string
 
 	PKI pki.PKI
 }
@@ -1,5 +1,6 @@
 package ca
 
 type CA struct {
-	TTL string
+	ExpirationThreshold string
+	TTL                 string
 }
@@ -0,0 +1,8 @@
+package constraints
+
+type Constraints struct {
+	Enabled             string
+	MaxPathLength       string
+	PermittedDNSDomains string
+	PermittedIPRanges   string
+}
@@ -0,0 +1,8 @@
+package intermediate
+
+type Intermediate struct {
+	Enabled       string
+	ParentCrtFile string
+	ParentKeyFile string
+	ParentMount   string
+}
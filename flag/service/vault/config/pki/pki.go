@@ -3,9 +3,14 @@ package pki
 import (
 	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/pki/ca"
 	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/pki/commonname"
+	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/pki/constraints"
+	"github.com/giantswarm/cert-operator/v3/flag/service/vault/config/pki/intermediate"
 )
 
 type PKI struct {
-	CA         ca.CA
-	CommonName commonname.CommonName
+	CA           ca.CA
+	CommonName   commonname.CommonName
+	Constraints  constraints.Constraints
+	Intermediate intermediate.Intermediate
+	TrustDomains string
 }
@@ -0,0 +1,8 @@
+package tls
+
+type TLS struct {
+	CAFile     string
+	CrtFile    string
+	KeyFile    string
+	ServerName string
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/viper"
 
+	"github.com/giantswarm/cert-operator/v3/command/migrate"
 	"github.com/giantswarm/cert-operator/v3/flag"
 	"github.com/giantswarm/cert-operator/v3/pkg/project"
 	"github.com/giantswarm/cert-operator/v3/server"
@@ -95,6 +96,24 @@ func main() {
 		}
 	}
 
+	// Create the migrate command, which exports the PKI of tenant clusters into
+	// cert-manager or Cluster API resources.
+	var migrateCommand *migrate.Command
+	{
+		c := migrate.Config{
+			Flag:   f,
+			Logger: newLogger,
+			Viper:  viper.New(),
+		}
+
+		migrateCommand, err = migrate.New(c)
+		if err != nil {
+			panic(fmt.Sprintf("%#v\n", err))
+		}
+	}
+
+	newCommand.CobraCommand().AddCommand(migrateCommand.CobraCommand())
+
 	daemonCommand := newCommand.DaemonCommand().CobraCommand()
 
 	daemonCommand.PersistentFlags().String(f.Service.CRD.LabelSelector, "", "Label selector for CRD informer ListOptions.")
@@ -108,12 +127,50 @@ func main() {
 
 	daemonCommand.PersistentFlags().Duration(f.Service.Resource.VaultCrt.ExpirationThreshold, 0, "Amount of time to renew certificates before their expiration date.")
 	daemonCommand.PersistentFlags().String(f.Service.Resource.VaultCrt.Namespace, "", "Namespace used to manage Kubernetes secrets in.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Resource.VaultCrt.ReloadWorkloads, false, "Whether to restart the workloads consuming certificate secrets after renewals.")
+	daemonCommand.PersistentFlags().Int(f.Service.Resource.VaultCrt.RenewalBudget.PerCluster, 0, "Maximum number of certificate renewals per minute and Tenant Cluster. Zero disables the limit.")
+	daemonCommand.PersistentFlags().Int(f.Service.Resource.VaultCrt.RenewalBudget.PerMinute, 0, "Maximum number of certificate renewals per minute across all Tenant Clusters. Zero disables the limit.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Resource.VaultCrt.RenewalJitter, 0, "Maximum delay added to the renewal time of each certificate. Must be smaller than the expiration threshold.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Resource.VaultCrt.RenewalOverlap, 0, "Period during which the previous certificate is kept in the secret after a renewal. Zero disables the overlap.")
 
 	daemonCommand.PersistentFlags().Bool(f.Service.App.Unique, false, "Whether the operator is deployed as a unique app.")
+	daemonCommand.PersistentFlags().Bool(f.Service.CAPI.CertConfigs.Create, false, "Whether to create the CertConfigs given in the template for CAPI clusters.")
+	daemonCommand.PersistentFlags().String(f.Service.CAPI.CertConfigs.Template, "[]", "YAML list of the CertConfigs to create for CAPI clusters.")
+	daemonCommand.PersistentFlags().Bool(f.Service.CAPI.CleanupPKI, false, "Whether to tear down the PKI of CAPI clusters once they got deleted.")
+	daemonCommand.PersistentFlags().Bool(f.Service.CAPI.Secrets, false, "Whether to also write the CA, service account and kubeconfig secrets in the format of CAPI, exporting the private keys of new CAs from Vault.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Issuer.Enabled, false, "Whether to sign cert-manager CertificateRequests of ClusterPKIIssuers.")
 	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.Address, "", "Address used to connect to Vault.")
-	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.Token, "", "Token used to authenticate against Vault.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Vault.Config.Addresses, nil, "Ordered list of standby Vault addresses used when the Vault at the preferred address is unhealthy.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.Auth.AppRole.MountPath, "approle", "Path the AppRole auth method is mounted at in Vault.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.Auth.AppRole.RoleID, "", "Role ID used to log in to Vault with the AppRole auth method.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.Auth.AppRole.SecretIDFile, "", "File path of the secret ID used to log in to Vault with the AppRole auth method. The file is read again on every login.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.Auth.Cert.MountPath, "cert", "Path the TLS certificate auth method is mounted at in Vault.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.Auth.Cert.Name, "", "Name of the certificate role used to log in to Vault with the TLS certificate auth method. All matching roles are tried if empty.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.Auth.Method, "token", "Method used to authenticate against Vault, one of token, approle or cert.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Vault.Config.Cache.TTL, 0, "Duration Vault PKI backends, CA certificates and roles are cached for across reconciliations. Zero disables the cache.")
+	daemonCommand.PersistentFlags().Int(f.Service.Vault.Config.CircuitBreaker.FailureThreshold, 0, "Number of consecutive Vault requests failing due to an outage after which Vault requests are rejected. Zero disables the circuit breaker.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Vault.Config.CircuitBreaker.MaxOpenDuration, 0, "Maximum duration Vault requests are rejected for before Vault is probed again.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Vault.Config.CircuitBreaker.OpenDuration, 0, "Duration Vault requests are rejected for before Vault is probed again. It doubles with every failed probe.")
+	daemonCommand.PersistentFlags().Int(f.Service.Vault.Config.MaxRetries, 0, "Maximum number of retries of failed Vault requests. Zero keeps the default of the Vault client.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.Namespace, "", "Vault Enterprise namespace of the PKI backends. %s in its last path element is replaced by the Cluster ID. Tokens are managed in its parent namespace then. Empty uses the root namespace.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Vault.Config.Timeout, 0, "Timeout of Vault requests. Zero keeps the default of the Vault client.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.TLS.CAFile, "", "Certificate authority file path used to verify the certificate of Vault.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.TLS.CrtFile, "", "Certificate file path presented to Vault as client certificate.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.TLS.KeyFile, "", "Key file path of the client certificate presented to Vault.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.TLS.ServerName, "", "Server name used to verify the certificate of Vault. Defaults to the host of the Vault address.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.Token, "", "Token used to authenticate against Vault with the token auth method.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Vault.Config.PKI.CA.ExpirationThreshold, 0, "Remaining lifetime of Cluster CAs below which the CAExpiringSoon condition of CAPI clusters is set. Zero disables the condition.")
 	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.PKI.CA.TTL, "", "TTL used to generate a new Cluster CA.")
 	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.PKI.CommonName.Format, "", "Common name used to generate a new Cluster CA.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Vault.Config.PKI.Constraints.Enabled, false, "Whether to restrict new Cluster CAs with name and path length constraints.")
+	daemonCommand.PersistentFlags().Int(f.Service.Vault.Config.PKI.Constraints.MaxPathLength, 0, "Maximum number of CAs which may follow a constrained Cluster CA in a chain. -1 does not limit it.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Vault.Config.PKI.Constraints.PermittedDNSDomains, nil, "DNS domains constrained Cluster CAs may sign certificates for. %s is replaced by the Cluster ID. Defaults to the common name format.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Vault.Config.PKI.Constraints.PermittedIPRanges, nil, "CIDRs of the IP addresses constrained Cluster CAs may sign certificates for. IP addresses are not restricted if none are given.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Vault.Config.PKI.Intermediate.Enabled, false, "Whether to create new Cluster CAs as intermediate CAs signed by the configured parent.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.PKI.Intermediate.ParentCrtFile, "", "Certificate file path of the root CA signing the intermediate Cluster CAs.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.PKI.Intermediate.ParentKeyFile, "", "Key file path of the root CA signing the intermediate Cluster CAs.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.PKI.Intermediate.ParentMount, "", "Path of the Vault PKI mount signing the intermediate Cluster CAs.")
+	daemonCommand.PersistentFlags().String(f.Service.Vault.Config.PKI.TrustDomains, "", "YAML map of cluster components to the trust domains whose own PKI backend issues their certificates.")
 
 	if err := newCommand.CobraCommand().Execute(); err != nil {
 		panic(fmt.Sprintf("%#v\n", err))
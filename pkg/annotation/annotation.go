@@ -0,0 +1,32 @@
+package annotation
+
+const (
+	// CASecret is the annotation key used on CertConfigs and CAPI Clusters to
+	// name the secret holding the CA to import into the PKI backend of the
+	// tenant cluster, instead of generating one. The secret must be in the
+	// namespace of the annotated object and hold the PEM encoded CA
+	// certificate and private key under tls.crt and tls.key.
+	CASecret = "cert-operator.giantswarm.io/ca-secret"
+	// ForceRenew is the annotation key used on CertConfigs to request the
+	// reissuance of the certificate. The annotation is removed once the
+	// certificate got reissued.
+	ForceRenew = "cert-operator.giantswarm.io/force-renew"
+	// Paused is the annotation key used on CertConfigs to stop cert-operator
+	// from reconciling them, e.g. during an incident.
+	Paused = "cert-operator.giantswarm.io/paused"
+	// ReloadSelector is the annotation key used on CertConfigs to select the
+	// Deployments, StatefulSets and DaemonSets to restart after renewals by
+	// their labels, in addition to the ones referencing the certificate
+	// secret.
+	ReloadSelector = "cert-operator.giantswarm.io/reload-selector"
+	// RestartedForSerial is the pod template annotation key used on workloads
+	// restarted after renewals. Its value is the serial number of the renewed
+	// certificate.
+	RestartedForSerial = "cert-operator.giantswarm.io/restarted-for-serial"
+	// TrustDomain is the annotation key used on CertConfigs to pin the trust
+	// domain, and with it the Vault PKI backend, their certificate is issued
+	// by. It takes precedence over the configured trust domain mapping, but
+	// only selects among the default trust domain and the trust domains of the
+	// mapping.
+	TrustDomain = "cert-operator.giantswarm.io/trust-domain"
+)
@@ -0,0 +1,56 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	kindClusterPKIIssuer = "ClusterPKIIssuer"
+)
+
+func NewClusterPKIIssuerTypeMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{
+		APIVersion: SchemeGroupVersion.String(),
+		Kind:       kindClusterPKIIssuer,
+	}
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster
+
+// ClusterPKIIssuer is a cert-manager external issuer signing the
+// CertificateRequests referencing it with the Vault PKI backend of a tenant
+// cluster.
+type ClusterPKIIssuer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ClusterPKIIssuerSpec `json:"spec"`
+}
+
+// +k8s:openapi-gen=true
+type ClusterPKIIssuerSpec struct {
+	// +kubebuilder:validation:Optional
+	// Domains certificates are issued for. Their subdomains are allowed as
+	// well. Defaults to the common name of the workload cluster.
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+	// Workload cluster ID whose Vault PKI backend signs the certificates.
+	ClusterID string `json:"clusterID"`
+	// +kubebuilder:validation:Optional
+	// Duration of the certificates of CertificateRequests which do not specify
+	// a duration themselves.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Organizations of the issued certificates. CertificateRequests asking
+	// for other organizations are rejected.
+	Organizations []string `json:"organizations,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ClusterPKIIssuerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ClusterPKIIssuer `json:"items"`
+}
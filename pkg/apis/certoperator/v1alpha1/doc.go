@@ -0,0 +1,5 @@
+// +k8s:deepcopy-gen=package,register
+// +groupName=cert-operator.giantswarm.io
+
+// Package v1alpha1 contains the API types served by cert-operator itself.
+package v1alpha1
@@ -0,0 +1,188 @@
+// Package caconstraint restricts the names the CAs of tenant cluster PKI
+// backends may sign certificates for, and the number of CAs which may follow
+// them in a chain. The constraints are embedded into the CA certificates when
+// they are generated, so that clients reject certificates for other names even
+// if the CA signs them.
+package caconstraint
+
+import (
+	"crypto/x509"
+	"net"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// NoMaxPathLength does not limit the number of CAs which may follow a CA
+	// in a chain.
+	NoMaxPathLength = -1
+)
+
+// KubernetesDNSNames are the names of the Kubernetes API service, which the
+// certificates of API servers and workers carry as alternative names. The CAs
+// must always permit them.
+var KubernetesDNSNames = []string{
+	"kubernetes",
+	"kubernetes.default",
+	"kubernetes.default.svc",
+	"kubernetes.default.svc.cluster.local",
+}
+
+type Config struct {
+	CommonNameFormat string
+	// DNSNames are the formats of further names the CAs have to permit, e.g.
+	// the alternative names of the CertConfigs created from a template. "%s"
+	// is replaced by the cluster ID.
+	DNSNames []string
+	// MaxPathLength is the maximum number of CAs which may follow the CA in a
+	// chain. 0 only allows the CA to sign leaf certificates. NoMaxPathLength
+	// does not limit it.
+	MaxPathLength int
+	// PermittedDNSDomains are the formats of the DNS domains the CA may sign
+	// certificates for, e.g. "%s.k8s.gigantic.io". "%s" is replaced by the
+	// cluster ID. Subdomains are permitted as well. Defaults to
+	// CommonNameFormat, KubernetesDNSNames and DNSNames. Given domains must
+	// permit KubernetesDNSNames and DNSNames.
+	PermittedDNSDomains []string
+	// PermittedIPRanges are the CIDRs of the IP addresses the CA may sign
+	// certificates for. IP addresses are not restricted if none are given.
+	PermittedIPRanges []string
+}
+
+// Constraints are the constraints of the CA of a single cluster.
+type Constraints struct {
+	MaxPathLength       int
+	PermittedDNSDomains []string
+	PermittedIPRanges   []*net.IPNet
+}
+
+// Policy derives the Constraints of the CAs of tenant clusters.
+type Policy struct {
+	maxPathLength       int
+	permittedDNSDomains []string
+	permittedIPRanges   []*net.IPNet
+}
+
+func New(config Config) (*Policy, error) {
+	if config.MaxPathLength < NoMaxPathLength {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MaxPathLength must not be lower than %d", config, NoMaxPathLength)
+	}
+
+	p := &Policy{
+		maxPathLength: config.MaxPathLength,
+	}
+
+	// Certificates carrying a name outside of the permitted domains fail
+	// verification against the CA, so the names known to be issued must be
+	// permitted.
+	dnsNames := append(append([]string{}, KubernetesDNSNames...), config.DNSNames...)
+
+	if len(config.PermittedDNSDomains) == 0 {
+		if config.CommonNameFormat == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.CommonNameFormat must not be empty", config)
+		}
+		p.permittedDNSDomains = []string{config.CommonNameFormat}
+		for _, n := range dnsNames {
+			if !permits(p.permittedDNSDomains, n) {
+				p.permittedDNSDomains = append(p.permittedDNSDomains, n)
+			}
+		}
+	}
+	for _, d := range config.PermittedDNSDomains {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.PermittedDNSDomains must not contain empty domains", config)
+		}
+		p.permittedDNSDomains = append(p.permittedDNSDomains, d)
+	}
+	for _, n := range dnsNames {
+		if !permits(p.permittedDNSDomains, n) {
+			return nil, microerror.Maskf(invalidConfigError, "%T.PermittedDNSDomains must permit %#q", config, n)
+		}
+	}
+
+	for _, r := range config.PermittedIPRanges {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(r))
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "%T.PermittedIPRanges must only contain CIDRs, got %#q", config, r)
+		}
+		p.permittedIPRanges = append(p.permittedIPRanges, n)
+	}
+
+	return p, nil
+}
+
+// Constraints returns the constraints of the CA of the given cluster. The
+// cluster ID is the one of the tenant cluster, not the ID of the PKI backend of
+// one of its trust domains, so that the CAs of all trust domains permit the
+// names of the cluster.
+func (p *Policy) Constraints(clusterID string) Constraints {
+	c := Constraints{
+		MaxPathLength:     p.maxPathLength,
+		PermittedIPRanges: p.permittedIPRanges,
+	}
+
+	for _, d := range p.permittedDNSDomains {
+		c.PermittedDNSDomains = append(c.PermittedDNSDomains, strings.ReplaceAll(d, "%s", clusterID))
+	}
+
+	return c
+}
+
+// Permits returns whether the given DNS name is permitted by the constraints.
+func (c Constraints) Permits(name string) bool {
+	return permits(c.PermittedDNSDomains, name)
+}
+
+// Apply adds the constraints to the given CA certificate template.
+func (c Constraints) Apply(template *x509.Certificate) {
+	// RFC 5280 requires name constraints to be marked critical.
+	template.PermittedDNSDomainsCritical = true
+	template.PermittedDNSDomains = c.PermittedDNSDomains
+	template.PermittedIPRanges = c.PermittedIPRanges
+
+	if c.MaxPathLength != NoMaxPathLength {
+		template.MaxPathLen = c.MaxPathLength
+		template.MaxPathLenZero = c.MaxPathLength == 0
+	}
+}
+
+// VaultParams returns the parameters of the Vault endpoints generating and
+// signing CAs which embed the constraints. IP ranges are only given if there
+// are any, because only recent Vault versions support them.
+func (c Constraints) VaultParams() map[string]interface{} {
+	params := map[string]interface{}{
+		"max_path_length":       c.MaxPathLength,
+		"permitted_dns_domains": c.PermittedDNSDomains,
+	}
+
+	if len(c.PermittedIPRanges) > 0 {
+		var ranges []string
+		for _, r := range c.PermittedIPRanges {
+			ranges = append(ranges, r.String())
+		}
+		params["permitted_ip_ranges"] = ranges
+	}
+
+	return params
+}
+
+// permits returns whether the given name is one of the given domains or a
+// subdomain of them. Domains with a leading period only permit subdomains.
+// This is how x509 matches names against permitted DNS domains.
+func permits(domains []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, d := range domains {
+		d = strings.ToLower(d)
+		if strings.HasPrefix(d, ".") {
+			if strings.HasSuffix(name, d) {
+				return true
+			}
+		} else if name == d || strings.HasSuffix(name, "."+d) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,294 @@
+package caconstraint
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Policy_Constraints(t *testing.T) {
+	_, ipRange, err := net.ParseCIDR("172.31.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name                string
+		config              Config
+		expectedConstraints Constraints
+		errorMatcher        func(error) bool
+	}{
+		{
+			name: "case 0: DNS domains default to the common name format and the Kubernetes API service names",
+			config: Config{
+				CommonNameFormat: "%s.k8s.gigantic.io",
+			},
+			expectedConstraints: Constraints{
+				PermittedDNSDomains: []string{
+					"al9qy.k8s.gigantic.io",
+					"kubernetes",
+					"kubernetes.default",
+					"kubernetes.default.svc",
+					"kubernetes.default.svc.cluster.local",
+				},
+			},
+		},
+		{
+			name: "case 1: DNS domains and IP ranges are configurable",
+			config: Config{
+				CommonNameFormat:    "%s.k8s.gigantic.io",
+				MaxPathLength:       NoMaxPathLength,
+				PermittedDNSDomains: []string{"%s.k8s.gigantic.io", "kubernetes", "default", "svc", "cluster.local"},
+				PermittedIPRanges:   []string{"172.31.0.0/16"},
+			},
+			expectedConstraints: Constraints{
+				MaxPathLength:       NoMaxPathLength,
+				PermittedDNSDomains: []string{"al9qy.k8s.gigantic.io", "kubernetes", "default", "svc", "cluster.local"},
+				PermittedIPRanges:   []*net.IPNet{ipRange},
+			},
+		},
+		{
+			name: "case 2: IP ranges must be CIDRs",
+			config: Config{
+				CommonNameFormat:  "%s.k8s.gigantic.io",
+				PermittedIPRanges: []string{"172.31.0.1"},
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 3: the max path length must not be lower than -1",
+			config: Config{
+				CommonNameFormat: "%s.k8s.gigantic.io",
+				MaxPathLength:    -2,
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 4: DNS domains default to the given names not covered yet",
+			config: Config{
+				CommonNameFormat: "%s.k8s.gigantic.io",
+				DNSNames:         []string{"api.%s.k8s.gigantic.io", "etcd.example.com"},
+			},
+			expectedConstraints: Constraints{
+				PermittedDNSDomains: []string{
+					"al9qy.k8s.gigantic.io",
+					"kubernetes",
+					"kubernetes.default",
+					"kubernetes.default.svc",
+					"kubernetes.default.svc.cluster.local",
+					"etcd.example.com",
+				},
+			},
+		},
+		{
+			name: "case 5: configured DNS domains must permit the Kubernetes API service names",
+			config: Config{
+				CommonNameFormat:    "%s.k8s.gigantic.io",
+				PermittedDNSDomains: []string{"%s.k8s.gigantic.io", "cluster.local"},
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 6: configured DNS domains must permit the given names",
+			config: Config{
+				CommonNameFormat:    "%s.k8s.gigantic.io",
+				DNSNames:            []string{"etcd.example.com"},
+				PermittedDNSDomains: []string{"%s.k8s.gigantic.io", "kubernetes", "default", "svc", "local"},
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := New(tc.config)
+			if err != nil {
+				if tc.errorMatcher == nil || !tc.errorMatcher(err) {
+					t.Fatalf("unexpected error %#v", err)
+				}
+				return
+			} else if tc.errorMatcher != nil {
+				t.Fatalf("expected error, got nil")
+			}
+
+			c := p.Constraints("al9qy")
+			if !reflect.DeepEqual(c, tc.expectedConstraints) {
+				t.Fatalf("expected %#v got %#v", tc.expectedConstraints, c)
+			}
+		})
+	}
+}
+
+func Test_Constraints_Apply(t *testing.T) {
+	_, ipRange, err := net.ParseCIDR("172.31.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	constraints := Constraints{
+		PermittedDNSDomains: []string{"al9qy.k8s.gigantic.io"},
+		PermittedIPRanges:   []*net.IPNet{ipRange},
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ca *x509.Certificate
+	{
+		template := &x509.Certificate{
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			KeyUsage:              x509.KeyUsageCertSign,
+			NotAfter:              time.Now().Add(time.Hour),
+			NotBefore:             time.Now().Add(-time.Hour),
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "al9qy.k8s.gigantic.io"},
+		}
+		constraints.Apply(template)
+
+		der, err := x509.CreateCertificate(rand.Reader, template, template, caKey.Public(), caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ca, err = x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !ca.MaxPathLenZero || ca.MaxPathLen != 0 {
+		t.Fatalf("expected max path length 0 got %d", ca.MaxPathLen)
+	}
+
+	testCases := []struct {
+		name          string
+		dnsNames      []string
+		ipAddresses   []net.IP
+		expectedValid bool
+	}{
+		{
+			name:          "case 0: subdomains of the permitted domain are valid",
+			dnsNames:      []string{"api.al9qy.k8s.gigantic.io"},
+			ipAddresses:   []net.IP{net.ParseIP("172.31.0.1")},
+			expectedValid: true,
+		},
+		{
+			name:          "case 1: other domains are invalid",
+			dnsNames:      []string{"api.example.com"},
+			expectedValid: false,
+		},
+		{
+			name:          "case 2: other IP addresses are invalid",
+			dnsNames:      []string{"api.al9qy.k8s.gigantic.io"},
+			ipAddresses:   []net.IP{net.ParseIP("10.0.0.1")},
+			expectedValid: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			template := &x509.Certificate{
+				DNSNames:     tc.dnsNames,
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+				IPAddresses:  tc.ipAddresses,
+				NotAfter:     time.Now().Add(time.Hour),
+				NotBefore:    time.Now().Add(-time.Hour),
+				SerialNumber: big.NewInt(int64(i + 2)),
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, ca, k.Public(), caKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			crt, err := x509.ParseCertificate(der)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			roots := x509.NewCertPool()
+			roots.AddCert(ca)
+			_, err = crt.Verify(x509.VerifyOptions{Roots: roots})
+			if (err == nil) != tc.expectedValid {
+				t.Fatalf("expected valid %t got error %#v", tc.expectedValid, err)
+			}
+		})
+	}
+}
+
+// Test_Policy_Constraints_kubernetes ensures that certificates carrying the
+// names of the Kubernetes API service verify against CAs created with the
+// default constraints.
+func Test_Policy_Constraints_kubernetes(t *testing.T) {
+	p, err := New(Config{CommonNameFormat: "%s.k8s.gigantic.io"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ca *x509.Certificate
+	{
+		template := &x509.Certificate{
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			KeyUsage:              x509.KeyUsageCertSign,
+			NotAfter:              time.Now().Add(time.Hour),
+			NotBefore:             time.Now().Add(-time.Hour),
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "al9qy.k8s.gigantic.io"},
+		}
+		p.Constraints("al9qy").Apply(template)
+
+		der, err := x509.CreateCertificate(rand.Reader, template, template, caKey.Public(), caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ca, err = x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		DNSNames:     append([]string{"api.al9qy.k8s.gigantic.io"}, KubernetesDNSNames...),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotAfter:     time.Now().Add(time.Hour),
+		NotBefore:    time.Now().Add(-time.Hour),
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "api.al9qy.k8s.gigantic.io"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, k.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crt, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	_, err = crt.Verify(x509.VerifyOptions{DNSName: "kubernetes.default.svc", Roots: roots})
+	if err != nil {
+		t.Fatalf("expected certificate to be valid, got error %#v", err)
+	}
+}
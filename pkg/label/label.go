@@ -2,24 +2,53 @@ package label
 
 import (
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 
 	"github.com/giantswarm/cert-operator/v3/pkg/project"
 )
 
 const (
+	Certificate     = "giantswarm.io/certificate"
 	Cluster         = "giantswarm.io/cluster"
+	ManagedBy       = "giantswarm.io/managed-by"
 	OperatorVersion = "cert-operator.giantswarm.io/version"
 )
 
+const (
+	// ForceRenew is the label equivalent of annotation.ForceRenew. Being a
+	// label it can be set on all CertConfigs of a Tenant Cluster at once using
+	// the Cluster label as selector.
+	ForceRenew = "cert-operator.giantswarm.io/force-renew"
+	// Paused is the label equivalent of annotation.Paused.
+	Paused = "cert-operator.giantswarm.io/paused"
+)
+
 func AppVersionSelector() labels.Selector {
 	return labels.SelectorFromSet(map[string]string{
 		OperatorVersion: project.Version(),
 	})
 }
 
+// SecretSelector selects all certificate secrets managed by this version of
+// the operator.
+func SecretSelector() labels.Selector {
+	return labels.SelectorFromSet(map[string]string{
+		OperatorVersion: project.Version(),
+	}).Add(certificateExists())
+}
+
 // KubeconfigSelector selects all certconfigs that use the special version `0.0.0`.
 func KubeconfigSelector() labels.Selector {
 	return labels.SelectorFromSet(map[string]string{
 		OperatorVersion: project.ManagementClusterAppVersion(),
 	})
 }
+
+func certificateExists() labels.Requirement {
+	r, err := labels.NewRequirement(Certificate, selection.Exists, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return *r
+}
@@ -0,0 +1,128 @@
+// Package trustdomain maps the components of tenant clusters to trust
+// domains. Every trust domain of a tenant cluster is backed by its own Vault
+// PKI backend, so that e.g. etcd does not trust the client certificates of
+// the Kubernetes API.
+package trustdomain
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Default is the trust domain of components which are not mapped to
+	// another trust domain. It is backed by the original PKI backend of the
+	// tenant cluster, whose ID is the cluster ID.
+	Default = "kubernetes"
+)
+
+var nameRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// Mapping maps cluster components to trust domains.
+type Mapping map[string]string
+
+// Parse parses the given YAML or JSON map of cluster components to trust
+// domains and validates it. An empty string results in an empty mapping.
+func Parse(s string) (Mapping, error) {
+	m := Mapping{}
+	if s == "" {
+		return m, nil
+	}
+
+	err := yaml.UnmarshalStrict([]byte(s), &m)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "trust domains must be a map of cluster components to trust domains: %s", err)
+	}
+
+	for c, d := range m {
+		err := Validate(d)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "trust domain of cluster component %#q: %s", c, err)
+		}
+	}
+
+	return m, nil
+}
+
+// Validate checks whether the given trust domain can be used as part of the
+// mount path of a Vault PKI backend.
+func Validate(domain string) error {
+	if !nameRegexp.MatchString(domain) {
+		return microerror.Maskf(invalidConfigError, "trust domain %#q must consist of lower case alphanumeric characters or '-'", domain)
+	}
+
+	return nil
+}
+
+// Domain returns the trust domain of the given cluster component.
+func (m Mapping) Domain(component string) string {
+	d, ok := m[component]
+	if !ok || d == "" {
+		return Default
+	}
+
+	return d
+}
+
+// Domains returns the sorted trust domains of the mapping, except Default.
+func (m Mapping) Domains() []string {
+	seen := map[string]bool{}
+
+	var domains []string
+	for _, d := range m {
+		if d == "" || d == Default || seen[d] {
+			continue
+		}
+		seen[d] = true
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+
+	return domains
+}
+
+// Has returns whether the given trust domain is Default or one of the trust
+// domains of the mapping. Only these trust domains have PKI backends which are
+// torn down along with the tenant cluster.
+func (m Mapping) Has(domain string) bool {
+	if domain == Default {
+		return true
+	}
+
+	for _, d := range m {
+		if d == domain {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PKIID returns the ID of the Vault PKI backend of the given trust domain of
+// the given tenant cluster. The Default trust domain keeps using the cluster
+// ID, so that existing PKI backends stay in place.
+func PKIID(clusterID string, domain string) string {
+	if domain == "" || domain == Default {
+		return clusterID
+	}
+
+	return fmt.Sprintf("%s-%s", clusterID, domain)
+}
+
+// ClusterID returns the ID of the tenant cluster the Vault PKI backend of the
+// given ID belongs to. It reverses PKIID for the trust domains of the mapping.
+func (m Mapping) ClusterID(pkiID string) string {
+	for _, d := range m.Domains() {
+		suffix := "-" + d
+		if strings.HasSuffix(pkiID, suffix) && len(pkiID) > len(suffix) {
+			return strings.TrimSuffix(pkiID, suffix)
+		}
+	}
+
+	return pkiID
+}
@@ -0,0 +1,83 @@
+package trustdomain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_TrustDomain_Parse(t *testing.T) {
+	testCases := []struct {
+		name             string
+		input            string
+		expectedMapping  Mapping
+		expectedDomains  []string
+		expectedEtcdPKI  string
+		expectedCalicoID string
+		expectedHasProxy bool
+		errorMatcher     func(error) bool
+	}{
+		{
+			name:             "case 0: an empty mapping keeps all components in the default trust domain",
+			input:            "",
+			expectedMapping:  Mapping{},
+			expectedDomains:  nil,
+			expectedEtcdPKI:  "al9qy",
+			expectedCalicoID: "al9qy",
+		},
+		{
+			name:             "case 1: components are mapped to their trust domains",
+			input:            "etcd: etcd\ncalico-etcd-client: etcd\naggregator: front-proxy\napi: kubernetes\n",
+			expectedMapping:  Mapping{"etcd": "etcd", "calico-etcd-client": "etcd", "aggregator": "front-proxy", "api": "kubernetes"},
+			expectedDomains:  []string{"etcd", "front-proxy"},
+			expectedEtcdPKI:  "al9qy-etcd",
+			expectedCalicoID: "al9qy",
+			expectedHasProxy: true,
+		},
+		{
+			name:         "case 2: trust domains must be usable in mount paths",
+			input:        `{"etcd": "etcd/ca"}`,
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 3: the mapping must be a map",
+			input:        "- etcd",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mapping, err := Parse(tc.input)
+			if err != nil {
+				if tc.errorMatcher == nil || !tc.errorMatcher(err) {
+					t.Fatalf("unexpected error %#v", err)
+				}
+				return
+			} else if tc.errorMatcher != nil {
+				t.Fatalf("expected error, got nil")
+			}
+
+			if !reflect.DeepEqual(mapping, tc.expectedMapping) {
+				t.Fatalf("expected mapping %v got %v", tc.expectedMapping, mapping)
+			}
+			if !reflect.DeepEqual(mapping.Domains(), tc.expectedDomains) {
+				t.Fatalf("expected domains %v got %v", tc.expectedDomains, mapping.Domains())
+			}
+			if id := PKIID("al9qy", mapping.Domain("etcd")); id != tc.expectedEtcdPKI {
+				t.Fatalf("expected PKI ID %#q got %#q", tc.expectedEtcdPKI, id)
+			}
+			if id := PKIID("al9qy", mapping.Domain("calico")); id != tc.expectedCalicoID {
+				t.Fatalf("expected PKI ID %#q got %#q", tc.expectedCalicoID, id)
+			}
+			if !mapping.Has(Default) {
+				t.Fatalf("expected the default trust domain to be known")
+			}
+			if mapping.Has("front-proxy") != tc.expectedHasProxy {
+				t.Fatalf("expected front-proxy trust domain to be known %t got %t", tc.expectedHasProxy, !tc.expectedHasProxy)
+			}
+			if id := mapping.ClusterID(tc.expectedEtcdPKI); id != "al9qy" {
+				t.Fatalf("expected cluster ID %#q got %#q", "al9qy", id)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package vaultauth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/giantswarm/microerror"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+// isTokenRejected asserts errors of Vault rejecting a token, e.g. because it
+// expired, was revoked or reached its maximum TTL and cannot be renewed
+// anymore.
+func isTokenRejected(err error) bool {
+	var responseErr *vaultapi.ResponseError
+	if !errors.As(err, &responseErr) {
+		return false
+	}
+
+	return responseErr.StatusCode == http.StatusBadRequest || responseErr.StatusCode == http.StatusForbidden
+}
@@ -0,0 +1,22 @@
+package vaultauth
+
+import (
+	"context"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+type Interface interface {
+	// Login acquires a new token using the configured auth method and sets
+	// it on the Vault client. Static tokens cannot be acquired again, so
+	// Login only verifies that a token is set in this case.
+	Login(ctx context.Context) error
+	// LookupSelf looks up the token of the Vault client. A new token is
+	// acquired beforehand in case the current one is not valid anymore and
+	// the auth method supports logging in again.
+	LookupSelf(ctx context.Context) (*vaultapi.Secret, error)
+	// Renew renews the token of the Vault client. A new token is acquired
+	// instead in case the current one cannot be renewed anymore and the auth
+	// method supports logging in again.
+	Renew(ctx context.Context) error
+}
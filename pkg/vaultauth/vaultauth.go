@@ -0,0 +1,246 @@
+// Package vaultauth acquires and renews the token of the Vault client shared
+// by the controllers and collectors of the operator.
+package vaultauth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+const (
+	// MethodAppRole logs in with a role ID and a secret ID using the AppRole
+	// auth method.
+	MethodAppRole = "approle"
+	// MethodCert logs in with the client certificate of the Vault client
+	// using the TLS certificate auth method.
+	MethodCert = "cert"
+	// MethodToken uses the static token the Vault client is created with.
+	MethodToken = "token"
+)
+
+const (
+	// minTokenTTL is the remaining TTL below which renewed tokens are
+	// replaced by new ones, because they reached their maximum TTL.
+	minTokenTTL = 5 * time.Minute
+)
+
+type Config struct {
+	Logger      micrologger.Logger
+	VaultClient *vaultapi.Client
+
+	// Method is one of MethodAppRole, MethodCert and MethodToken.
+	Method string
+	// MountPath is the path the auth method is mounted at. It defaults to the
+	// name of the method.
+	MountPath string
+
+	// AppRoleRoleID is the role ID used to log in with MethodAppRole.
+	AppRoleRoleID string
+	// AppRoleSecretIDFile is the path of the file holding the secret ID used
+	// to log in with MethodAppRole. The file is read on every login, so that
+	// rotated secret IDs are picked up.
+	AppRoleSecretIDFile string
+
+	// CertName is the name of the certificate role used to log in with
+	// MethodCert. All roles matching the client certificate are tried in
+	// case it is empty.
+	CertName string
+}
+
+type VaultAuth struct {
+	logger      micrologger.Logger
+	vaultClient *vaultapi.Client
+
+	method    string
+	mountPath string
+
+	appRoleRoleID       string
+	appRoleSecretIDFile string
+	certName            string
+
+	mutex sync.Mutex
+}
+
+func New(config Config) (*VaultAuth, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.VaultClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultClient must not be empty", config)
+	}
+
+	switch config.Method {
+	case MethodAppRole:
+		if config.AppRoleRoleID == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.AppRoleRoleID must not be empty", config)
+		}
+		if config.AppRoleSecretIDFile == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.AppRoleSecretIDFile must not be empty", config)
+		}
+	case MethodCert, MethodToken:
+	default:
+		return nil, microerror.Maskf(invalidConfigError, "%T.Method must be one of %#q, %#q or %#q, got %#q", config, MethodAppRole, MethodCert, MethodToken, config.Method)
+	}
+
+	mountPath := strings.Trim(config.MountPath, "/")
+	if mountPath == "" {
+		mountPath = config.Method
+	}
+
+	v := &VaultAuth{
+		logger:      config.Logger,
+		vaultClient: config.VaultClient,
+
+		method:    config.Method,
+		mountPath: mountPath,
+
+		appRoleRoleID:       config.AppRoleRoleID,
+		appRoleSecretIDFile: config.AppRoleSecretIDFile,
+		certName:            config.CertName,
+	}
+
+	return v, nil
+}
+
+func (v *VaultAuth) Login(ctx context.Context) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	return v.login(ctx)
+}
+
+func (v *VaultAuth) LookupSelf(ctx context.Context) (*vaultapi.Secret, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.vaultClient.Token() == "" {
+		err := v.login(ctx)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	secret, err := v.vaultClient.Auth().Token().LookupSelfWithContext(ctx)
+	if isTokenRejected(err) && v.canLogin() {
+		err = v.login(ctx)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		secret, err = v.vaultClient.Auth().Token().LookupSelfWithContext(ctx)
+	}
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return secret, nil
+}
+
+func (v *VaultAuth) Renew(ctx context.Context) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.vaultClient.Token() == "" {
+		return v.login(ctx)
+	}
+
+	secret, err := v.vaultClient.Auth().Token().RenewSelfWithContext(ctx, 0)
+	if isTokenRejected(err) && v.canLogin() {
+		v.logger.LogCtx(ctx, "level", "debug", "message", "vault token cannot be renewed anymore")
+		return v.login(ctx)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if v.canLogin() && secret != nil && secret.Auth != nil && time.Duration(secret.Auth.LeaseDuration)*time.Second < minTokenTTL {
+		v.logger.LogCtx(ctx, "level", "debug", "message", "vault token reached its maximum TTL")
+		return v.login(ctx)
+	}
+
+	return nil
+}
+
+// canLogin returns whether the auth method can acquire new tokens.
+func (v *VaultAuth) canLogin() bool {
+	return v.method != MethodToken
+}
+
+// login must be called with the mutex held.
+func (v *VaultAuth) login(ctx context.Context) error {
+	if !v.canLogin() {
+		if v.vaultClient.Token() == "" {
+			return microerror.Maskf(executionFailedError, "vault token must not be empty")
+		}
+
+		return nil
+	}
+
+	data, err := v.loginData()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// The login request is sent without the current token, which may have
+	// been rejected already.
+	c, err := v.vaultClient.Clone()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	c.ClearToken()
+
+	v.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("logging in to vault using the %#q auth method", v.method))
+
+	secret, err := c.Logical().WriteWithContext(ctx, fmt.Sprintf("auth/%s/login", v.mountPath), data)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return microerror.Maskf(executionFailedError, "vault login using the %#q auth method returned no token", v.method)
+	}
+
+	v.vaultClient.SetToken(secret.Auth.ClientToken)
+
+	v.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("logged in to vault using the %#q auth method", v.method))
+
+	return nil
+}
+
+func (v *VaultAuth) loginData() (map[string]interface{}, error) {
+	switch v.method {
+	case MethodAppRole:
+		b, err := os.ReadFile(v.appRoleSecretIDFile)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		secretID := strings.TrimSpace(string(b))
+		if secretID == "" {
+			return nil, microerror.Maskf(executionFailedError, "secret ID file %#q must not be empty", v.appRoleSecretIDFile)
+		}
+
+		d := map[string]interface{}{
+			"role_id":   v.appRoleRoleID,
+			"secret_id": secretID,
+		}
+
+		return d, nil
+
+	case MethodCert:
+		d := map[string]interface{}{}
+		if v.certName != "" {
+			d["name"] = v.certName
+		}
+
+		return d, nil
+	}
+
+	return nil, microerror.Maskf(executionFailedError, "auth method %#q does not support logging in", v.method)
+}
@@ -0,0 +1,262 @@
+package vaultauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+type fakeVaultRequest struct {
+	Path  string
+	Token string
+	Data  map[string]interface{}
+}
+
+// fakeVault answers logins with tokens numbered by login and renewals with
+// the configured status code and lease duration.
+type fakeVault struct {
+	mutex    sync.Mutex
+	logins   int
+	requests []fakeVaultRequest
+
+	renewLeaseDuration int
+	renewStatus        int
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var data map[string]interface{}
+	if r.ContentLength > 0 {
+		_ = json.NewDecoder(r.Body).Decode(&data)
+	}
+	f.requests = append(f.requests, fakeVaultRequest{Path: r.URL.Path, Token: r.Header.Get("X-Vault-Token"), Data: data})
+
+	switch r.URL.Path {
+	case "/v1/auth/approle/login", "/v1/auth/cert/login":
+		f.logins++
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"auth": map[string]interface{}{
+				"client_token":   []string{"", "token-1", "token-2", "token-3"}[f.logins],
+				"lease_duration": 3600,
+				"renewable":      true,
+			},
+		})
+	case "/v1/auth/token/renew-self":
+		if f.renewStatus != http.StatusOK {
+			writeJSON(w, f.renewStatus, map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"auth": map[string]interface{}{
+				"client_token":   r.Header.Get("X-Vault-Token"),
+				"lease_duration": f.renewLeaseDuration,
+				"renewable":      true,
+			},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newTestVaultAuth(t *testing.T, fake *fakeVault, config Config) (*VaultAuth, *vaultapi.Client) {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	c := vaultapi.DefaultConfig()
+	c.Address = server.URL
+	c.MaxRetries = 0
+
+	vaultClient, err := vaultapi.NewClient(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaultClient.ClearToken()
+
+	config.Logger = microloggertest.New()
+	config.VaultClient = vaultClient
+
+	v, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return v, vaultClient
+}
+
+func writeSecretID(t *testing.T, path, secretID string) {
+	err := os.WriteFile(path, []byte(secretID+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_VaultAuth_AppRole(t *testing.T) {
+	ctx := context.Background()
+	secretIDFile := filepath.Join(t.TempDir(), "secret-id")
+	writeSecretID(t, secretIDFile, "secret-1")
+
+	fake := &fakeVault{renewStatus: http.StatusOK, renewLeaseDuration: 3600}
+	v, vaultClient := newTestVaultAuth(t, fake, Config{
+		Method:              MethodAppRole,
+		AppRoleRoleID:       "role",
+		AppRoleSecretIDFile: secretIDFile,
+	})
+
+	err := v.Login(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vaultClient.Token() != "token-1" {
+		t.Fatalf("expected token %#q got %#q", "token-1", vaultClient.Token())
+	}
+	if fake.requests[0].Data["role_id"] != "role" || fake.requests[0].Data["secret_id"] != "secret-1" {
+		t.Fatalf("expected login with role ID and secret ID, got %v", fake.requests[0].Data)
+	}
+
+	// Tokens which can be renewed are kept.
+	err = v.Renew(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.logins != 1 || vaultClient.Token() != "token-1" {
+		t.Fatalf("expected token to be renewed, got %d logins and token %#q", fake.logins, vaultClient.Token())
+	}
+
+	// Tokens which are rejected are replaced using the rotated secret ID. The
+	// rejected token must not be sent with the login.
+	writeSecretID(t, secretIDFile, "secret-2")
+	fake.renewStatus = http.StatusForbidden
+
+	err = v.Renew(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vaultClient.Token() != "token-2" {
+		t.Fatalf("expected token %#q got %#q", "token-2", vaultClient.Token())
+	}
+	login := fake.requests[len(fake.requests)-1]
+	if login.Data["secret_id"] != "secret-2" || login.Token != "" {
+		t.Fatalf("expected login with the rotated secret ID and without token, got %#v", login)
+	}
+
+	// Tokens which reached their maximum TTL are replaced too.
+	fake.renewStatus = http.StatusOK
+	fake.renewLeaseDuration = 60
+
+	err = v.Renew(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vaultClient.Token() != "token-3" {
+		t.Fatalf("expected token %#q got %#q", "token-3", vaultClient.Token())
+	}
+}
+
+func Test_VaultAuth_Cert(t *testing.T) {
+	fake := &fakeVault{}
+	v, vaultClient := newTestVaultAuth(t, fake, Config{
+		Method:   MethodCert,
+		CertName: "cert-operator",
+	})
+
+	err := v.Login(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vaultClient.Token() != "token-1" {
+		t.Fatalf("expected token %#q got %#q", "token-1", vaultClient.Token())
+	}
+	if fake.requests[0].Data["name"] != "cert-operator" {
+		t.Fatalf("expected login with certificate role name, got %v", fake.requests[0].Data)
+	}
+}
+
+func Test_VaultAuth_Token(t *testing.T) {
+	ctx := context.Background()
+
+	fake := &fakeVault{renewStatus: http.StatusForbidden}
+	v, vaultClient := newTestVaultAuth(t, fake, Config{
+		Method: MethodToken,
+	})
+
+	// Static tokens must be given.
+	err := v.Login(ctx)
+	if !IsExecutionFailed(err) {
+		t.Fatalf("expected executionFailedError got %#v", err)
+	}
+
+	vaultClient.SetToken("static")
+
+	err = v.Login(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Static tokens cannot be replaced, so renewal errors are returned.
+	err = v.Renew(ctx)
+	if err == nil {
+		t.Fatal("expected error got nil")
+	}
+	if fake.logins != 0 || vaultClient.Token() != "static" {
+		t.Fatalf("expected no login, got %d logins and token %#q", fake.logins, vaultClient.Token())
+	}
+}
+
+func Test_VaultAuth_New(t *testing.T) {
+	testCases := []struct {
+		name         string
+		config       Config
+		errorMatcher func(error) bool
+	}{
+		{
+			name:   "case 0: token method",
+			config: Config{Method: MethodToken},
+		},
+		{
+			name:         "case 1: approle method without secret ID file",
+			config:       Config{Method: MethodAppRole, AppRoleRoleID: "role"},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 2: unknown method",
+			config:       Config{Method: "kubernetes"},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.Logger = microloggertest.New()
+			tc.config.VaultClient = &vaultapi.Client{}
+
+			_, err := New(tc.config)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+		})
+	}
+}
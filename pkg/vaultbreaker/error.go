@@ -0,0 +1,178 @@
+package vaultbreaker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+const (
+	// ClassPermissionDenied classifies requests Vault denied, e.g. because
+	// of missing policies. Vault is operational in this case.
+	ClassPermissionDenied = "permission_denied"
+	// ClassRateLimited classifies requests Vault rejected because of rate
+	// limit quotas.
+	ClassRateLimited = "rate_limited"
+	// ClassSealed classifies requests a sealed Vault could not serve.
+	ClassSealed = "sealed"
+	// ClassServerError classifies requests Vault failed with a 5xx status
+	// code for other reasons than being sealed.
+	ClassServerError = "server_error"
+	// ClassUnavailable classifies requests which did not reach Vault, e.g.
+	// because it is unreachable or timed out.
+	ClassUnavailable = "unavailable"
+
+	// classCanceled classifies requests canceled by their callers. They say
+	// nothing about the state of Vault.
+	classCanceled = "canceled"
+)
+
+var circuitOpenError = &microerror.Error{
+	Kind: "circuitOpenError",
+}
+
+// IsCircuitOpen asserts circuitOpenError, which is returned for Vault
+// requests rejected by the circuit breaker.
+func IsCircuitOpen(err error) bool {
+	return microerror.Cause(err) == circuitOpenError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+// IsOutage asserts errors of Vault requests failing because Vault is not
+// operational, including requests rejected by the circuit breaker. Callers
+// are expected to back off instead of retrying right away.
+func IsOutage(err error) bool {
+	return IsCircuitOpen(err) || isTripping(Classify(err))
+}
+
+// IsPermissionDenied asserts errors of Vault requests classified as
+// ClassPermissionDenied.
+func IsPermissionDenied(err error) bool {
+	return Classify(err) == ClassPermissionDenied
+}
+
+// IsRateLimited asserts errors of Vault requests classified as
+// ClassRateLimited.
+func IsRateLimited(err error) bool {
+	return Classify(err) == ClassRateLimited
+}
+
+// IsSealed asserts errors of Vault requests classified as ClassSealed.
+func IsSealed(err error) bool {
+	return Classify(err) == ClassSealed
+}
+
+// IsServerError asserts errors of Vault requests classified as
+// ClassServerError.
+func IsServerError(err error) bool {
+	return Classify(err) == ClassServerError
+}
+
+// IsUnavailable asserts errors of Vault requests classified as
+// ClassUnavailable.
+func IsUnavailable(err error) bool {
+	return Classify(err) == ClassUnavailable
+}
+
+// Classify returns the class of the error returned by a Vault request. It
+// returns an empty string for errors which are not caused by Vault, as well
+// as for requests rejected by the circuit breaker.
+func Classify(err error) string {
+	if err == nil || IsCircuitOpen(err) {
+		return ""
+	}
+
+	var responseErr *vaultapi.ResponseError
+	if errors.As(err, &responseErr) {
+		return classifyStatus(responseErr.StatusCode, strings.Join(responseErr.Errors, " "))
+	}
+
+	c := classifyTransportError(err)
+	if c == classCanceled {
+		return ""
+	}
+
+	return c
+}
+
+// classifyResponse classifies the result of a single request sent to Vault.
+// Bodies of failed 503 responses are read to tell sealed Vaults apart and
+// replaced, so that the caller can still read them.
+func classifyResponse(res *http.Response, err error) string {
+	if err != nil {
+		return classifyTransportError(err)
+	}
+
+	if res.StatusCode != http.StatusServiceUnavailable || res.Body == nil {
+		return classifyStatus(res.StatusCode, "")
+	}
+
+	body, err := readBody(res)
+	if err != nil {
+		return ClassUnavailable
+	}
+
+	return classifyStatus(res.StatusCode, string(body))
+}
+
+func classifyStatus(statusCode int, message string) string {
+	switch {
+	case statusCode == http.StatusForbidden:
+		return ClassPermissionDenied
+	case statusCode == http.StatusTooManyRequests:
+		return ClassRateLimited
+	case statusCode == http.StatusServiceUnavailable && strings.Contains(strings.ToLower(message), "sealed"):
+		return ClassSealed
+	case statusCode >= http.StatusInternalServerError:
+		return ClassServerError
+	default:
+		return ""
+	}
+}
+
+// classifyTransportError classifies errors of requests which did not get a
+// response from Vault. During strategic updates Vault temporarily replies
+// with HTTP responses to HTTPS requests, which fail the same way.
+func classifyTransportError(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return classCanceled
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClassUnavailable
+	case errors.As(err, &netErr), errors.As(err, &urlErr):
+		return ClassUnavailable
+	case strings.Contains(err.Error(), "server gave HTTP response to HTTPS client"):
+		return ClassUnavailable
+	default:
+		return ""
+	}
+}
+
+// isTripping returns whether requests of the given class count towards
+// opening the circuit.
+func isTripping(class string) bool {
+	switch class {
+	case ClassRateLimited, ClassSealed, ClassServerError, ClassUnavailable:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,32 @@
+package vaultbreaker
+
+const (
+	// StateClosed is the state in which Vault requests are sent.
+	StateClosed = "closed"
+	// StateHalfOpen is the state in which a single probe request is sent
+	// after the circuit has been open.
+	StateHalfOpen = "half-open"
+	// StateOpen is the state in which Vault requests are rejected.
+	StateOpen = "open"
+)
+
+type Interface interface {
+	// Open returns whether Vault requests are currently rejected, either
+	// because the circuit is open or because Vault is being probed.
+	Open() bool
+	// Stats returns the current state of the circuit and the counters of
+	// the requests it observed.
+	Stats() Stats
+}
+
+type Stats struct {
+	// Failures counts the failed Vault requests by their class.
+	Failures map[string]uint64
+	// Opened counts how often the circuit opened.
+	Opened uint64
+	// Rejected counts the Vault requests rejected while the circuit was
+	// open.
+	Rejected uint64
+	// State is one of StateClosed, StateHalfOpen and StateOpen.
+	State string
+}
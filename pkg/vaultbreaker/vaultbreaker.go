@@ -0,0 +1,284 @@
+// Package vaultbreaker protects Vault from the operator while it recovers
+// from an outage. Vault requests are classified and the circuit opens after
+// a number of consecutive failures, so that requests are rejected right away
+// instead of being sent to Vault. Once the circuit has been open for a while,
+// a single probe request is sent. The circuit closes when the probe succeeds
+// and stays open twice as long as before when it fails.
+package vaultbreaker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+)
+
+const (
+	// maxBodySize limits the size of response bodies read to classify
+	// failed requests.
+	maxBodySize = 64 * 1024
+)
+
+type Config struct {
+	Logger micrologger.Logger
+
+	// FailureThreshold is the number of consecutive Vault requests failing
+	// due to an outage after which the circuit opens. Zero disables the
+	// circuit breaker.
+	FailureThreshold int
+	// MaxOpenDuration limits the duration the circuit stays open for.
+	MaxOpenDuration time.Duration
+	// OpenDuration is the duration the circuit stays open for before Vault is
+	// probed. It doubles with every failed probe up to MaxOpenDuration.
+	OpenDuration time.Duration
+}
+
+type Breaker struct {
+	logger             micrologger.Logger
+	currentTimeFactory func() time.Time
+
+	failureThreshold int
+	maxOpenDuration  time.Duration
+	openDuration     time.Duration
+
+	mutex           sync.Mutex
+	backoff         time.Duration
+	failures        int
+	openUntil       time.Time
+	probing         bool
+	state           string
+	failuresByClass map[string]uint64
+	opened          uint64
+	rejected        uint64
+}
+
+func New(config Config) (*Breaker, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.FailureThreshold < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.FailureThreshold must not be negative", config)
+	}
+	if config.FailureThreshold > 0 {
+		if config.OpenDuration <= 0 {
+			return nil, microerror.Maskf(invalidConfigError, "%T.OpenDuration must be positive", config)
+		}
+		if config.MaxOpenDuration < config.OpenDuration {
+			return nil, microerror.Maskf(invalidConfigError, "%T.MaxOpenDuration must not be smaller than %T.OpenDuration", config, config)
+		}
+	}
+
+	b := &Breaker{
+		logger:             config.Logger,
+		currentTimeFactory: time.Now,
+
+		failureThreshold: config.FailureThreshold,
+		maxOpenDuration:  config.MaxOpenDuration,
+		openDuration:     config.OpenDuration,
+
+		backoff:         config.OpenDuration,
+		state:           StateClosed,
+		failuresByClass: map[string]uint64{},
+	}
+
+	return b, nil
+}
+
+func (b *Breaker) Open() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		return b.currentTimeFactory().Before(b.openUntil)
+	case StateHalfOpen:
+		return b.probing
+	default:
+		return false
+	}
+}
+
+func (b *Breaker) Stats() Stats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	failures := map[string]uint64{}
+	for k, v := range b.failuresByClass {
+		failures[k] = v
+	}
+
+	s := Stats{
+		Failures: failures,
+		Opened:   b.opened,
+		Rejected: b.rejected,
+		State:    b.state,
+	}
+
+	return s
+}
+
+// Wrap returns a transport sending Vault requests through the circuit
+// breaker. It is expected to wrap all other transports of the Vault client,
+// so that it observes the requests after they failed over. The given
+// transport is returned as it is in case the circuit breaker is disabled.
+func (b *Breaker) Wrap(transport http.RoundTripper) http.RoundTripper {
+	if b.failureThreshold == 0 {
+		return transport
+	}
+
+	t := &breakerTransport{
+		breaker:   b,
+		transport: transport,
+	}
+
+	return t
+}
+
+// allow returns circuitOpenError in case the request must not be sent. The
+// first request after the circuit has been open for long enough is let
+// through as probe.
+func (b *Breaker) allow(ctx context.Context) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.currentTimeFactory().Before(b.openUntil) {
+			b.rejected++
+			return microerror.Maskf(circuitOpenError, "vault requests are rejected until %s", b.openUntil.Format(time.RFC3339))
+		}
+
+		b.logger.LogCtx(ctx, "level", "debug", "message", "probing vault after the circuit breaker has been open")
+		b.state = StateHalfOpen
+		b.probing = true
+
+	case StateHalfOpen:
+		if b.probing {
+			b.rejected++
+			return microerror.Maskf(circuitOpenError, "vault is being probed")
+		}
+
+		b.probing = true
+	}
+
+	return nil
+}
+
+// record updates the circuit with the class of a request sent to Vault.
+// retryAfter is the duration Vault asked clients to wait for, if any.
+func (b *Breaker) record(ctx context.Context, class string, retryAfter time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if class == classCanceled {
+		if b.state == StateHalfOpen {
+			b.probing = false
+		}
+
+		return
+	}
+
+	if class != "" {
+		b.failuresByClass[class]++
+	}
+
+	if !isTripping(class) {
+		if b.state != StateClosed {
+			b.logger.LogCtx(ctx, "level", "info", "message", "closed the vault circuit breaker")
+		}
+
+		b.backoff = b.openDuration
+		b.failures = 0
+		b.probing = false
+		b.state = StateClosed
+
+		return
+	}
+
+	b.failures++
+
+	switch {
+	case b.state == StateHalfOpen:
+		b.backoff *= 2
+		if b.backoff > b.maxOpenDuration {
+			b.backoff = b.maxOpenDuration
+		}
+	case b.state == StateClosed && b.failures >= b.failureThreshold:
+		b.backoff = b.openDuration
+	default:
+		return
+	}
+
+	d := b.backoff
+	if retryAfter > d {
+		d = retryAfter
+		if d > b.maxOpenDuration {
+			d = b.maxOpenDuration
+		}
+	}
+
+	b.openUntil = b.currentTimeFactory().Add(d)
+	b.probing = false
+	b.state = StateOpen
+	b.opened++
+
+	b.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("opened the vault circuit breaker for %s after %d failed requests, the last one being %s", d, b.failures, class))
+}
+
+type breakerTransport struct {
+	breaker   *Breaker
+	transport http.RoundTripper
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.breaker.allow(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := t.transport.RoundTrip(req)
+	t.breaker.record(req.Context(), classifyResponse(res, err), retryAfter(res))
+
+	return res, err
+}
+
+// readBody reads the beginning of the response body and puts it back in
+// front of the rest of the body.
+func readBody(res *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(bytes.NewReader(body), res.Body),
+		Closer: res.Body,
+	}
+
+	return body, nil
+}
+
+// retryAfter returns the duration of the Retry-After header of rate limited
+// and unavailable responses. Only durations given in seconds are supported.
+func retryAfter(res *http.Response) time.Duration {
+	if res == nil || (res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable) {
+		return 0
+	}
+
+	s, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || s < 0 {
+		return 0
+	}
+
+	return time.Duration(s) * time.Second
+}
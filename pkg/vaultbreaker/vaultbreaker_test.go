@@ -0,0 +1,333 @@
+package vaultbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger/microloggertest"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+func Test_Classify(t *testing.T) {
+	testCases := []struct {
+		name          string
+		err           error
+		expectedClass string
+		expectedOut   bool
+	}{
+		{
+			name:          "case 0: no error",
+			err:           nil,
+			expectedClass: "",
+		},
+		{
+			name:          "case 1: sealed vault",
+			err:           &vaultapi.ResponseError{StatusCode: http.StatusServiceUnavailable, Errors: []string{"Vault is sealed"}},
+			expectedClass: ClassSealed,
+			expectedOut:   true,
+		},
+		{
+			name:          "case 2: server error",
+			err:           microerror.Mask(&vaultapi.ResponseError{StatusCode: http.StatusInternalServerError}),
+			expectedClass: ClassServerError,
+			expectedOut:   true,
+		},
+		{
+			name:          "case 3: rate limited",
+			err:           &vaultapi.ResponseError{StatusCode: http.StatusTooManyRequests},
+			expectedClass: ClassRateLimited,
+			expectedOut:   true,
+		},
+		{
+			name:          "case 4: permission denied",
+			err:           &vaultapi.ResponseError{StatusCode: http.StatusForbidden, Errors: []string{"permission denied"}},
+			expectedClass: ClassPermissionDenied,
+		},
+		{
+			name:          "case 5: unreachable vault",
+			err:           &url.Error{Op: "Get", URL: "https://vault:8200/v1/sys/mounts", Err: errors.New("connection refused")},
+			expectedClass: ClassUnavailable,
+			expectedOut:   true,
+		},
+		{
+			name:          "case 6: vault replying with HTTP to HTTPS requests",
+			err:           errors.New("Get https://vault:8200/v1/sys/mounts: http: server gave HTTP response to HTTPS client"),
+			expectedClass: ClassUnavailable,
+			expectedOut:   true,
+		},
+		{
+			name:          "case 7: request rejected by the circuit breaker",
+			err:           fmt.Errorf("giving up: %w", &url.Error{Op: "Get", URL: "https://vault:8200/v1/sys/mounts", Err: microerror.Mask(circuitOpenError)}),
+			expectedClass: "",
+			expectedOut:   true,
+		},
+		{
+			name:          "case 8: request canceled by its caller",
+			err:           &url.Error{Op: "Get", URL: "https://vault:8200/v1/sys/mounts", Err: context.Canceled},
+			expectedClass: "",
+		},
+		{
+			name:          "case 9: bad request",
+			err:           &vaultapi.ResponseError{StatusCode: http.StatusBadRequest},
+			expectedClass: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			class := Classify(tc.err)
+			if class != tc.expectedClass {
+				t.Fatalf("expected class %#q got %#q", tc.expectedClass, class)
+			}
+
+			outage := IsOutage(tc.err)
+			if outage != tc.expectedOut {
+				t.Fatalf("expected outage %t got %t", tc.expectedOut, outage)
+			}
+		})
+	}
+}
+
+// fakeVault answers every request with the configured status code and
+// message.
+type fakeVault struct {
+	mutex    sync.Mutex
+	requests int
+
+	message string
+	status  int
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.requests++
+
+	w.WriteHeader(f.status)
+	_, _ = io.WriteString(w, f.message)
+}
+
+func (f *fakeVault) set(status int, message string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.status = status
+	f.message = message
+}
+
+func Test_Breaker(t *testing.T) {
+	fake := &fakeVault{status: http.StatusServiceUnavailable, message: `{"errors":["Vault is sealed"]}`}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	now := time.Unix(0, 0)
+
+	b, err := New(Config{
+		Logger: microloggertest.New(),
+
+		FailureThreshold: 2,
+		MaxOpenDuration:  25 * time.Second,
+		OpenDuration:     10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.currentTimeFactory = func() time.Time { return now }
+
+	client := &http.Client{Transport: b.Wrap(http.DefaultTransport)}
+
+	get := func() error {
+		res, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		// The body read for the classification must still be readable.
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != fake.message {
+			t.Fatalf("expected body %#q got %#q", fake.message, body)
+		}
+
+		return nil
+	}
+
+	expectState := func(state string, open bool, requests int) {
+		t.Helper()
+
+		if b.Stats().State != state {
+			t.Fatalf("expected state %#q got %#q", state, b.Stats().State)
+		}
+		if b.Open() != open {
+			t.Fatalf("expected open %t got %t", open, b.Open())
+		}
+		if fake.requests != requests {
+			t.Fatalf("expected %d requests sent to vault got %d", requests, fake.requests)
+		}
+	}
+
+	// The circuit opens after the configured number of consecutive failures.
+	_ = get()
+	expectState(StateClosed, false, 1)
+	_ = get()
+	expectState(StateOpen, true, 2)
+
+	// Requests are rejected without being sent while the circuit is open.
+	err = get()
+	if !IsCircuitOpen(err) {
+		t.Fatalf("expected circuitOpenError got %#v", err)
+	}
+	expectState(StateOpen, true, 2)
+
+	// Vault is probed once the circuit has been open for long enough. A
+	// failed probe opens the circuit twice as long as before.
+	now = now.Add(10 * time.Second)
+	expectState(StateOpen, false, 2)
+	_ = get()
+	expectState(StateOpen, true, 3)
+	now = now.Add(19 * time.Second)
+	expectState(StateOpen, true, 3)
+
+	// The duration is capped at the maximum.
+	now = now.Add(1 * time.Second)
+	_ = get()
+	expectState(StateOpen, true, 4)
+	now = now.Add(25 * time.Second)
+	expectState(StateOpen, false, 4)
+
+	// Permission denied requests show that Vault is operational, so the
+	// probe closes the circuit.
+	fake.set(http.StatusForbidden, `{"errors":["permission denied"]}`)
+	_ = get()
+	expectState(StateClosed, false, 5)
+
+	stats := b.Stats()
+	if stats.Opened != 3 || stats.Rejected != 1 || stats.Failures[ClassSealed] != 4 || stats.Failures[ClassPermissionDenied] != 1 {
+		t.Fatalf("unexpected stats %#v", stats)
+	}
+
+	// The open duration starts over after the circuit closed.
+	fake.set(http.StatusInternalServerError, "")
+	_ = get()
+	_ = get()
+	expectState(StateOpen, true, 7)
+	now = now.Add(10 * time.Second)
+	expectState(StateOpen, false, 7)
+}
+
+func Test_Breaker_RetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "20")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	now := time.Unix(0, 0)
+
+	b, err := New(Config{
+		Logger: microloggertest.New(),
+
+		FailureThreshold: 1,
+		MaxOpenDuration:  time.Minute,
+		OpenDuration:     10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.currentTimeFactory = func() time.Time { return now }
+
+	client := &http.Client{Transport: b.Wrap(http.DefaultTransport)}
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// Vault asked for more time than the open duration.
+	now = now.Add(15 * time.Second)
+	if !b.Open() {
+		t.Fatal("expected circuit to respect the Retry-After header")
+	}
+	now = now.Add(5 * time.Second)
+	if b.Open() {
+		t.Fatal("expected circuit to allow probing after the Retry-After header")
+	}
+}
+
+func Test_Breaker_Disabled(t *testing.T) {
+	b, err := New(Config{
+		Logger: microloggertest.New(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Wrap(http.DefaultTransport) != http.DefaultTransport {
+		t.Fatal("expected transport not to be wrapped")
+	}
+
+	_, err = New(Config{
+		Logger:           microloggertest.New(),
+		FailureThreshold: 1,
+	})
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalidConfigError got %#v", err)
+	}
+}
+
+func Test_Breaker_VaultClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	b, err := New(Config{
+		Logger: microloggertest.New(),
+
+		FailureThreshold: 1,
+		MaxOpenDuration:  time.Minute,
+		OpenDuration:     time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := vaultapi.DefaultConfig()
+	c.Address = server.URL
+	c.HttpClient.Transport = b.Wrap(c.HttpClient.Transport)
+	c.MaxRetries = 0
+
+	vaultClient, err := vaultapi.NewClient(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = vaultClient.Logical().Read("sys/mounts")
+	if !IsServerError(err) {
+		t.Fatalf("expected server error got %#v", err)
+	}
+
+	// Rejected requests are asserted through the errors of the Vault client.
+	_, err = vaultClient.Logical().Read("sys/mounts")
+	if !IsCircuitOpen(err) || !IsOutage(err) {
+		t.Fatalf("expected circuitOpenError got %#v", err)
+	}
+	if !strings.Contains(err.Error(), "circuit open") {
+		t.Fatalf("expected error message to mention the open circuit, got %q", err.Error())
+	}
+}
@@ -0,0 +1,35 @@
+package vaultcache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	PrometheusNamespace = "cert_operator"
+	PrometheusSubsystem = "vault_cache"
+)
+
+var hitCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "hits_total",
+		Help:      "A metric counting the Vault lookups answered from the cache, labeled by the kind of lookup.",
+	},
+	[]string{"kind"},
+)
+
+var missCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "misses_total",
+		Help:      "A metric counting the Vault lookups sent to Vault because they were not cached, labeled by the kind of lookup.",
+	},
+	[]string{"kind"},
+)
+
+func init() {
+	prometheus.MustRegister(hitCounter)
+	prometheus.MustRegister(missCounter)
+}
@@ -0,0 +1,101 @@
+package vaultcache
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultpki"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+type PKIConfig struct {
+	Cache    *Cache
+	VaultPKI vaultpki.Interface
+}
+
+type pki struct {
+	vaultpki.Interface
+
+	cache *Cache
+}
+
+// NewPKI returns a vaultpki.Interface caching the PKI backends and the CA
+// certificates returned by the given implementation. The given
+// implementation is returned as it is in case the cache is disabled.
+func NewPKI(config PKIConfig) (vaultpki.Interface, error) {
+	if config.Cache == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Cache must not be empty", config)
+	}
+	if config.VaultPKI == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultPKI must not be empty", config)
+	}
+
+	if !config.Cache.enabled() {
+		return config.VaultPKI, nil
+	}
+
+	p := &pki{
+		Interface: config.VaultPKI,
+
+		cache: config.Cache,
+	}
+
+	return p, nil
+}
+
+func (p *pki) CreateBackend(ID string) error {
+	defer p.cache.Invalidate(ID)
+	return p.Interface.CreateBackend(ID)
+}
+
+func (p *pki) CreateCA(ID string) (vaultpki.CertificateAuthority, error) {
+	defer p.cache.Invalidate(ID)
+	return p.Interface.CreateCA(ID)
+}
+
+func (p *pki) CreateCAWithPrivateKey(ID string) (vaultpki.CertificateAuthority, error) {
+	defer p.cache.Invalidate(ID)
+	return p.Interface.CreateCAWithPrivateKey(ID)
+}
+
+// DeleteBackend invalidates the roles of the PKI backend as well, as they are
+// deleted along with it.
+func (p *pki) DeleteBackend(ID string) error {
+	defer p.cache.Invalidate(ID)
+	return p.Interface.DeleteBackend(ID)
+}
+
+func (p *pki) GetBackend(ID string) (*vaultapi.MountOutput, error) {
+	v, generation, ok := p.cache.get(kindBackend, ID, kindBackend)
+	if ok {
+		return v.(*vaultapi.MountOutput), nil
+	}
+
+	backend, err := p.Interface.GetBackend(ID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	p.cache.set(generation, ID, kindBackend, backend)
+
+	return backend, nil
+}
+
+// GetCACertificate does not cache empty CA certificates, as the CA of a PKI
+// backend may also be created without going through vaultpki.Interface,
+// e.g. when it is imported or signed by a parent CA.
+func (p *pki) GetCACertificate(ID string) (vaultpki.CertificateAuthority, error) {
+	v, generation, ok := p.cache.get(kindCACertificate, ID, kindCACertificate)
+	if ok {
+		return v.(vaultpki.CertificateAuthority), nil
+	}
+
+	ca, err := p.Interface.GetCACertificate(ID)
+	if err != nil {
+		return vaultpki.CertificateAuthority{}, microerror.Mask(err)
+	}
+
+	if ca.Certificate != "" {
+		p.cache.set(generation, ID, kindCACertificate, ca)
+	}
+
+	return ca, nil
+}
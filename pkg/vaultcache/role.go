@@ -0,0 +1,76 @@
+package vaultcache
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultrole"
+	"github.com/giantswarm/vaultrole/key"
+)
+
+type RoleConfig struct {
+	Cache     *Cache
+	VaultRole vaultrole.Interface
+}
+
+type role struct {
+	vaultrole.Interface
+
+	cache *Cache
+}
+
+// NewRole returns a vaultrole.Interface caching the roles returned by the
+// given implementation. The given implementation is returned as it is in
+// case the cache is disabled.
+func NewRole(config RoleConfig) (vaultrole.Interface, error) {
+	if config.Cache == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Cache must not be empty", config)
+	}
+	if config.VaultRole == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultRole must not be empty", config)
+	}
+
+	if !config.Cache.enabled() {
+		return config.VaultRole, nil
+	}
+
+	r := &role{
+		Interface: config.VaultRole,
+
+		cache: config.Cache,
+	}
+
+	return r, nil
+}
+
+func (r *role) Create(config vaultrole.CreateConfig) error {
+	defer r.cache.invalidate(config.ID, roleName(config.ID, config.Organizations))
+	return r.Interface.Create(config)
+}
+
+func (r *role) Search(config vaultrole.SearchConfig) (vaultrole.Role, error) {
+	name := roleName(config.ID, config.Organizations)
+
+	v, generation, ok := r.cache.get(kindRole, config.ID, name)
+	if ok {
+		return v.(vaultrole.Role), nil
+	}
+
+	result, err := r.Interface.Search(config)
+	if err != nil {
+		return vaultrole.Role{}, microerror.Mask(err)
+	}
+
+	r.cache.set(generation, config.ID, name, result)
+
+	return result, nil
+}
+
+func (r *role) Update(config vaultrole.UpdateConfig) error {
+	defer r.cache.invalidate(config.ID, roleName(config.ID, config.Organizations))
+	return r.Interface.Update(config)
+}
+
+// roleName returns the cache key of the role, which is prefixed so that it
+// cannot collide with the lookups of the PKI backend.
+func roleName(ID string, organizations []string) string {
+	return kindRole + "/" + key.RoleName(ID, organizations)
+}
@@ -0,0 +1,135 @@
+// Package vaultcache caches the Vault lookups done for every CertConfig on
+// every reconciliation. Many CertConfigs share the PKI backend and the roles
+// of their cluster, which rarely change. Entries expire after a TTL and are
+// invalidated whenever the operator changes the PKI backend or role they
+// belong to. Failed lookups are never cached.
+package vaultcache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	kindBackend       = "backend"
+	kindCACertificate = "ca_certificate"
+	kindRole          = "role"
+)
+
+type Config struct {
+	// TTL is the duration lookups are cached for. Zero disables the cache.
+	TTL time.Duration
+}
+
+// Cache is shared by the wrapped implementations of all controllers, so that
+// changes done by one controller invalidate the entries of the others.
+type Cache struct {
+	currentTimeFactory func() time.Time
+	ttl                time.Duration
+
+	mutex sync.Mutex
+	// entries maps the IDs of PKI backends to the cached lookups of the
+	// backend and its roles.
+	entries map[string]map[string]entry
+	// generation is incremented on every invalidation, so that lookups
+	// racing with a change are not cached.
+	generation uint64
+	lastSweep  time.Time
+}
+
+type entry struct {
+	expiresAt time.Time
+	value     interface{}
+}
+
+func New(config Config) (*Cache, error) {
+	if config.TTL < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TTL must not be negative", config)
+	}
+
+	c := &Cache{
+		currentTimeFactory: time.Now,
+		ttl:                config.TTL,
+
+		entries: map[string]map[string]entry{},
+	}
+
+	return c, nil
+}
+
+// Invalidate removes all cached lookups of the PKI backend of the given ID,
+// including the lookups of its roles.
+func (c *Cache) Invalidate(ID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generation++
+	delete(c.entries, ID)
+}
+
+func (c *Cache) enabled() bool {
+	return c.ttl > 0
+}
+
+// get returns the cached value of the given lookup, if any. In case of a
+// miss, the returned generation has to be given to set along with the result
+// of the lookup.
+func (c *Cache) get(kind string, ID string, name string) (interface{}, uint64, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[ID][name]
+	if !ok || !c.currentTimeFactory().Before(e.expiresAt) {
+		missCounter.WithLabelValues(kind).Inc()
+		return nil, c.generation, false
+	}
+
+	hitCounter.WithLabelValues(kind).Inc()
+
+	return e.value, c.generation, true
+}
+
+func (c *Cache) invalidate(ID string, name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generation++
+	delete(c.entries[ID], name)
+}
+
+func (c *Cache) set(generation uint64, ID string, name string, value interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	now := c.currentTimeFactory()
+
+	// Expired entries are dropped once per TTL, so that the entries of
+	// deleted PKI backends do not pile up.
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for id, names := range c.entries {
+			for n, e := range names {
+				if !now.Before(e.expiresAt) {
+					delete(names, n)
+				}
+			}
+			if len(names) == 0 {
+				delete(c.entries, id)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if c.entries[ID] == nil {
+		c.entries[ID] = map[string]entry{}
+	}
+	c.entries[ID][name] = entry{
+		expiresAt: now.Add(c.ttl),
+		value:     value,
+	}
+}
@@ -0,0 +1,231 @@
+package vaultcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultpki"
+	"github.com/giantswarm/vaultrole"
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var notFoundError = &microerror.Error{
+	Kind: "notFoundError",
+}
+
+// fakePKI counts the lookups of PKI backends and CA certificates.
+type fakePKI struct {
+	vaultpki.Interface
+
+	backendCalls int
+	caCalls      int
+
+	ca string
+}
+
+func (f *fakePKI) CreateCA(ID string) (vaultpki.CertificateAuthority, error) {
+	f.ca = "ca-" + ID
+	return vaultpki.CertificateAuthority{Certificate: f.ca}, nil
+}
+
+func (f *fakePKI) DeleteBackend(ID string) error {
+	return nil
+}
+
+func (f *fakePKI) GetBackend(ID string) (*vaultapi.MountOutput, error) {
+	f.backendCalls++
+	return &vaultapi.MountOutput{Type: "pki"}, nil
+}
+
+func (f *fakePKI) GetCACertificate(ID string) (vaultpki.CertificateAuthority, error) {
+	f.caCalls++
+	return vaultpki.CertificateAuthority{Certificate: f.ca}, nil
+}
+
+// fakeRole counts the searches of roles.
+type fakeRole struct {
+	vaultrole.Interface
+
+	searchCalls int
+
+	roles map[string]vaultrole.Role
+}
+
+func (f *fakeRole) Search(config vaultrole.SearchConfig) (vaultrole.Role, error) {
+	f.searchCalls++
+
+	role, ok := f.roles[roleName(config.ID, config.Organizations)]
+	if !ok {
+		return vaultrole.Role{}, microerror.Mask(notFoundError)
+	}
+
+	return role, nil
+}
+
+func (f *fakeRole) Update(config vaultrole.UpdateConfig) error {
+	f.roles[roleName(config.ID, config.Organizations)] = vaultrole.Role{ID: config.ID, Organizations: config.Organizations, AltNames: config.AltNames}
+	return nil
+}
+
+func newTestCache(t *testing.T, ttl time.Duration) (*Cache, *time.Time) {
+	c, err := New(Config{TTL: ttl})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(0, 0)
+	c.currentTimeFactory = func() time.Time { return now }
+
+	return c, &now
+}
+
+func Test_PKI(t *testing.T) {
+	cache, now := newTestCache(t, time.Minute)
+
+	fake := &fakePKI{}
+	p, err := NewPKI(PKIConfig{Cache: cache, VaultPKI: fake})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hits := testutil.ToFloat64(hitCounter.WithLabelValues(kindBackend))
+
+	// Backends are looked up once per TTL.
+	for i := 0; i < 3; i++ {
+		_, err = p.GetBackend("al9qy")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fake.backendCalls != 1 {
+		t.Fatalf("expected 1 backend lookup got %d", fake.backendCalls)
+	}
+	if testutil.ToFloat64(hitCounter.WithLabelValues(kindBackend))-hits != 2 {
+		t.Fatalf("expected 2 cache hits")
+	}
+
+	*now = now.Add(time.Minute)
+	_, err = p.GetBackend("al9qy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.backendCalls != 2 {
+		t.Fatalf("expected expired backend to be looked up again, got %d lookups", fake.backendCalls)
+	}
+
+	// Empty CA certificates are not cached, as the CA may be created without
+	// going through the cache.
+	_, _ = p.GetCACertificate("al9qy")
+	_, _ = p.GetCACertificate("al9qy")
+	if fake.caCalls != 2 {
+		t.Fatalf("expected empty CA certificates not to be cached, got %d lookups", fake.caCalls)
+	}
+
+	_, err = p.CreateCA("al9qy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		ca, err := p.GetCACertificate("al9qy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ca.Certificate != "ca-al9qy" {
+			t.Fatalf("expected CA certificate %#q got %#q", "ca-al9qy", ca.Certificate)
+		}
+	}
+	if fake.caCalls != 3 {
+		t.Fatalf("expected CA certificate to be cached, got %d lookups", fake.caCalls)
+	}
+
+	// Deleting the backend invalidates all of its lookups.
+	err = p.DeleteBackend("al9qy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = p.GetBackend("al9qy")
+	_, _ = p.GetCACertificate("al9qy")
+	if fake.backendCalls != 3 || fake.caCalls != 4 {
+		t.Fatalf("expected deleted backend to be looked up again, got %d backend and %d CA lookups", fake.backendCalls, fake.caCalls)
+	}
+}
+
+func Test_Role(t *testing.T) {
+	cache, _ := newTestCache(t, time.Minute)
+
+	fake := &fakeRole{roles: map[string]vaultrole.Role{}}
+	r, err := NewRole(RoleConfig{Cache: cache, VaultRole: fake})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	search := vaultrole.SearchConfig{ID: "al9qy", Organizations: []string{"system:masters"}}
+
+	// Roles which are not found are not cached.
+	_, err = r.Search(search)
+	if microerror.Cause(err) != notFoundError {
+		t.Fatalf("expected notFoundError got %#v", err)
+	}
+	_, _ = r.Search(search)
+	if fake.searchCalls != 2 {
+		t.Fatalf("expected missing roles not to be cached, got %d searches", fake.searchCalls)
+	}
+
+	err = r.Update(vaultrole.UpdateConfig{ID: "al9qy", Organizations: []string{"system:masters"}, AltNames: []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = r.Search(search)
+	_, _ = r.Search(search)
+	if fake.searchCalls != 3 {
+		t.Fatalf("expected role to be cached, got %d searches", fake.searchCalls)
+	}
+
+	// Roles of other organizations are cached separately.
+	_, _ = r.Search(vaultrole.SearchConfig{ID: "al9qy"})
+	if fake.searchCalls != 4 {
+		t.Fatalf("expected role of other organizations to be searched, got %d searches", fake.searchCalls)
+	}
+
+	// Updates invalidate the role.
+	err = r.Update(vaultrole.UpdateConfig{ID: "al9qy", Organizations: []string{"system:masters"}, AltNames: []string{"b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	role, err := r.Search(search)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(role.AltNames) != 1 || role.AltNames[0] != "b" {
+		t.Fatalf("expected updated role got %#v", role)
+	}
+
+	// Lookups racing with an update are not cached.
+	_, generation, _ := cache.get(kindRole, "al9qy", "other")
+	cache.Invalidate("al9qy")
+	cache.set(generation, "al9qy", "other", vaultrole.Role{})
+	_, _, ok := cache.get(kindRole, "al9qy", "other")
+	if ok {
+		t.Fatal("expected lookup racing with an invalidation not to be cached")
+	}
+}
+
+func Test_Disabled(t *testing.T) {
+	cache, _ := newTestCache(t, 0)
+
+	fake := &fakePKI{}
+	p, err := NewPKI(PKIConfig{Cache: cache, VaultPKI: fake})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != vaultpki.Interface(fake) {
+		t.Fatal("expected disabled cache to return the given implementation")
+	}
+
+	_, err = New(Config{TTL: -time.Second})
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalidConfigError got %#v", err)
+	}
+}
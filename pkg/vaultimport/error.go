@@ -0,0 +1,24 @@
+package vaultimport
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidCAError = &microerror.Error{
+	Kind: "invalidCAError",
+	Desc: "The CA to import into the Vault PKI backend is invalid.",
+}
+
+// IsInvalidCA asserts invalidCAError.
+func IsInvalidCA(err error) bool {
+	return microerror.Cause(err) == invalidCAError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
@@ -0,0 +1,8 @@
+package vaultimport
+
+type Interface interface {
+	// ImportCA imports the given PEM encoded CA certificate and private key
+	// as the CA of the PKI backend of the given ID. The PKI backend must
+	// exist already. The CA is validated using ValidateCA before.
+	ImportCA(ID string, certificate string, privateKey string) error
+}
@@ -0,0 +1,128 @@
+// Package vaultimport imports CAs provided by customers into the Vault PKI
+// backends of tenant clusters, instead of having Vault generate them.
+package vaultimport
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	vaultpkikey "github.com/giantswarm/vaultpki/key"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+type Config struct {
+	Logger      micrologger.Logger
+	VaultClient *vaultapi.Client
+}
+
+type VaultImport struct {
+	logger      micrologger.Logger
+	vaultClient *vaultapi.Client
+}
+
+func New(config Config) (*VaultImport, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.VaultClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultClient must not be empty", config)
+	}
+
+	i := &VaultImport{
+		logger:      config.Logger,
+		vaultClient: config.VaultClient,
+	}
+
+	return i, nil
+}
+
+func (i *VaultImport) ImportCA(ID string, certificate string, privateKey string) error {
+	err := ValidateCA(certificate, privateKey, time.Now())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	k := fmt.Sprintf("%s/config/ca", vaultpkikey.MountPKIPath(ID))
+	v := map[string]interface{}{
+		"pem_bundle": strings.TrimSpace(certificate) + "\n" + strings.TrimSpace(privateKey),
+	}
+
+	_, err = i.vaultClient.Logical().Write(k, v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// ValidateCA ensures the given PEM encoded certificate is a CA certificate
+// valid at the given time, which is allowed to sign certificates, and that
+// the given PEM encoded private key belongs to it. Further certificates
+// following the CA certificate are considered its chain and are not
+// validated.
+func ValidateCA(certificate string, privateKey string, now time.Time) error {
+	var crt *x509.Certificate
+	{
+		block, _ := pem.Decode([]byte(certificate))
+		if block == nil || block.Type != "CERTIFICATE" {
+			return microerror.Maskf(invalidCAError, "certificate must be PEM encoded")
+		}
+
+		var err error
+		crt, err = x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return microerror.Maskf(invalidCAError, "certificate must be valid: %s", err)
+		}
+	}
+
+	if !crt.BasicConstraintsValid || !crt.IsCA {
+		return microerror.Maskf(invalidCAError, "certificate must be a CA certificate")
+	}
+	if crt.KeyUsage != 0 && crt.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return microerror.Maskf(invalidCAError, "certificate must be allowed to sign certificates")
+	}
+	if now.Before(crt.NotBefore) || now.After(crt.NotAfter) {
+		return microerror.Maskf(invalidCAError, "certificate must be valid between %s and %s", crt.NotBefore, crt.NotAfter)
+	}
+
+	var signer crypto.Signer
+	{
+		block, _ := pem.Decode([]byte(privateKey))
+		if block == nil {
+			return microerror.Maskf(invalidCAError, "private key must be PEM encoded")
+		}
+
+		var k interface{}
+		var err error
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			k, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			k, err = x509.ParseECPrivateKey(block.Bytes)
+		default:
+			k, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+		if err != nil {
+			return microerror.Maskf(invalidCAError, "private key must be valid: %s", err)
+		}
+
+		var ok bool
+		signer, ok = k.(crypto.Signer)
+		if !ok {
+			return microerror.Maskf(invalidCAError, "private key must be a signing key")
+		}
+	}
+
+	p, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !p.Equal(crt.PublicKey) {
+		return microerror.Maskf(invalidCAError, "private key must belong to the certificate")
+	}
+
+	return nil
+}
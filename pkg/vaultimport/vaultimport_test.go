@@ -0,0 +1,105 @@
+package vaultimport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func Test_VaultImport_ValidateCA(t *testing.T) {
+	now := time.Now()
+
+	newKey := func() (*ecdsa.PrivateKey, string) {
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}))
+	}
+	newCertificate := func(k *ecdsa.PrivateKey, isCA bool, keyUsage x509.KeyUsage, notAfter time.Time) string {
+		template := &x509.Certificate{
+			BasicConstraintsValid: true,
+			IsCA:                  isCA,
+			KeyUsage:              keyUsage,
+			NotAfter:              notAfter,
+			NotBefore:             now.Add(-time.Hour),
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "Customer CA"},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, k.Public(), k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+
+	key, keyPEM := newKey()
+	_, otherKeyPEM := newKey()
+
+	testCases := []struct {
+		name         string
+		certificate  string
+		privateKey   string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:        "case 0: a CA with its private key is valid",
+			certificate: newCertificate(key, true, x509.KeyUsageCertSign|x509.KeyUsageCRLSign, now.Add(time.Hour)),
+			privateKey:  keyPEM,
+		},
+		{
+			name:         "case 1: the private key must belong to the certificate",
+			certificate:  newCertificate(key, true, x509.KeyUsageCertSign, now.Add(time.Hour)),
+			privateKey:   otherKeyPEM,
+			errorMatcher: IsInvalidCA,
+		},
+		{
+			name:         "case 2: the certificate must be a CA certificate",
+			certificate:  newCertificate(key, false, x509.KeyUsageDigitalSignature, now.Add(time.Hour)),
+			privateKey:   keyPEM,
+			errorMatcher: IsInvalidCA,
+		},
+		{
+			name:         "case 3: the CA must be allowed to sign certificates",
+			certificate:  newCertificate(key, true, x509.KeyUsageDigitalSignature, now.Add(time.Hour)),
+			privateKey:   keyPEM,
+			errorMatcher: IsInvalidCA,
+		},
+		{
+			name:         "case 4: the CA must not be expired",
+			certificate:  newCertificate(key, true, x509.KeyUsageCertSign, now.Add(-time.Minute)),
+			privateKey:   keyPEM,
+			errorMatcher: IsInvalidCA,
+		},
+		{
+			name:         "case 5: the certificate must be PEM encoded",
+			certificate:  "ca",
+			privateKey:   keyPEM,
+			errorMatcher: IsInvalidCA,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateCA(tc.certificate, tc.privateKey, now)
+			if err != nil {
+				if tc.errorMatcher == nil || !tc.errorMatcher(err) {
+					t.Fatalf("unexpected error %#v", err)
+				}
+				return
+			} else if tc.errorMatcher != nil {
+				t.Fatalf("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package vaultintermediate
+
+import (
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
+)
+
+type Interface interface {
+	// CreateCA creates the CA of the PKI backend of the given ID as
+	// intermediate CA signed by the configured parent. The PKI backend must
+	// exist already. The given constraints are embedded into the CA unless
+	// they are nil.
+	CreateCA(ID string, constraints *caconstraint.Constraints) error
+	// CreateCAWithPrivateKey works like CreateCA, but lets Vault export the
+	// private key of the CA, which is returned PEM encoded.
+	CreateCAWithPrivateKey(ID string, constraints *caconstraint.Constraints) (string, error)
+	// CAChain returns the PEM encoded certificates of the CA of the PKI
+	// backend of the given ID, followed by the certificates of its parents.
+	// The chain of self-signed CAs only consists of the CA itself, older Vault
+	// versions return an empty chain for them.
+	CAChain(ID string) (string, error)
+}
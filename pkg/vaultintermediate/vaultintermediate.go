@@ -0,0 +1,368 @@
+// Package vaultintermediate creates the CAs of tenant cluster PKI backends as
+// intermediate CAs chaining to a shared root, instead of the self-signed root
+// CAs created by vaultpki. The parent is either another Vault PKI mount or a
+// root CA whose private key is given to the operator.
+package vaultintermediate
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	vaultpkikey "github.com/giantswarm/vaultpki/key"
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
+)
+
+type Config struct {
+	Logger      micrologger.Logger
+	VaultClient *vaultapi.Client
+
+	CATTL            string
+	CommonNameFormat string
+	// ParentMount is the path of the Vault PKI mount signing the intermediate
+	// CAs, e.g. "pki-root". It must not be given together with
+	// ParentCertificate.
+	ParentMount string
+	// ParentVaultClient is used to sign with ParentMount, in case the parent
+	// mount is in another Vault namespace than the PKI backends. It defaults
+	// to VaultClient.
+	ParentVaultClient *vaultapi.Client
+	// ParentCertificate and ParentPrivateKey are the PEM encoded certificate
+	// and private key of the root CA signing the intermediate CAs.
+	ParentCertificate string
+	ParentPrivateKey  string
+}
+
+type VaultIntermediate struct {
+	logger            micrologger.Logger
+	vaultClient       *vaultapi.Client
+	parentVaultClient *vaultapi.Client
+
+	caTTL            time.Duration
+	commonNameFormat string
+	parentMount      string
+	parent           *x509.Certificate
+	parentPEM        string
+	parentKey        crypto.Signer
+}
+
+func New(config Config) (*VaultIntermediate, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.VaultClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultClient must not be empty", config)
+	}
+
+	if config.CommonNameFormat == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CommonNameFormat must not be empty", config)
+	}
+	caTTL, err := time.ParseDuration(config.CATTL)
+	if err != nil || caTTL <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CATTL must be a positive duration", config)
+	}
+	if (config.ParentMount == "") == (config.ParentCertificate == "") {
+		return nil, microerror.Maskf(invalidConfigError, "either %T.ParentMount or %T.ParentCertificate must be given", config, config)
+	}
+
+	if config.ParentVaultClient == nil {
+		config.ParentVaultClient = config.VaultClient
+	}
+
+	v := &VaultIntermediate{
+		logger:            config.Logger,
+		vaultClient:       config.VaultClient,
+		parentVaultClient: config.ParentVaultClient,
+
+		caTTL:            caTTL,
+		commonNameFormat: config.CommonNameFormat,
+		parentMount:      strings.Trim(config.ParentMount, "/"),
+	}
+
+	if config.ParentCertificate != "" {
+		v.parent, v.parentKey, err = parseParent(config.ParentCertificate, config.ParentPrivateKey)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		v.parentPEM = strings.TrimSpace(config.ParentCertificate)
+	}
+
+	return v, nil
+}
+
+func (v *VaultIntermediate) CreateCA(ID string, constraints *caconstraint.Constraints) error {
+	_, err := v.createCA(ID, constraints, false)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (v *VaultIntermediate) CreateCAWithPrivateKey(ID string, constraints *caconstraint.Constraints) (string, error) {
+	privateKey, err := v.createCA(ID, constraints, true)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return privateKey, nil
+}
+
+func (v *VaultIntermediate) createCA(ID string, constraints *caconstraint.Constraints, exported bool) (string, error) {
+	commonName := vaultpkikey.CommonName(ID, v.commonNameFormat)
+
+	generationType := "internal"
+	if exported {
+		generationType = "exported"
+	}
+
+	var csr string
+	var privateKey string
+	{
+		k := fmt.Sprintf("%s/intermediate/generate/%s", vaultpkikey.MountPKIPath(ID), generationType)
+		d := map[string]interface{}{
+			"common_name": commonName,
+			"ttl":         v.caTTL.String(),
+		}
+
+		secret, err := v.vaultClient.Logical().Write(k, d)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		csr, err = stringData(secret, "csr")
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		if exported {
+			privateKey, err = stringData(secret, "private_key")
+			if err != nil {
+				return "", microerror.Mask(err)
+			}
+		}
+	}
+
+	var chain string
+	{
+		var err error
+		if v.parentMount != "" {
+			chain, err = v.signWithMount(csr, commonName, constraints)
+		} else {
+			chain, err = v.signWithParent(csr, constraints)
+		}
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+	}
+
+	{
+		k := fmt.Sprintf("%s/intermediate/set-signed", vaultpkikey.MountPKIPath(ID))
+		d := map[string]interface{}{
+			"certificate": chain,
+		}
+
+		_, err := v.vaultClient.Logical().Write(k, d)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+	}
+
+	return privateKey, nil
+}
+
+func (v *VaultIntermediate) CAChain(ID string) (string, error) {
+	k := fmt.Sprintf("%s/cert/ca_chain", vaultpkikey.MountPKIPath(ID))
+
+	secret, err := v.vaultClient.Logical().Read(k)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	// Older Vault versions do not return the chain of self-signed CAs.
+	if secret == nil || secret.Data["certificate"] == nil {
+		return "", nil
+	}
+
+	chain, err := stringData(secret, "certificate")
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return strings.TrimSpace(chain), nil
+}
+
+// signWithMount signs the given CSR with the parent Vault PKI mount and
+// returns the signed certificate followed by the chain of the parent.
+func (v *VaultIntermediate) signWithMount(csr string, commonName string, constraints *caconstraint.Constraints) (string, error) {
+	k := fmt.Sprintf("%s/root/sign-intermediate", v.parentMount)
+	d := map[string]interface{}{}
+	if constraints != nil {
+		d = constraints.VaultParams()
+	}
+	d["common_name"] = commonName
+	d["csr"] = csr
+	d["format"] = "pem"
+	d["ttl"] = v.caTTL.String()
+
+	secret, err := v.parentVaultClient.Logical().Write(k, d)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	crt, err := stringData(secret, "certificate")
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	// Parents which are intermediates themselves return their whole chain.
+	// Older Vault versions only return the issuing CA.
+	parents := []string{}
+	if l, ok := secret.Data["ca_chain"].([]interface{}); ok {
+		for _, c := range l {
+			if s, ok := c.(string); ok && s != "" {
+				parents = append(parents, strings.TrimSpace(s))
+			}
+		}
+	}
+	if len(parents) == 0 {
+		issuingCA, err := stringData(secret, "issuing_ca")
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		parents = append(parents, strings.TrimSpace(issuingCA))
+	}
+
+	return strings.Join(append([]string{strings.TrimSpace(crt)}, parents...), "\n"), nil
+}
+
+// signWithParent signs the given CSR with the private key of the parent root
+// CA and returns the signed certificate followed by the root CA.
+func (v *VaultIntermediate) signWithParent(csrPEM string, constraints *caconstraint.Constraints) (string, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil {
+		return "", microerror.Maskf(executionFailedError, "csr must be PEM encoded")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	err = csr.CheckSignature()
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	// The intermediate CA must not outlive its parent.
+	now := time.Now()
+	notAfter := now.Add(v.caTTL)
+	if notAfter.After(v.parent.NotAfter) {
+		notAfter = v.parent.NotAfter
+	}
+
+	template := &x509.Certificate{
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		NotAfter:              notAfter,
+		NotBefore:             now.Add(-30 * time.Second),
+		SerialNumber:          serialNumber,
+		Subject:               csr.Subject,
+	}
+	if constraints != nil {
+		constraints.Apply(template)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, v.parent, csr.PublicKey, v.parentKey)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	crt := strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+
+	return crt + "\n" + v.parentPEM, nil
+}
+
+func parseParent(crtPEM, keyPEM string) (*x509.Certificate, crypto.Signer, error) {
+	var crt *x509.Certificate
+	{
+		block, _ := pem.Decode([]byte(crtPEM))
+		if block == nil || block.Type != "CERTIFICATE" {
+			return nil, nil, microerror.Maskf(invalidConfigError, "parent certificate must be PEM encoded")
+		}
+
+		var err error
+		crt, err = x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, microerror.Maskf(invalidConfigError, "parent certificate must be valid: %s", err)
+		}
+		if !crt.IsCA {
+			return nil, nil, microerror.Maskf(invalidConfigError, "parent certificate must be a CA")
+		}
+	}
+
+	var signer crypto.Signer
+	{
+		block, _ := pem.Decode([]byte(keyPEM))
+		if block == nil {
+			return nil, nil, microerror.Maskf(invalidConfigError, "parent private key must be PEM encoded")
+		}
+
+		var k interface{}
+		var err error
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			k, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			k, err = x509.ParseECPrivateKey(block.Bytes)
+		default:
+			k, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+		if err != nil {
+			return nil, nil, microerror.Maskf(invalidConfigError, "parent private key must be valid: %s", err)
+		}
+
+		var ok bool
+		signer, ok = k.(crypto.Signer)
+		if !ok {
+			return nil, nil, microerror.Maskf(invalidConfigError, "parent private key must be a signing key")
+		}
+
+		p, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+		if !ok || !p.Equal(crt.PublicKey) {
+			return nil, nil, microerror.Maskf(invalidConfigError, "parent private key must match the parent certificate")
+		}
+	}
+
+	return crt, signer, nil
+}
+
+func stringData(secret *vaultapi.Secret, k string) (string, error) {
+	if secret == nil {
+		return "", microerror.Maskf(executionFailedError, "response missing")
+	}
+
+	v, ok := secret.Data[k]
+	if !ok {
+		return "", microerror.Maskf(executionFailedError, "%s missing", k)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", microerror.Maskf(executionFailedError, "%s must be string", k)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,199 @@
+package vaultintermediate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
+)
+
+func Test_VaultIntermediate_signWithParent(t *testing.T) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rootPEM, rootKeyPEM string
+	{
+		template := &x509.Certificate{
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			KeyUsage:              x509.KeyUsageCertSign,
+			NotAfter:              time.Now().Add(24 * time.Hour),
+			NotBefore:             time.Now().Add(-time.Hour),
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "Corporate Root CA"},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, rootKey.Public(), rootKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rootPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+		b, err := x509.MarshalECPrivateKey(rootKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rootKeyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}))
+	}
+
+	vaultClient, err := vaultapi.NewClient(vaultapi.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var newIntermediate *VaultIntermediate
+	{
+		c := Config{
+			Logger:      microloggertest.New(),
+			VaultClient: vaultClient,
+
+			CATTL:             "87600h",
+			CommonNameFormat:  "%s.k8s.gigantic.io",
+			ParentCertificate: rootPEM,
+			ParentPrivateKey:  rootKeyPEM,
+		}
+
+		newIntermediate, err = New(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var csrPEM string
+	{
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.CertificateRequest{Subject: pkix.Name{CommonName: "al9qy.k8s.gigantic.io"}}
+		der, err := x509.CreateCertificateRequest(rand.Reader, template, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		csrPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+	}
+
+	constraints := &caconstraint.Constraints{
+		PermittedDNSDomains: []string{"al9qy.k8s.gigantic.io"},
+	}
+
+	chain, err := newIntermediate.signWithParent(csrPEM, constraints)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(chain, strings.TrimSpace(rootPEM)) {
+		t.Fatalf("expected chain to end with the parent certificate")
+	}
+
+	block, _ := pem.Decode([]byte(chain))
+	if block == nil {
+		t.Fatalf("expected chain to start with a PEM encoded certificate")
+	}
+	intermediate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !intermediate.IsCA {
+		t.Fatalf("expected intermediate to be a CA")
+	}
+	if intermediate.Subject.CommonName != "al9qy.k8s.gigantic.io" {
+		t.Fatalf("expected common name %#q got %#q", "al9qy.k8s.gigantic.io", intermediate.Subject.CommonName)
+	}
+	if !reflect.DeepEqual(intermediate.PermittedDNSDomains, constraints.PermittedDNSDomains) || !intermediate.MaxPathLenZero {
+		t.Fatalf("expected intermediate to be constrained to %#v got %#v", constraints.PermittedDNSDomains, intermediate.PermittedDNSDomains)
+	}
+	// The intermediate must not outlive its parent, although the CA TTL is
+	// longer.
+	if intermediate.NotAfter.After(newIntermediate.parent.NotAfter) {
+		t.Fatalf("expected intermediate to expire with its parent at %s got %s", newIntermediate.parent.NotAfter, intermediate.NotAfter)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM([]byte(rootPEM))
+	_, err = intermediate.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	if err != nil {
+		t.Fatalf("expected intermediate to chain to the parent got %#v", err)
+	}
+}
+
+func Test_VaultIntermediate_New(t *testing.T) {
+	vaultClient, err := vaultapi.NewClient(vaultapi.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name         string
+		config       Config
+		errorMatcher func(error) bool
+	}{
+		{
+			name: "case 0: a parent mount is valid",
+			config: Config{
+				CATTL:            "87600h",
+				CommonNameFormat: "%s.k8s.gigantic.io",
+				ParentMount:      "pki-root",
+			},
+		},
+		{
+			name: "case 1: a parent must be given",
+			config: Config{
+				CATTL:            "87600h",
+				CommonNameFormat: "%s.k8s.gigantic.io",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 2: only one parent must be given",
+			config: Config{
+				CATTL:             "87600h",
+				CommonNameFormat:  "%s.k8s.gigantic.io",
+				ParentCertificate: "crt",
+				ParentMount:       "pki-root",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 3: the parent certificate must be valid",
+			config: Config{
+				CATTL:             "87600h",
+				CommonNameFormat:  "%s.k8s.gigantic.io",
+				ParentCertificate: "crt",
+				ParentPrivateKey:  "key",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.Logger = microloggertest.New()
+			tc.config.VaultClient = vaultClient
+
+			_, err := New(tc.config)
+			if err != nil {
+				if tc.errorMatcher == nil || !tc.errorMatcher(err) {
+					t.Fatalf("unexpected error %#v", err)
+				}
+				return
+			} else if tc.errorMatcher != nil {
+				t.Fatalf("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package vaultmetrics
+
+import (
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultcrt"
+)
+
+type CrtConfig struct {
+	VaultCrt vaultcrt.Interface
+}
+
+type crt struct {
+	vaultCrt vaultcrt.Interface
+}
+
+// NewCrt returns a vaultcrt.Interface measuring the certificates issued by
+// the given implementation.
+func NewCrt(config CrtConfig) (vaultcrt.Interface, error) {
+	if config.VaultCrt == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultCrt must not be empty", config)
+	}
+
+	c := &crt{
+		vaultCrt: config.VaultCrt,
+	}
+
+	return c, nil
+}
+
+func (c *crt) Create(config vaultcrt.CreateConfig) (vaultcrt.CreateResult, error) {
+	start := time.Now()
+	result, err := c.vaultCrt.Create(config)
+	observeOperation("vaultcrt.Create", start, err)
+
+	return result, err
+}
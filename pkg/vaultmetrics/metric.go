@@ -0,0 +1,65 @@
+package vaultmetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	PrometheusNamespace = "cert_operator"
+	PrometheusSubsystem = "vault"
+)
+
+var (
+	// buckets range from 5ms to about 10s, which covers fast reads as well
+	// as certificates issued by Vaults with slow storage.
+	buckets = prometheus.ExponentialBuckets(0.005, 2, 12)
+)
+
+var operationDurationHistogram = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "operation_duration_seconds",
+		Help:      "A metric of the duration of Vault PKI, role and certificate operations, labeled by operation and status class.",
+		Buckets:   buckets,
+	},
+	[]string{"operation", "status_class"},
+)
+
+var operationErrorCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "operation_errors_total",
+		Help:      "A metric counting the failed Vault PKI, role and certificate operations, labeled by operation and status class.",
+	},
+	[]string{"operation", "status_class"},
+)
+
+var requestDurationHistogram = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "request_duration_seconds",
+		Help:      "A metric of the duration of Vault API requests, labeled by method, endpoint and status class.",
+		Buckets:   buckets,
+	},
+	[]string{"method", "operation", "status_class"},
+)
+
+var requestErrorCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "request_errors_total",
+		Help:      "A metric counting the failed Vault API requests, labeled by method, endpoint and status class.",
+	},
+	[]string{"method", "operation", "status_class"},
+)
+
+func init() {
+	prometheus.MustRegister(operationDurationHistogram)
+	prometheus.MustRegister(operationErrorCounter)
+	prometheus.MustRegister(requestDurationHistogram)
+	prometheus.MustRegister(requestErrorCounter)
+}
@@ -0,0 +1,103 @@
+package vaultmetrics
+
+import (
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultpki"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+type PKIConfig struct {
+	VaultPKI vaultpki.Interface
+}
+
+type pki struct {
+	vaultPKI vaultpki.Interface
+}
+
+// NewPKI returns a vaultpki.Interface measuring the operations of the given
+// implementation.
+func NewPKI(config PKIConfig) (vaultpki.Interface, error) {
+	if config.VaultPKI == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultPKI must not be empty", config)
+	}
+
+	p := &pki{
+		vaultPKI: config.VaultPKI,
+	}
+
+	return p, nil
+}
+
+func (p *pki) BackendExists(ID string) (bool, error) {
+	start := time.Now()
+	exists, err := p.vaultPKI.BackendExists(ID)
+	observeOperation("vaultpki.BackendExists", start, err)
+
+	return exists, err
+}
+
+func (p *pki) CAExists(ID string) (bool, error) {
+	start := time.Now()
+	exists, err := p.vaultPKI.CAExists(ID)
+	observeOperation("vaultpki.CAExists", start, err)
+
+	return exists, err
+}
+
+func (p *pki) CreateBackend(ID string) error {
+	start := time.Now()
+	err := p.vaultPKI.CreateBackend(ID)
+	observeOperation("vaultpki.CreateBackend", start, err)
+
+	return err
+}
+
+func (p *pki) CreateCA(ID string) (vaultpki.CertificateAuthority, error) {
+	start := time.Now()
+	ca, err := p.vaultPKI.CreateCA(ID)
+	observeOperation("vaultpki.CreateCA", start, err)
+
+	return ca, err
+}
+
+func (p *pki) CreateCAWithPrivateKey(ID string) (vaultpki.CertificateAuthority, error) {
+	start := time.Now()
+	ca, err := p.vaultPKI.CreateCAWithPrivateKey(ID)
+	observeOperation("vaultpki.CreateCAWithPrivateKey", start, err)
+
+	return ca, err
+}
+
+func (p *pki) DeleteBackend(ID string) error {
+	start := time.Now()
+	err := p.vaultPKI.DeleteBackend(ID)
+	observeOperation("vaultpki.DeleteBackend", start, err)
+
+	return err
+}
+
+func (p *pki) GetBackend(ID string) (*vaultapi.MountOutput, error) {
+	start := time.Now()
+	backend, err := p.vaultPKI.GetBackend(ID)
+	observeOperation("vaultpki.GetBackend", start, err)
+
+	return backend, err
+}
+
+func (p *pki) GetCACertificate(ID string) (vaultpki.CertificateAuthority, error) {
+	start := time.Now()
+	ca, err := p.vaultPKI.GetCACertificate(ID)
+	observeOperation("vaultpki.GetCACertificate", start, err)
+
+	return ca, err
+}
+
+func (p *pki) ListBackends() (map[string]*vaultapi.MountOutput, error) {
+	start := time.Now()
+	backends, err := p.vaultPKI.ListBackends()
+	observeOperation("vaultpki.ListBackends", start, err)
+
+	return backends, err
+}
@@ -0,0 +1,62 @@
+package vaultmetrics
+
+import (
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultrole"
+)
+
+type RoleConfig struct {
+	VaultRole vaultrole.Interface
+}
+
+type role struct {
+	vaultRole vaultrole.Interface
+}
+
+// NewRole returns a vaultrole.Interface measuring the operations of the
+// given implementation.
+func NewRole(config RoleConfig) (vaultrole.Interface, error) {
+	if config.VaultRole == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultRole must not be empty", config)
+	}
+
+	r := &role{
+		vaultRole: config.VaultRole,
+	}
+
+	return r, nil
+}
+
+func (r *role) Create(config vaultrole.CreateConfig) error {
+	start := time.Now()
+	err := r.vaultRole.Create(config)
+	observeOperation("vaultrole.Create", start, err)
+
+	return err
+}
+
+func (r *role) Exists(config vaultrole.ExistsConfig) (bool, error) {
+	start := time.Now()
+	exists, err := r.vaultRole.Exists(config)
+	observeOperation("vaultrole.Exists", start, err)
+
+	return exists, err
+}
+
+func (r *role) Search(config vaultrole.SearchConfig) (vaultrole.Role, error) {
+	start := time.Now()
+	result, err := r.vaultRole.Search(config)
+	observeOperation("vaultrole.Search", start, err)
+
+	return result, err
+}
+
+func (r *role) Update(config vaultrole.UpdateConfig) error {
+	start := time.Now()
+	err := r.vaultRole.Update(config)
+	observeOperation("vaultrole.Update", start, err)
+
+	return err
+}
@@ -0,0 +1,81 @@
+package vaultmetrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+type transport struct {
+	transport http.RoundTripper
+}
+
+// NewTransport returns a transport measuring the Vault API requests sent
+// through the given transport. Requests rejected before they are sent, e.g.
+// by the circuit breaker, are not measured in case the returned transport is
+// wrapped by the rejecting one.
+func NewTransport(t http.RoundTripper) http.RoundTripper {
+	return &transport{
+		transport: t,
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	res, err := t.transport.RoundTrip(req)
+
+	s := statusClassError
+	if err == nil {
+		s = statusClass(res.StatusCode)
+	}
+
+	method := req.Method
+	if req.URL.Query().Get("list") == "true" {
+		method = "LIST"
+	}
+	operation := endpoint(req.URL.Path)
+
+	requestDurationHistogram.WithLabelValues(method, operation, s).Observe(time.Since(start).Seconds())
+	if err != nil || res.StatusCode >= http.StatusBadRequest {
+		requestErrorCounter.WithLabelValues(method, operation, s).Inc()
+	}
+
+	return res, err
+}
+
+// endpoint returns the Vault endpoint of the given request path without the
+// IDs of PKI backends, roles and other objects, so that the number of label
+// values is bounded. Every mount besides sys and auth is expected to be a PKI
+// mount, e.g.
+//
+//	/v1/pki-al9qy/issue/role-org-x   -> pki/issue
+//	/v1/sys/mounts/pki-al9qy         -> sys/mounts
+//	/v1/auth/token/renew-self        -> auth/token/renew-self
+//	/v1/auth/approle/login           -> auth/login
+func endpoint(path string) string {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/v1/"), "/"), "/")
+
+	switch segments[0] {
+	case "":
+		return "unknown"
+	case "sys":
+		if len(segments) < 2 {
+			return "sys"
+		}
+		return "sys/" + segments[1]
+	case "auth":
+		if segments[len(segments)-1] == "login" {
+			return "auth/login"
+		}
+		if len(segments) >= 3 && segments[1] == "token" {
+			return "auth/token/" + segments[2]
+		}
+		return "auth"
+	default:
+		if len(segments) < 2 {
+			return "pki"
+		}
+		return "pki/" + segments[1]
+	}
+}
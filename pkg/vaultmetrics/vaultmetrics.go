@@ -0,0 +1,62 @@
+// Package vaultmetrics instruments the Vault interactions of the operator.
+// The Vault API requests of the Vault client are measured by the transport
+// returned by NewTransport, labeled by the endpoint they are sent to. The
+// operations of vaultpki.Interface, vaultrole.Interface and vaultcrt.Interface
+// are measured by the implementations returned by NewPKI, NewRole and NewCrt,
+// so that the time spent in e.g. issuing certificates can be told apart from
+// the time spent in looking up roles.
+//
+// Both are labeled by status class, which is the class of the HTTP status
+// code Vault answered with, e.g. 2xx or 5xx, or error in case Vault did not
+// answer, e.g. because it is unreachable or the request was rejected by the
+// circuit breaker.
+package vaultmetrics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/vaultpki"
+	"github.com/giantswarm/vaultrole"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+const (
+	statusClassError = "error"
+)
+
+// observeOperation records the duration and the outcome of an operation
+// started at the given time.
+func observeOperation(operation string, start time.Time, err error) {
+	s := operationStatusClass(err)
+
+	operationDurationHistogram.WithLabelValues(operation, s).Observe(time.Since(start).Seconds())
+	if err != nil {
+		operationErrorCounter.WithLabelValues(operation, s).Inc()
+	}
+}
+
+// operationStatusClass returns the status class of the Vault response an
+// operation failed with. Resources which do not exist are reported by Vault
+// with 4xx status codes or empty responses, so they are classified as 4xx.
+func operationStatusClass(err error) string {
+	if err == nil {
+		return statusClass(200)
+	}
+
+	var responseErr *vaultapi.ResponseError
+	if errors.As(err, &responseErr) {
+		return statusClass(responseErr.StatusCode)
+	}
+
+	if vaultpki.IsNotFound(err) || vaultrole.IsNotFound(err) {
+		return statusClass(404)
+	}
+
+	return statusClassError
+}
+
+func statusClass(statusCode int) string {
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
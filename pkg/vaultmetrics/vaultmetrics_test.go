@@ -0,0 +1,145 @@
+package vaultmetrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultpki"
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_endpoint(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/v1/pki-al9qy/issue/role-org-abc", expected: "pki/issue"},
+		{path: "/v1/pki-al9qy-etcd/roles/role-org-abc", expected: "pki/roles"},
+		{path: "/v1/pki-al9qy/root/generate/internal", expected: "pki/root"},
+		{path: "/v1/pki-al9qy/cert/ca", expected: "pki/cert"},
+		{path: "/v1/pki-al9qy", expected: "pki"},
+		{path: "/v1/sys/mounts/pki-al9qy", expected: "sys/mounts"},
+		{path: "/v1/sys/namespaces/tc-al9qy", expected: "sys/namespaces"},
+		{path: "/v1/auth/token/renew-self", expected: "auth/token/renew-self"},
+		{path: "/v1/auth/approle/login", expected: "auth/login"},
+		{path: "/v1/auth/cert-operator/login", expected: "auth/login"},
+		{path: "/v1/", expected: "unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			e := endpoint(tc.path)
+			if e != tc.expected {
+				t.Fatalf("expected endpoint %#q got %#q", tc.expected, e)
+			}
+		})
+	}
+}
+
+func Test_operationStatusClass(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "case 0: success", err: nil, expected: "2xx"},
+		{name: "case 1: rate limited", err: microerror.Mask(&vaultapi.ResponseError{StatusCode: http.StatusTooManyRequests}), expected: "4xx"},
+		{name: "case 2: sealed", err: &vaultapi.ResponseError{StatusCode: http.StatusServiceUnavailable}, expected: "5xx"},
+		{name: "case 3: unreachable", err: errors.New("connection refused"), expected: "error"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := operationStatusClass(tc.err)
+			if s != tc.expected {
+				t.Fatalf("expected status class %#q got %#q", tc.expected, s)
+			}
+		})
+	}
+}
+
+func Test_Transport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/pki-al9qy/issue/role" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(http.DefaultTransport)}
+
+	failed := testutil.ToFloat64(requestErrorCounter.WithLabelValues(http.MethodPut, "pki/issue", "5xx"))
+	series := testutil.CollectAndCount(requestDurationHistogram)
+
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/v1/pki-al9qy/issue/role", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	res, err = client.Get(server.URL + "/v1/pki-al9qy/roles?list=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if testutil.ToFloat64(requestErrorCounter.WithLabelValues(http.MethodPut, "pki/issue", "5xx"))-failed != 1 {
+		t.Fatal("expected failed request to be counted")
+	}
+	if testutil.CollectAndCount(requestDurationHistogram)-series != 2 {
+		t.Fatal("expected issue and list requests to be measured separately")
+	}
+	if testutil.ToFloat64(requestErrorCounter.WithLabelValues("LIST", "pki/roles", "2xx")) != 0 {
+		t.Fatal("expected successful request not to be counted as error")
+	}
+}
+
+type fakePKI struct {
+	vaultpki.Interface
+}
+
+func (f *fakePKI) GetBackend(ID string) (*vaultapi.MountOutput, error) {
+	return nil, &vaultapi.ResponseError{StatusCode: http.StatusTooManyRequests}
+}
+
+func (f *fakePKI) GetCACertificate(ID string) (vaultpki.CertificateAuthority, error) {
+	return vaultpki.CertificateAuthority{Certificate: "ca"}, nil
+}
+
+func Test_PKI(t *testing.T) {
+	p, err := NewPKI(PKIConfig{VaultPKI: &fakePKI{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := testutil.ToFloat64(operationErrorCounter.WithLabelValues("vaultpki.GetBackend", "4xx"))
+
+	_, err = p.GetBackend("al9qy")
+	if err == nil {
+		t.Fatal("expected error got nil")
+	}
+	ca, err := p.GetCACertificate("al9qy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca.Certificate != "ca" {
+		t.Fatalf("expected CA certificate to be passed through, got %#q", ca.Certificate)
+	}
+
+	if testutil.ToFloat64(operationErrorCounter.WithLabelValues("vaultpki.GetBackend", "4xx"))-failed != 1 {
+		t.Fatal("expected failed operation to be counted")
+	}
+	if testutil.ToFloat64(operationErrorCounter.WithLabelValues("vaultpki.GetCACertificate", "2xx")) != 0 {
+		t.Fatal("expected successful operation not to be counted as error")
+	}
+}
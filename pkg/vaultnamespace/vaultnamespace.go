@@ -0,0 +1,188 @@
+// Package vaultnamespace places the Vault PKI backends of tenant clusters in
+// Vault Enterprise namespaces. The namespace is either the same for all
+// tenant clusters or templated with the cluster ID, in which case every
+// tenant cluster gets a child namespace of its own.
+package vaultnamespace
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+)
+
+const (
+	// placeholder is replaced by the cluster ID in templated namespaces.
+	placeholder = "%s"
+)
+
+type Config struct {
+	// Format is the Vault namespace, e.g. "giantswarm/ghost". The last path
+	// element may contain %s, which is replaced by the cluster ID, e.g.
+	// "giantswarm/ghost/%s". An empty format uses the root namespace.
+	Format string
+	// TrustDomains maps the IDs of trust domain PKI backends to the cluster
+	// IDs they belong to.
+	TrustDomains trustdomain.Mapping
+}
+
+type Namespace struct {
+	base         string
+	name         string
+	trustDomains trustdomain.Mapping
+}
+
+func New(config Config) (*Namespace, error) {
+	format := strings.Trim(config.Format, "/")
+
+	var base, name string
+	switch n := strings.Count(format, placeholder); {
+	case n == 0:
+		base = format
+	case n > 1:
+		return nil, microerror.Maskf(invalidConfigError, "%T.Format must contain %s at most once", config, placeholder)
+	default:
+		i := strings.LastIndex(format, "/")
+		if strings.Contains(format[:i+1], placeholder) {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Format must contain %s in its last path element only", config, placeholder)
+		}
+		if i >= 0 {
+			base = format[:i]
+		}
+		name = format[i+1:]
+	}
+
+	if strings.Contains(format, "//") {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Format must not contain empty path elements", config)
+	}
+
+	n := &Namespace{
+		base:         base,
+		name:         name,
+		trustDomains: config.TrustDomains,
+	}
+
+	return n, nil
+}
+
+// Base returns the namespace tokens, mounts and metrics are managed in. It
+// is the parent of the namespaces of the tenant clusters in case the
+// namespace is templated.
+func (n *Namespace) Base() string {
+	return n.base
+}
+
+// Templated returns whether every tenant cluster has its own namespace.
+func (n *Namespace) Templated() bool {
+	return n.name != ""
+}
+
+// Cluster returns the namespace of the PKI backends of the given tenant
+// cluster.
+func (n *Namespace) Cluster(clusterID string) string {
+	if !n.Templated() {
+		return n.base
+	}
+
+	return join(n.base, n.childName(clusterID))
+}
+
+// Client returns a Vault client using the namespace of the PKI backend of
+// the given ID. The given client is expected to use the base namespace.
+func (n *Namespace) Client(vaultClient *vaultapi.Client, ID string) *vaultapi.Client {
+	if !n.Templated() {
+		return vaultClient
+	}
+
+	return vaultClient.WithNamespace(n.Cluster(n.trustDomains.ClusterID(ID)))
+}
+
+// ClusterIDs returns the IDs of the tenant clusters whose namespaces exist
+// below the base namespace.
+func (n *Namespace) ClusterIDs(vaultClient *vaultapi.Client) ([]string, error) {
+	if !n.Templated() {
+		return nil, nil
+	}
+
+	secret, err := vaultClient.Logical().List("sys/namespaces")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if secret == nil {
+		return nil, nil
+	}
+
+	keys, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	var clusterIDs []string
+	for _, k := range keys {
+		s, ok := k.(string)
+		if !ok {
+			continue
+		}
+
+		id, ok := n.clusterID(strings.TrimSuffix(s, "/"))
+		if ok {
+			clusterIDs = append(clusterIDs, id)
+		}
+	}
+
+	return clusterIDs, nil
+}
+
+// EnsureCluster creates the namespace of the given tenant cluster below the
+// base namespace, unless it exists already.
+func (n *Namespace) EnsureCluster(vaultClient *vaultapi.Client, clusterID string) error {
+	if !n.Templated() {
+		return nil
+	}
+
+	p := fmt.Sprintf("sys/namespaces/%s", n.childName(clusterID))
+
+	secret, err := vaultClient.Logical().Read(p)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if secret != nil {
+		return nil
+	}
+
+	_, err = vaultClient.Logical().Write(p, nil)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (n *Namespace) childName(clusterID string) string {
+	return strings.Replace(n.name, placeholder, clusterID, 1)
+}
+
+// clusterID reverses childName. Namespaces not matching the template are
+// not owned by the operator.
+func (n *Namespace) clusterID(childName string) (string, bool) {
+	i := strings.Index(n.name, placeholder)
+	prefix := n.name[:i]
+	suffix := n.name[i+len(placeholder):]
+
+	if len(childName) <= len(prefix)+len(suffix) || !strings.HasPrefix(childName, prefix) || !strings.HasSuffix(childName, suffix) {
+		return "", false
+	}
+
+	return childName[len(prefix) : len(childName)-len(suffix)], true
+}
+
+func join(base, name string) string {
+	if base == "" {
+		return name
+	}
+
+	return base + "/" + name
+}
@@ -0,0 +1,279 @@
+package vaultnamespace
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/vaultpki"
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+)
+
+func Test_Namespace_New(t *testing.T) {
+	testCases := []struct {
+		name              string
+		format            string
+		expectedBase      string
+		expectedCluster   string
+		expectedTemplated bool
+		errorMatcher      func(error) bool
+	}{
+		{
+			name:            "case 0: root namespace",
+			format:          "",
+			expectedBase:    "",
+			expectedCluster: "",
+		},
+		{
+			name:            "case 1: static namespace",
+			format:          "/giantswarm/ghost/",
+			expectedBase:    "giantswarm/ghost",
+			expectedCluster: "giantswarm/ghost",
+		},
+		{
+			name:              "case 2: templated namespace",
+			format:            "giantswarm/ghost/tc-%s",
+			expectedBase:      "giantswarm/ghost",
+			expectedCluster:   "giantswarm/ghost/tc-al9qy",
+			expectedTemplated: true,
+		},
+		{
+			name:              "case 3: templated namespace below the root namespace",
+			format:            "%s",
+			expectedBase:      "",
+			expectedCluster:   "al9qy",
+			expectedTemplated: true,
+		},
+		{
+			name:         "case 4: placeholder outside of the last path element",
+			format:       "giantswarm/%s/pki",
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 5: placeholder given twice",
+			format:       "giantswarm/%s-%s",
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 6: empty path element",
+			format:       "giantswarm//%s",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := New(Config{Format: tc.format})
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.errorMatcher != nil {
+				return
+			}
+
+			if n.Base() != tc.expectedBase {
+				t.Fatalf("expected base %#q got %#q", tc.expectedBase, n.Base())
+			}
+			if n.Cluster("al9qy") != tc.expectedCluster {
+				t.Fatalf("expected cluster namespace %#q got %#q", tc.expectedCluster, n.Cluster("al9qy"))
+			}
+			if n.Templated() != tc.expectedTemplated {
+				t.Fatalf("expected templated %t got %t", tc.expectedTemplated, n.Templated())
+			}
+		})
+	}
+}
+
+// fakeVault serves the namespaces and mounts of a Vault Enterprise server.
+// The mounts are keyed by the namespace they are requested in.
+type fakeVault struct {
+	mutex      sync.Mutex
+	created    []string
+	mounts     map[string][]string
+	namespaces []string
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	ns := r.Header.Get(vaultapi.NamespaceHeaderName)
+
+	switch {
+	case r.URL.Path == "/v1/sys/namespaces" && r.URL.Query().Get("list") == "true" && ns == "giantswarm":
+		var keys []string
+		for _, n := range f.namespaces {
+			keys = append(keys, n+"/")
+		}
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+	case r.URL.Path == "/v1/sys/namespaces/tc-al9qy" && ns == "giantswarm":
+		if r.Method == http.MethodGet {
+			for _, n := range f.namespaces {
+				if n == "tc-al9qy" {
+					writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"path": "giantswarm/tc-al9qy/"}})
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.created = append(f.created, "tc-al9qy")
+		f.namespaces = append(f.namespaces, "tc-al9qy")
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{}})
+	case r.URL.Path == "/v1/sys/mounts" && r.Method == http.MethodGet:
+		mounts := map[string]interface{}{}
+		for _, m := range f.mounts[ns] {
+			mounts[m] = map[string]interface{}{"type": "pki"}
+		}
+		writeJSON(w, mounts)
+	case r.URL.Path == "/v1/sys/mounts/pki-al9qy" && r.Method != http.MethodGet:
+		f.mounts[ns] = append(f.mounts[ns], "pki-al9qy/")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newTestPKI(t *testing.T, fake *fakeVault, format string) vaultpki.Interface {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	c := vaultapi.DefaultConfig()
+	c.Address = server.URL
+	c.MaxRetries = 0
+
+	vaultClient, err := vaultapi.NewClient(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaultClient.SetToken("token")
+
+	n, err := New(Config{Format: format, TrustDomains: trustdomain.Mapping{"etcd": "etcd"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaultClient.SetNamespace(n.Base())
+
+	p, err := NewPKI(WrapConfig[vaultpki.Interface]{
+		Namespace:   n,
+		VaultClient: vaultClient,
+		New: func(vaultClient *vaultapi.Client) (vaultpki.Interface, error) {
+			c := vaultpki.Config{
+				Logger:      microloggertest.New(),
+				VaultClient: vaultClient,
+
+				CATTL:            "87600h",
+				CommonNameFormat: "%s.k8s.gigantic.io",
+			}
+
+			return vaultpki.New(c)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func Test_PKI_Templated(t *testing.T) {
+	fake := &fakeVault{
+		mounts: map[string][]string{
+			"giantswarm/tc-5xchu": {"pki-5xchu/", "pki-5xchu-etcd/"},
+			"giantswarm/tc-al9qy": {"pki-al9qy-etcd/"},
+		},
+		namespaces: []string{"other", "tc-5xchu"},
+	}
+
+	p := newTestPKI(t, fake, "giantswarm/tc-%s")
+
+	// The namespace of a tenant cluster is created along with its first PKI
+	// backend only.
+	err := p.CreateBackend("al9qy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.CreateBackend("al9qy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fake.created, []string{"tc-al9qy"}) {
+		t.Fatalf("expected namespace %#q to be created once, got %v", "tc-al9qy", fake.created)
+	}
+	if len(fake.mounts["giantswarm/tc-al9qy"]) != 3 {
+		t.Fatalf("expected PKI backends to be mounted in the namespace of the cluster, got %v", fake.mounts)
+	}
+
+	exists, err := p.BackendExists("al9qy-etcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatalf("expected trust domain PKI backend to be looked up in the namespace of the cluster")
+	}
+
+	// Only the namespaces of tenant clusters are listed. Like vaultpki does,
+	// trust domain PKI backends are not listed.
+	backends, err := p.ListBackends()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for k := range backends {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	expected := []string{"pki-5xchu/", "pki-al9qy/"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expected backends %v got %v", expected, keys)
+	}
+}
+
+func Test_PKI_Static(t *testing.T) {
+	fake := &fakeVault{
+		mounts: map[string][]string{
+			"giantswarm": {"pki-5xchu/"},
+		},
+	}
+
+	p := newTestPKI(t, fake, "giantswarm")
+
+	err := p.CreateBackend("al9qy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.created) != 0 {
+		t.Fatalf("expected no namespace to be created, got %v", fake.created)
+	}
+
+	backends, err := p.ListBackends()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backends) != 2 {
+		t.Fatalf("expected backends of the static namespace, got %v", backends)
+	}
+}
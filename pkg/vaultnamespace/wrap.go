@@ -0,0 +1,431 @@
+package vaultnamespace
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultcrt"
+	"github.com/giantswarm/vaultpki"
+	"github.com/giantswarm/vaultrole"
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultimport"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultintermediate"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultroot"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultsign"
+)
+
+// WrapConfig configures the wrapping of a Vault PKI implementation, e.g.
+// vaultpki.Interface, so that it operates in the namespace of the PKI backend
+// it is called for.
+type WrapConfig[T any] struct {
+	Namespace *Namespace
+	// VaultClient is the client using the base namespace.
+	VaultClient *vaultapi.Client
+
+	// New creates the implementation using the given client. It is called
+	// for every operation in case the namespace is templated.
+	New func(vaultClient *vaultapi.Client) (T, error)
+}
+
+type wrapped[T any] struct {
+	namespace   *Namespace
+	vaultClient *vaultapi.Client
+	new         func(vaultClient *vaultapi.Client) (T, error)
+}
+
+// wrap returns the implementation of the base namespace in case the
+// namespace is not templated. Otherwise the returned wrapped is embedded by
+// the wrapping implementation.
+func wrap[T any](config WrapConfig[T]) (T, *wrapped[T], error) {
+	var empty T
+
+	if config.Namespace == nil {
+		return empty, nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+	if config.VaultClient == nil {
+		return empty, nil, microerror.Maskf(invalidConfigError, "%T.VaultClient must not be empty", config)
+	}
+	if config.New == nil {
+		return empty, nil, microerror.Maskf(invalidConfigError, "%T.New must not be empty", config)
+	}
+
+	if !config.Namespace.Templated() {
+		t, err := config.New(config.VaultClient)
+		if err != nil {
+			return empty, nil, microerror.Mask(err)
+		}
+
+		return t, nil, nil
+	}
+
+	w := &wrapped[T]{
+		namespace:   config.Namespace,
+		vaultClient: config.VaultClient,
+		new:         config.New,
+	}
+
+	return empty, w, nil
+}
+
+// forID returns the implementation operating in the namespace of the PKI
+// backend of the given ID.
+func (w *wrapped[T]) forID(ID string) (T, error) {
+	t, err := w.new(w.namespace.Client(w.vaultClient, ID))
+	if err != nil {
+		return t, microerror.Mask(err)
+	}
+
+	return t, nil
+}
+
+type pki struct {
+	*wrapped[vaultpki.Interface]
+}
+
+// NewPKI returns a vaultpki.Interface managing every PKI backend in the
+// namespace of its tenant cluster. The namespaces of tenant clusters are
+// created along with their first PKI backend. They are not deleted along with
+// their PKI backends, as they may hold other secrets.
+func NewPKI(config WrapConfig[vaultpki.Interface]) (vaultpki.Interface, error) {
+	t, w, err := wrap(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if w == nil {
+		return t, nil
+	}
+
+	return &pki{wrapped: w}, nil
+}
+
+func (p *pki) BackendExists(ID string) (bool, error) {
+	t, err := p.forID(ID)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return t.BackendExists(ID)
+}
+
+func (p *pki) CAExists(ID string) (bool, error) {
+	t, err := p.forID(ID)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return t.CAExists(ID)
+}
+
+func (p *pki) CreateBackend(ID string) error {
+	err := p.namespace.EnsureCluster(p.vaultClient, p.namespace.trustDomains.ClusterID(ID))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	t, err := p.forID(ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return t.CreateBackend(ID)
+}
+
+func (p *pki) CreateCA(ID string) (vaultpki.CertificateAuthority, error) {
+	t, err := p.forID(ID)
+	if err != nil {
+		return vaultpki.CertificateAuthority{}, microerror.Mask(err)
+	}
+
+	return t.CreateCA(ID)
+}
+
+func (p *pki) CreateCAWithPrivateKey(ID string) (vaultpki.CertificateAuthority, error) {
+	t, err := p.forID(ID)
+	if err != nil {
+		return vaultpki.CertificateAuthority{}, microerror.Mask(err)
+	}
+
+	return t.CreateCAWithPrivateKey(ID)
+}
+
+func (p *pki) DeleteBackend(ID string) error {
+	t, err := p.forID(ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return t.DeleteBackend(ID)
+}
+
+func (p *pki) GetBackend(ID string) (*vaultapi.MountOutput, error) {
+	t, err := p.forID(ID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return t.GetBackend(ID)
+}
+
+func (p *pki) GetCACertificate(ID string) (vaultpki.CertificateAuthority, error) {
+	t, err := p.forID(ID)
+	if err != nil {
+		return vaultpki.CertificateAuthority{}, microerror.Mask(err)
+	}
+
+	return t.GetCACertificate(ID)
+}
+
+// ListBackends lists the PKI backends of the namespaces of all tenant
+// clusters.
+func (p *pki) ListBackends() (map[string]*vaultapi.MountOutput, error) {
+	clusterIDs, err := p.namespace.ClusterIDs(p.vaultClient)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	backends := map[string]*vaultapi.MountOutput{}
+	for _, id := range clusterIDs {
+		t, err := p.forID(id)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		m, err := t.ListBackends()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for k, v := range m {
+			backends[k] = v
+		}
+	}
+
+	return backends, nil
+}
+
+type role struct {
+	*wrapped[vaultrole.Interface]
+}
+
+// NewRole returns a vaultrole.Interface managing the roles of every PKI
+// backend in the namespace of its tenant cluster.
+func NewRole(config WrapConfig[vaultrole.Interface]) (vaultrole.Interface, error) {
+	t, w, err := wrap(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if w == nil {
+		return t, nil
+	}
+
+	return &role{wrapped: w}, nil
+}
+
+func (r *role) Create(config vaultrole.CreateConfig) error {
+	t, err := r.forID(config.ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return t.Create(config)
+}
+
+func (r *role) Exists(config vaultrole.ExistsConfig) (bool, error) {
+	t, err := r.forID(config.ID)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return t.Exists(config)
+}
+
+func (r *role) Search(config vaultrole.SearchConfig) (vaultrole.Role, error) {
+	t, err := r.forID(config.ID)
+	if err != nil {
+		return vaultrole.Role{}, microerror.Mask(err)
+	}
+
+	return t.Search(config)
+}
+
+func (r *role) Update(config vaultrole.UpdateConfig) error {
+	t, err := r.forID(config.ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return t.Update(config)
+}
+
+type crt struct {
+	*wrapped[vaultcrt.Interface]
+}
+
+// NewCrt returns a vaultcrt.Interface issuing certificates with every PKI
+// backend in the namespace of its tenant cluster.
+func NewCrt(config WrapConfig[vaultcrt.Interface]) (vaultcrt.Interface, error) {
+	t, w, err := wrap(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if w == nil {
+		return t, nil
+	}
+
+	return &crt{wrapped: w}, nil
+}
+
+func (c *crt) Create(config vaultcrt.CreateConfig) (vaultcrt.CreateResult, error) {
+	t, err := c.forID(config.ID)
+	if err != nil {
+		return vaultcrt.CreateResult{}, microerror.Mask(err)
+	}
+
+	return t.Create(config)
+}
+
+type imp struct {
+	*wrapped[vaultimport.Interface]
+}
+
+// NewImport returns a vaultimport.Interface importing CAs into every PKI
+// backend in the namespace of its tenant cluster.
+func NewImport(config WrapConfig[vaultimport.Interface]) (vaultimport.Interface, error) {
+	t, w, err := wrap(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if w == nil {
+		return t, nil
+	}
+
+	return &imp{wrapped: w}, nil
+}
+
+func (i *imp) ImportCA(ID string, certificate string, privateKey string) error {
+	t, err := i.forID(ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return t.ImportCA(ID, certificate, privateKey)
+}
+
+type intermediate struct {
+	*wrapped[vaultintermediate.Interface]
+}
+
+// NewIntermediate returns a vaultintermediate.Interface creating the
+// intermediate CAs of every PKI backend in the namespace of its tenant
+// cluster. The given New function is expected to sign with the client of the
+// base namespace, so that parent mounts in the base namespace can be used.
+func NewIntermediate(config WrapConfig[vaultintermediate.Interface]) (vaultintermediate.Interface, error) {
+	t, w, err := wrap(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if w == nil {
+		return t, nil
+	}
+
+	return &intermediate{wrapped: w}, nil
+}
+
+func (i *intermediate) CreateCA(ID string, constraints *caconstraint.Constraints) error {
+	t, err := i.forID(ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return t.CreateCA(ID, constraints)
+}
+
+func (i *intermediate) CreateCAWithPrivateKey(ID string, constraints *caconstraint.Constraints) (string, error) {
+	t, err := i.forID(ID)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return t.CreateCAWithPrivateKey(ID, constraints)
+}
+
+func (i *intermediate) CAChain(ID string) (string, error) {
+	t, err := i.forID(ID)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return t.CAChain(ID)
+}
+
+type root struct {
+	*wrapped[vaultroot.Interface]
+}
+
+// NewRoot returns a vaultroot.Interface creating the constrained root CAs of
+// every PKI backend in the namespace of its tenant cluster.
+func NewRoot(config WrapConfig[vaultroot.Interface]) (vaultroot.Interface, error) {
+	t, w, err := wrap(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if w == nil {
+		return t, nil
+	}
+
+	return &root{wrapped: w}, nil
+}
+
+func (r *root) CreateCA(ID string, constraints caconstraint.Constraints) error {
+	t, err := r.forID(ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return t.CreateCA(ID, constraints)
+}
+
+func (r *root) CreateCAWithPrivateKey(ID string, constraints caconstraint.Constraints) (string, error) {
+	t, err := r.forID(ID)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return t.CreateCAWithPrivateKey(ID, constraints)
+}
+
+type sign struct {
+	*wrapped[vaultsign.Interface]
+}
+
+// NewSign returns a vaultsign.Interface signing CSRs with every PKI backend
+// in the namespace of its tenant cluster.
+func NewSign(config WrapConfig[vaultsign.Interface]) (vaultsign.Interface, error) {
+	t, w, err := wrap(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if w == nil {
+		return t, nil
+	}
+
+	return &sign{wrapped: w}, nil
+}
+
+func (s *sign) EnsureRole(config vaultsign.RoleConfig) error {
+	t, err := s.forID(config.ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return t.EnsureRole(config)
+}
+
+func (s *sign) Sign(config vaultsign.SignConfig) (vaultsign.SignResult, error) {
+	t, err := s.forID(config.ID)
+	if err != nil {
+		return vaultsign.SignResult{}, microerror.Mask(err)
+	}
+
+	return t.Sign(config)
+}
@@ -0,0 +1,15 @@
+package vaultroot
+
+import (
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
+)
+
+type Interface interface {
+	// CreateCA creates the CA of the PKI backend of the given ID as self-signed
+	// root CA embedding the given constraints. The PKI backend must exist
+	// already.
+	CreateCA(ID string, constraints caconstraint.Constraints) error
+	// CreateCAWithPrivateKey works like CreateCA, but lets Vault export the
+	// private key of the CA, which is returned PEM encoded.
+	CreateCAWithPrivateKey(ID string, constraints caconstraint.Constraints) (string, error)
+}
@@ -0,0 +1,106 @@
+// Package vaultroot creates the CAs of tenant cluster PKI backends as
+// self-signed root CAs like vaultpki does, but restricts them with name and
+// path length constraints.
+package vaultroot
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	vaultpkikey "github.com/giantswarm/vaultpki/key"
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
+)
+
+type Config struct {
+	Logger      micrologger.Logger
+	VaultClient *vaultapi.Client
+
+	CATTL            string
+	CommonNameFormat string
+}
+
+type VaultRoot struct {
+	logger      micrologger.Logger
+	vaultClient *vaultapi.Client
+
+	caTTL            string
+	commonNameFormat string
+}
+
+func New(config Config) (*VaultRoot, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.VaultClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultClient must not be empty", config)
+	}
+
+	if config.CATTL == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CATTL must not be empty", config)
+	}
+	if config.CommonNameFormat == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CommonNameFormat must not be empty", config)
+	}
+
+	v := &VaultRoot{
+		logger:      config.Logger,
+		vaultClient: config.VaultClient,
+
+		caTTL:            config.CATTL,
+		commonNameFormat: config.CommonNameFormat,
+	}
+
+	return v, nil
+}
+
+func (v *VaultRoot) CreateCA(ID string, constraints caconstraint.Constraints) error {
+	_, err := v.createCA(ID, constraints, false)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (v *VaultRoot) CreateCAWithPrivateKey(ID string, constraints caconstraint.Constraints) (string, error) {
+	privateKey, err := v.createCA(ID, constraints, true)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return privateKey, nil
+}
+
+func (v *VaultRoot) createCA(ID string, constraints caconstraint.Constraints, exported bool) (string, error) {
+	k := vaultpkikey.WriteCAPath(ID, exported)
+	d := params(vaultpkikey.CommonName(ID, v.commonNameFormat), v.caTTL, constraints)
+
+	secret, err := v.vaultClient.Logical().Write(k, d)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	if secret == nil || secret.Data["certificate"] == nil {
+		return "", microerror.Maskf(executionFailedError, "certificate missing")
+	}
+
+	if !exported {
+		return "", nil
+	}
+
+	privateKey, ok := secret.Data["private_key"].(string)
+	if !ok || privateKey == "" {
+		return "", microerror.Maskf(executionFailedError, "private key missing")
+	}
+
+	return privateKey, nil
+}
+
+func params(commonName string, ttl string, constraints caconstraint.Constraints) map[string]interface{} {
+	d := constraints.VaultParams()
+	d["common_name"] = commonName
+	d["ttl"] = ttl
+
+	return d
+}
@@ -0,0 +1,60 @@
+package vaultroot
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
+)
+
+func Test_VaultRoot_params(t *testing.T) {
+	_, ipRange, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name           string
+		constraints    caconstraint.Constraints
+		expectedParams map[string]interface{}
+	}{
+		{
+			name: "case 0: constrain DNS domains and path length",
+			constraints: caconstraint.Constraints{
+				MaxPathLength:       0,
+				PermittedDNSDomains: []string{"al9qy.k8s.gigantic.io"},
+			},
+			expectedParams: map[string]interface{}{
+				"common_name":           "al9qy.k8s.gigantic.io",
+				"max_path_length":       0,
+				"permitted_dns_domains": []string{"al9qy.k8s.gigantic.io"},
+				"ttl":                   "87600h",
+			},
+		},
+		{
+			name: "case 1: constrain IP ranges",
+			constraints: caconstraint.Constraints{
+				MaxPathLength:       caconstraint.NoMaxPathLength,
+				PermittedDNSDomains: []string{"al9qy.k8s.gigantic.io"},
+				PermittedIPRanges:   []*net.IPNet{ipRange},
+			},
+			expectedParams: map[string]interface{}{
+				"common_name":           "al9qy.k8s.gigantic.io",
+				"max_path_length":       -1,
+				"permitted_dns_domains": []string{"al9qy.k8s.gigantic.io"},
+				"permitted_ip_ranges":   []string{"10.0.0.0/8"},
+				"ttl":                   "87600h",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := params("al9qy.k8s.gigantic.io", "87600h", tc.constraints)
+			if !reflect.DeepEqual(p, tc.expectedParams) {
+				t.Fatalf("expected %#v got %#v", tc.expectedParams, p)
+			}
+		})
+	}
+}
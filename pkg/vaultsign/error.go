@@ -0,0 +1,39 @@
+package vaultsign
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/giantswarm/microerror"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+// IsRejected asserts errors of Vault rejecting a request, e.g. because the
+// role does not allow the requested names. Retrying such requests does not
+// help.
+func IsRejected(err error) bool {
+	var responseError *vaultapi.ResponseError
+	if errors.As(microerror.Cause(err), &responseError) {
+		return responseError.StatusCode == http.StatusBadRequest
+	}
+
+	return false
+}
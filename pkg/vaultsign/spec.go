@@ -0,0 +1,40 @@
+package vaultsign
+
+type RoleConfig struct {
+	// AllowedDomains are the domains the role issues certificates for. Their
+	// subdomains are allowed as well.
+	AllowedDomains []string
+	ID             string
+	// Name is the name of the role. It must not be one of the roles
+	// vaultrole maintains for cert configs.
+	Name string
+	// Organizations are set as organizations of every certificate signed
+	// with the role.
+	Organizations []string
+}
+
+type SignConfig struct {
+	AltNames   []string
+	CommonName string
+	CSR        string
+	ID         string
+	IPSANs     []string
+	// Role is the name of the role signing the request, see EnsureRole.
+	Role string
+	TTL  string
+}
+
+type SignResult struct {
+	CA           string
+	Crt          string
+	SerialNumber string
+}
+
+type Interface interface {
+	// EnsureRole writes the given role to the PKI backend of the given ID.
+	// The role is overwritten in case it exists already.
+	EnsureRole(config RoleConfig) error
+	// Sign signs the given PEM encoded certificate signing request with the
+	// PKI backend of the given ID, using the given role.
+	Sign(config SignConfig) (SignResult, error)
+}
@@ -0,0 +1,119 @@
+// Package vaultsign signs certificate signing requests with the Vault PKI
+// backends of tenant clusters. It complements vaultcrt, which lets Vault
+// generate the private keys of the certificates it issues.
+package vaultsign
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+type Config struct {
+	Logger      micrologger.Logger
+	VaultClient *vaultapi.Client
+}
+
+type VaultSign struct {
+	logger      micrologger.Logger
+	vaultClient *vaultapi.Client
+}
+
+func New(config Config) (*VaultSign, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.VaultClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultClient must not be empty", config)
+	}
+
+	s := &VaultSign{
+		logger:      config.Logger,
+		vaultClient: config.VaultClient,
+	}
+
+	return s, nil
+}
+
+func (s *VaultSign) EnsureRole(config RoleConfig) error {
+	k := rolePath(config.ID, config.Name)
+	v := map[string]interface{}{
+		"allow_bare_domains": true,
+		"allow_glob_domains": false,
+		"allow_ip_sans":      true,
+		"allow_localhost":    false,
+		"allow_subdomains":   true,
+		"allowed_domains":    strings.Join(config.AllowedDomains, ","),
+		"enforce_hostnames":  true,
+		"organization":       strings.Join(config.Organizations, ","),
+		"require_cn":         false,
+	}
+
+	_, err := s.vaultClient.Logical().Write(k, v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (s *VaultSign) Sign(config SignConfig) (SignResult, error) {
+	k := signPath(config.ID, config.Role)
+	v := map[string]interface{}{
+		"alt_names":   strings.Join(config.AltNames, ","),
+		"common_name": config.CommonName,
+		"csr":         config.CSR,
+		"ip_sans":     strings.Join(config.IPSANs, ","),
+		"ttl":         config.TTL,
+	}
+
+	secret, err := s.vaultClient.Logical().Write(k, v)
+	if err != nil {
+		return SignResult{}, microerror.Mask(err)
+	}
+	if secret == nil {
+		return SignResult{}, microerror.Maskf(executionFailedError, "response missing")
+	}
+
+	var result SignResult
+	for k, p := range map[string]*string{"issuing_ca": &result.CA, "certificate": &result.Crt, "serial_number": &result.SerialNumber} {
+		v, ok := secret.Data[k]
+		if !ok {
+			return SignResult{}, microerror.Maskf(executionFailedError, "%s missing", k)
+		}
+		*p, ok = v.(string)
+		if !ok {
+			return SignResult{}, microerror.Maskf(executionFailedError, "%s must be string", k)
+		}
+	}
+
+	// Intermediate CAs come with the chain up to their root, which is handed
+	// out instead of the issuing CA only.
+	if l, ok := secret.Data["ca_chain"].([]interface{}); ok && len(l) != 0 {
+		var chain []string
+		for _, c := range l {
+			if s, ok := c.(string); ok && s != "" {
+				chain = append(chain, strings.TrimSpace(s))
+			}
+		}
+		if len(chain) != 0 {
+			result.CA = strings.Join(chain, "\n")
+		}
+	}
+
+	return result, nil
+}
+
+func rolePath(ID string, role string) string {
+	return fmt.Sprintf("pki-%s/roles/%s", ID, role)
+}
+
+// signPath is the sign counterpart of the issue path of vaultcrt. Other than
+// vaultcrt it does not use the roles maintained by vaultrole, but the roles
+// written with EnsureRole.
+func signPath(ID string, role string) string {
+	return fmt.Sprintf("pki-%s/sign/%s", ID, role)
+}
@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
+)
+
+var (
+	breakerStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("cert_operator", "vault", "circuit_breaker_state"),
+		"A metric of the state of the Vault circuit breaker. The current state is 1, the others are 0.",
+		[]string{"state"},
+		nil,
+	)
+	breakerOpenedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("cert_operator", "vault", "circuit_breaker_opened_total"),
+		"A metric of how often the Vault circuit breaker opened.",
+		nil,
+		nil,
+	)
+	breakerRejectedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("cert_operator", "vault", "circuit_breaker_rejected_requests_total"),
+		"A metric of the Vault requests rejected by the circuit breaker.",
+		nil,
+		nil,
+	)
+	breakerFailuresDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("cert_operator", "vault", "circuit_breaker_failed_requests_total"),
+		"A metric of the failed Vault requests observed by the circuit breaker by their error class.",
+		[]string{"class"},
+		nil,
+	)
+)
+
+var (
+	breakerClasses = []string{
+		vaultbreaker.ClassPermissionDenied,
+		vaultbreaker.ClassRateLimited,
+		vaultbreaker.ClassSealed,
+		vaultbreaker.ClassServerError,
+		vaultbreaker.ClassUnavailable,
+	}
+	breakerStates = []string{
+		vaultbreaker.StateClosed,
+		vaultbreaker.StateHalfOpen,
+		vaultbreaker.StateOpen,
+	}
+)
+
+type VaultBreakerConfig struct {
+	VaultBreaker vaultbreaker.Interface
+}
+
+type VaultBreaker struct {
+	vaultBreaker vaultbreaker.Interface
+}
+
+func NewVaultBreaker(config VaultBreakerConfig) (*VaultBreaker, error) {
+	if config.VaultBreaker == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultBreaker must not be empty", config)
+	}
+
+	v := &VaultBreaker{
+		vaultBreaker: config.VaultBreaker,
+	}
+
+	return v, nil
+}
+
+func (v *VaultBreaker) Collect(ch chan<- prometheus.Metric) error {
+	stats := v.vaultBreaker.Stats()
+
+	for _, s := range breakerStates {
+		var value float64
+		if s == stats.State {
+			value = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			breakerStateDesc,
+			prometheus.GaugeValue,
+			value,
+			s,
+		)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		breakerOpenedDesc,
+		prometheus.CounterValue,
+		float64(stats.Opened),
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		breakerRejectedDesc,
+		prometheus.CounterValue,
+		float64(stats.Rejected),
+	)
+
+	for _, c := range breakerClasses {
+		ch <- prometheus.MustNewConstMetric(
+			breakerFailuresDesc,
+			prometheus.CounterValue,
+			float64(stats.Failures[c]),
+			c,
+		)
+	}
+
+	return nil
+}
+
+func (v *VaultBreaker) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- breakerStateDesc
+	ch <- breakerOpenedDesc
+	ch <- breakerRejectedDesc
+	ch <- breakerFailuresDesc
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"strings"
 
 	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
 )
 
 // executionFailedError is an error type for situations where Resource execution
@@ -32,8 +34,10 @@ var vaultAccessError = &microerror.Error{
 
 // IsVaultAccess asserts vaultAccessError. The matcher also asserts errors
 // caused by situations in which Vault is updated strategically and thus
-// temporarily replies with HTTP responses. In such cases we intend to cancel
-// collection and wait until Vault is fully operational again.
+// temporarily replies with HTTP responses, as well as any other Vault outage
+// asserted by vaultbreaker.IsOutage, e.g. sealed or rate limiting Vaults. In
+// such cases we intend to cancel collection and wait until Vault is fully
+// operational again.
 //
 //	Get https://vault.g8s.foo.bar:8200/v1/sys/mounts: http: server gave HTTP response to HTTPS client
 func IsVaultAccess(err error) bool {
@@ -51,5 +55,9 @@ func IsVaultAccess(err error) bool {
 		return true
 	}
 
+	if vaultbreaker.IsOutage(err) {
+		return true
+	}
+
 	return false
 }
@@ -4,12 +4,15 @@ import (
 	"github.com/giantswarm/exporterkit/collector"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
-	vault "github.com/hashicorp/vault/api"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultauth"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
 )
 
 type SetConfig struct {
-	Logger      micrologger.Logger
-	VaultClient *vault.Client
+	Logger       micrologger.Logger
+	VaultAuth    vaultauth.Interface
+	VaultBreaker vaultbreaker.Interface
 }
 
 // Set is basically only a wrapper for the operator's collector implementations.
@@ -24,18 +27,35 @@ func NewSet(config SetConfig) (*Set, error) {
 
 	var vaultCollector *Vault
 	{
-		c := VaultConfig(config)
+		c := VaultConfig{
+			Logger:    config.Logger,
+			VaultAuth: config.VaultAuth,
+		}
+
 		vaultCollector, err = NewVault(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 	}
 
+	var vaultBreakerCollector *VaultBreaker
+	{
+		c := VaultBreakerConfig{
+			VaultBreaker: config.VaultBreaker,
+		}
+
+		vaultBreakerCollector, err = NewVaultBreaker(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var collectorSet *collector.Set
 	{
 		c := collector.SetConfig{
 			Collectors: []collector.Interface{
 				vaultCollector,
+				vaultBreakerCollector,
 			},
 			Logger: config.Logger,
 		}
@@ -9,6 +9,8 @@ import (
 	"github.com/giantswarm/micrologger"
 	vault "github.com/hashicorp/vault/api"
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultauth"
 )
 
 const (
@@ -31,26 +33,26 @@ var (
 )
 
 type VaultConfig struct {
-	Logger      micrologger.Logger
-	VaultClient *vault.Client
+	Logger    micrologger.Logger
+	VaultAuth vaultauth.Interface
 }
 
 type Vault struct {
-	logger      micrologger.Logger
-	vaultClient *vault.Client
+	logger    micrologger.Logger
+	vaultAuth vaultauth.Interface
 }
 
 func NewVault(config VaultConfig) (*Vault, error) {
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
-	if config.VaultClient == nil {
-		return nil, microerror.Maskf(invalidConfigError, "%T.VaultClient must not be empty", config)
+	if config.VaultAuth == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultAuth must not be empty", config)
 	}
 
 	v := &Vault{
-		logger:      config.Logger,
-		vaultClient: config.VaultClient,
+		logger:    config.Logger,
+		vaultAuth: config.VaultAuth,
 	}
 
 	return v, nil
@@ -59,7 +61,7 @@ func NewVault(config VaultConfig) (*Vault, error) {
 func (v *Vault) Collect(ch chan<- prometheus.Metric) error {
 	ctx := context.Background()
 
-	secret, err := v.vaultClient.Auth().Token().LookupSelf()
+	secret, err := v.vaultAuth.LookupSelf(ctx)
 	if IsVaultAccess(err) {
 		v.logger.LogCtx(ctx, "level", "debug", "message", "vault not reachable")
 		v.logger.LogCtx(ctx, "level", "debug", "message", "vault upgrade in progress")
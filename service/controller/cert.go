@@ -3,46 +3,111 @@ package controller
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	corev1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
 	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/backoff"
 	"github.com/giantswarm/k8sclient/v7/pkg/k8sclient"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/giantswarm/operatorkit/v7/pkg/controller"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/collector"
 	"github.com/giantswarm/operatorkit/v7/pkg/resource"
+	"github.com/giantswarm/to"
 	"github.com/giantswarm/vaultcrt"
 	"github.com/giantswarm/vaultpki"
 	"github.com/giantswarm/vaultpki/key"
 	"github.com/giantswarm/vaultrole"
 	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
 	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/project"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultauth"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultcache"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultimport"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultintermediate"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultmetrics"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultnamespace"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultroot"
+	"github.com/giantswarm/cert-operator/v3/service/controller/context/requeuecontext"
+	controllerkey "github.com/giantswarm/cert-operator/v3/service/controller/key"
+	"github.com/giantswarm/cert-operator/v3/service/controller/resources/certconfig"
 )
 
 type CertConfig struct {
-	K8sClient   k8sclient.Interface
-	Logger      micrologger.Logger
-	VaultClient *vaultapi.Client
-
-	UniqueApp           bool
-	CATTL               string
-	CRDLabelSelector    string
-	CommonNameFormat    string
-	ExpirationThreshold time.Duration
-	Namespace           string
-	ProjectName         string
+	K8sClient    k8sclient.Interface
+	Logger       micrologger.Logger
+	VaultAuth    vaultauth.Interface
+	VaultBreaker vaultbreaker.Interface
+	VaultCache   *vaultcache.Cache
+	VaultClient  *vaultapi.Client
+
+	UniqueApp             bool
+	CAPISecrets           bool
+	CAConstraints         bool
+	CAExpirationThreshold time.Duration
+	CAMaxPathLength       int
+	CAPermittedDNSDomains []string
+	CAPermittedIPRanges   []string
+	CATTL                 string
+	// CertConfigTemplate are the CertConfigs created for CAPI clusters, see
+	// ClusterConfig. Their names must be permitted by the CA constraints.
+	CertConfigTemplate      string
+	CRDLabelSelector        string
+	CommonNameFormat        string
+	ExpirationThreshold     time.Duration
+	Intermediate            bool
+	IntermediateParentCrt   string
+	IntermediateParentKey   string
+	IntermediateParentMount string
+	Namespace               string
+	ReloadWorkloads         bool
+	ProjectName             string
+	RenewalBudget           int
+	RenewalBudgetPerCluster int
+	RenewalJitter           time.Duration
+	RenewalOverlap          time.Duration
+	TrustDomains            string
+	// VaultNamespace is the Vault namespace of the PKI backends. It may be
+	// templated with the cluster ID, see vaultnamespace.Config.
+	VaultNamespace string
 }
 
 type Cert struct {
 	*controller.Controller
+
+	collector     *collector.Set
+	k8sClient     k8sclient.Interface
+	logger        micrologger.Logger
+	secretWatcher *SecretWatcher
+	selector      labels.Selector
+
+	bootOnce sync.Once
+	booted   chan struct{}
+	stop     context.CancelFunc
+	stopOnce sync.Once
 }
 
 func NewCert(config CertConfig) (*Cert, error) {
@@ -50,16 +115,72 @@ func NewCert(config CertConfig) (*Cert, error) {
 		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
 	}
 
-	var err error
+	trustDomains, err := trustdomain.Parse(config.TrustDomains)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var vaultNamespace *vaultnamespace.Namespace
+	{
+		c := vaultnamespace.Config{
+			Format:       config.VaultNamespace,
+			TrustDomains: trustDomains,
+		}
+
+		vaultNamespace, err = vaultnamespace.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
 
 	var vaultCrt vaultcrt.Interface
 	{
-		c := vaultcrt.DefaultConfig()
+		c := vaultnamespace.WrapConfig[vaultcrt.Interface]{
+			Namespace:   vaultNamespace,
+			VaultClient: config.VaultClient,
+			New: func(vaultClient *vaultapi.Client) (vaultcrt.Interface, error) {
+				c := vaultcrt.DefaultConfig()
+
+				c.Logger = config.Logger
+				c.VaultClient = vaultClient
+
+				return vaultcrt.New(c)
+			},
+		}
+
+		vaultCrt, err = vaultnamespace.NewCrt(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
 
-		c.Logger = config.Logger
-		c.VaultClient = config.VaultClient
+	{
+		c := vaultmetrics.CrtConfig{
+			VaultCrt: vaultCrt,
+		}
 
-		vaultCrt, err = vaultcrt.New(c)
+		vaultCrt, err = vaultmetrics.NewCrt(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var vaultImport vaultimport.Interface
+	{
+		c := vaultnamespace.WrapConfig[vaultimport.Interface]{
+			Namespace:   vaultNamespace,
+			VaultClient: config.VaultClient,
+			New: func(vaultClient *vaultapi.Client) (vaultimport.Interface, error) {
+				c := vaultimport.Config{
+					Logger:      config.Logger,
+					VaultClient: vaultClient,
+				}
+
+				return vaultimport.New(c)
+			},
+		}
+
+		vaultImport, err = vaultnamespace.NewImport(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
@@ -67,30 +188,188 @@ func NewCert(config CertConfig) (*Cert, error) {
 
 	var vaultPKI vaultpki.Interface
 	{
-		c := vaultpki.Config{
-			Logger:      config.Logger,
+		c := vaultnamespace.WrapConfig[vaultpki.Interface]{
+			Namespace:   vaultNamespace,
 			VaultClient: config.VaultClient,
+			New: func(vaultClient *vaultapi.Client) (vaultpki.Interface, error) {
+				c := vaultpki.Config{
+					Logger:      config.Logger,
+					VaultClient: vaultClient,
+
+					CATTL:            config.CATTL,
+					CommonNameFormat: config.CommonNameFormat,
+				}
+
+				return vaultpki.New(c)
+			},
+		}
+
+		vaultPKI, err = vaultnamespace.NewPKI(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := vaultmetrics.PKIConfig{
+			VaultPKI: vaultPKI,
+		}
+
+		vaultPKI, err = vaultmetrics.NewPKI(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := vaultcache.PKIConfig{
+			Cache:    config.VaultCache,
+			VaultPKI: vaultPKI,
+		}
+
+		vaultPKI, err = vaultcache.NewPKI(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var vaultIntermediate vaultintermediate.Interface
+	if config.Intermediate {
+		c := vaultintermediate.Config{
+			Logger:            config.Logger,
+			ParentVaultClient: config.VaultClient,
 
 			CATTL:            config.CATTL,
 			CommonNameFormat: config.CommonNameFormat,
+			ParentMount:      config.IntermediateParentMount,
+		}
+
+		if config.IntermediateParentCrt != "" {
+			crt, err := os.ReadFile(config.IntermediateParentCrt)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			k, err := os.ReadFile(config.IntermediateParentKey)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			c.ParentCertificate = string(crt)
+			c.ParentPrivateKey = string(k)
+		}
+
+		w := vaultnamespace.WrapConfig[vaultintermediate.Interface]{
+			Namespace:   vaultNamespace,
+			VaultClient: config.VaultClient,
+			New: func(vaultClient *vaultapi.Client) (vaultintermediate.Interface, error) {
+				c := c
+				c.VaultClient = vaultClient
+
+				return vaultintermediate.New(c)
+			},
 		}
 
-		vaultPKI, err = vaultpki.New(c)
+		vaultIntermediate, err = vaultnamespace.NewIntermediate(w)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 	}
 
+	var caConstraints *caconstraint.Policy
+	var vaultRoot vaultroot.Interface
+	if config.CAConstraints {
+		var dnsNames []string
+		if config.CertConfigTemplate != "" {
+			template, err := certconfig.ParseTemplate(config.CertConfigTemplate)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, t := range template {
+				dnsNames = append(dnsNames, t.AltNames...)
+			}
+		}
+
+		{
+			c := caconstraint.Config{
+				CommonNameFormat:    config.CommonNameFormat,
+				DNSNames:            dnsNames,
+				MaxPathLength:       config.CAMaxPathLength,
+				PermittedDNSDomains: config.CAPermittedDNSDomains,
+				PermittedIPRanges:   config.CAPermittedIPRanges,
+			}
+
+			caConstraints, err = caconstraint.New(c)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		{
+			c := vaultnamespace.WrapConfig[vaultroot.Interface]{
+				Namespace:   vaultNamespace,
+				VaultClient: config.VaultClient,
+				New: func(vaultClient *vaultapi.Client) (vaultroot.Interface, error) {
+					c := vaultroot.Config{
+						Logger:      config.Logger,
+						VaultClient: vaultClient,
+
+						CATTL:            config.CATTL,
+						CommonNameFormat: config.CommonNameFormat,
+					}
+
+					return vaultroot.New(c)
+				},
+			}
+
+			vaultRoot, err = vaultnamespace.NewRoot(c)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+	}
+
 	var vaultRole vaultrole.Interface
 	{
-		c := vaultrole.DefaultConfig()
+		c := vaultnamespace.WrapConfig[vaultrole.Interface]{
+			Namespace:   vaultNamespace,
+			VaultClient: config.VaultClient,
+			New: func(vaultClient *vaultapi.Client) (vaultrole.Interface, error) {
+				c := vaultrole.DefaultConfig()
 
-		c.Logger = config.Logger
-		c.VaultClient = config.VaultClient
+				c.Logger = config.Logger
+				c.VaultClient = vaultClient
 
-		c.CommonNameFormat = config.CommonNameFormat
+				c.CommonNameFormat = config.CommonNameFormat
 
-		vaultRole, err = vaultrole.New(c)
+				return vaultrole.New(c)
+			},
+		}
+
+		vaultRole, err = vaultnamespace.NewRole(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := vaultmetrics.RoleConfig{
+			VaultRole: vaultRole,
+		}
+
+		vaultRole, err = vaultmetrics.NewRole(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := vaultcache.RoleConfig{
+			Cache:     config.VaultCache,
+			VaultRole: vaultRole,
+		}
+
+		vaultRole, err = vaultcache.NewRole(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
@@ -99,17 +378,31 @@ func NewCert(config CertConfig) (*Cert, error) {
 	var resources []resource.Interface
 	{
 		c := ResourceSetConfig{
-			CtrlClient:  config.K8sClient.CtrlClient(),
-			K8sClient:   config.K8sClient.K8sClient(),
-			Logger:      config.Logger,
-			VaultClient: config.VaultClient,
-			VaultCrt:    vaultCrt,
-			VaultPKI:    vaultPKI,
-			VaultRole:   vaultRole,
-
-			ExpirationThreshold: config.ExpirationThreshold,
-			Namespace:           config.Namespace,
-			ProjectName:         config.ProjectName,
+			CtrlClient:        config.K8sClient.CtrlClient(),
+			K8sClient:         config.K8sClient.K8sClient(),
+			Logger:            config.Logger,
+			VaultAuth:         config.VaultAuth,
+			VaultBreaker:      config.VaultBreaker,
+			VaultCrt:          vaultCrt,
+			VaultImport:       vaultImport,
+			VaultIntermediate: vaultIntermediate,
+			VaultPKI:          vaultPKI,
+			VaultRole:         vaultRole,
+			VaultRoot:         vaultRoot,
+
+			CAConstraints:           caConstraints,
+			CAPISecrets:             config.CAPISecrets,
+			CAExpirationThreshold:   config.CAExpirationThreshold,
+			CommonNameFormat:        config.CommonNameFormat,
+			ExpirationThreshold:     config.ExpirationThreshold,
+			Namespace:               config.Namespace,
+			ReloadWorkloads:         config.ReloadWorkloads,
+			ProjectName:             config.ProjectName,
+			RenewalBudget:           config.RenewalBudget,
+			RenewalBudgetPerCluster: config.RenewalBudgetPerCluster,
+			RenewalJitter:           config.RenewalJitter,
+			RenewalOverlap:          config.RenewalOverlap,
+			TrustDomains:            trustDomains,
 		}
 
 		resources, err = NewResourceSet(c)
@@ -148,11 +441,56 @@ func NewCert(config CertConfig) (*Cert, error) {
 		}
 	}
 
+	var secretWatcher *SecretWatcher
+	{
+		c := SecretWatcherConfig{
+			CtrlClient: config.K8sClient.CtrlClient(),
+			Logger:     config.Logger,
+
+			Selector: label.SecretSelector(),
+		}
+
+		secretWatcher, err = NewSecretWatcher(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var collectorSet *collector.Set
+	{
+		c := collector.SetConfig{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+			NewRuntimeObjectFunc: func() client.Object {
+				return new(corev1alpha1.CertConfig)
+			},
+			Selector: selector,
+
+			Controller: config.ProjectName,
+		}
+
+		collectorSet, err = collector.NewSet(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	c := &Cert{
 		Controller: operatorkitController,
+
+		collector:     collectorSet,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+		secretWatcher: secretWatcher,
+		selector:      selector,
+
+		bootOnce: sync.Once{},
+		booted:   make(chan struct{}),
+		stop:     nil,
+		stopOnce: sync.Once{},
 	}
 
-	err = cleanupPKIBackends(config.Logger, config.K8sClient, vaultPKI)
+	err = cleanupPKIBackends(config.Logger, config.K8sClient, vaultPKI, trustDomains)
 	if err != nil {
 		// We don't want a cleanup error to prevent the controller from starting.
 		config.Logger.Log("level", "error", "message", "failed to clean up PKI backends", "stack", fmt.Sprintf("%#v", err))
@@ -161,7 +499,171 @@ func NewCert(config CertConfig) (*Cert, error) {
 	return c, nil
 }
 
-func cleanupPKIBackends(logger micrologger.Logger, k8sClient k8sclient.Interface, vaultPKI vaultpki.Interface) error {
+// Boot boots the CertConfig controller. The operatorkit controller only
+// watches the CertConfigs themselves, which is why the controller-runtime
+// controller is set up here. It additionally watches the certificate secrets
+// and enqueues their CertConfig, see SecretWatcher. The CertConfigs are
+// reconciled by the operatorkit controller all the same.
+func (c *Cert) Boot(ctx context.Context) {
+	c.bootOnce.Do(func() {
+		// The collector is booted once, before the controller boot is retried,
+		// because booting it twice fails.
+		err := c.collector.Boot(ctx)
+		if err != nil {
+			c.logger.Errorf(ctx, err, "failed to boot collector")
+			os.Exit(1)
+		}
+
+		c.bootErrorHandling(ctx)
+
+		operation := func() error {
+			err := c.boot(ctx)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+
+		err = backoff.RetryNotify(operation, backoff.NewMaxRetries(7, 1*time.Second), backoff.NewNotifier(c.logger, ctx))
+		if err != nil {
+			c.logger.Errorf(ctx, err, "stop controller boot retries due to too many errors")
+			os.Exit(1)
+		}
+	})
+}
+
+// Booted returns a channel which is closed once the controller is booted.
+func (c *Cert) Booted() chan struct{} {
+	return c.booted
+}
+
+// Stop stops the controller and its collector.
+func (c *Cert) Stop(ctx context.Context) {
+	c.stopOnce.Do(func() {
+		c.collector.Stop(ctx)
+		if c.stop != nil {
+			c.stop()
+		}
+	})
+}
+
+// bootErrorHandling sets up the error handling operatorkit sets up when it
+// boots its own controller-runtime controller. Third party runtime errors are
+// logged and counted in the error gauge of operatorkit, which is reset
+// periodically.
+func (c *Cert) bootErrorHandling(ctx context.Context) {
+	errorGauge := operatorkitErrorGauge()
+
+	go func() {
+		for {
+			time.Sleep(controller.DefaultResyncPeriod * 4)
+			errorGauge.Set(0)
+		}
+	}()
+
+	utilruntime.ErrorHandlers = []func(err error){
+		func(err error) {
+			// Port forwarding errors are ignored like operatorkit does, because
+			// there is nothing we can do about them.
+			if controller.IsPortforward(err) {
+				return
+			}
+
+			errorGauge.Inc()
+			c.logger.Errorf(ctx, err, "caught third party runtime error")
+		},
+	}
+}
+
+func (c *Cert) boot(ctx context.Context) error {
+	var err error
+
+	var mgr manager.Manager
+	{
+		o := manager.Options{
+			// MetricsBindAddress is set to 0 in order to disable it. The
+			// metrics are served by the operator itself.
+			MetricsBindAddress: controller.DisableMetricsServing,
+			Scheme:             c.k8sClient.Scheme(),
+			SyncPeriod:         to.DurationP(controller.DefaultResyncPeriod),
+			// Only the certificate secrets are cached, not every secret of the
+			// management cluster.
+			NewCache: cache.BuilderWithOptions(cache.Options{
+				SelectorsByObject: cache.SelectorsByObject{
+					&corev1.Secret{}: {Label: label.SecretSelector()},
+				},
+			}),
+		}
+
+		mgr, err = manager.New(c.k8sClient.RESTConfig(), o)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		selected := func(obj client.Object) bool {
+			return c.selector.Matches(labels.Set(obj.GetLabels()))
+		}
+
+		err = builder.
+			ControllerManagedBy(mgr).
+			For(new(corev1alpha1.CertConfig), builder.WithPredicates(predicate.NewPredicateFuncs(selected))).
+			Watches(c.secretWatcher.Source(), c.secretWatcher.Handler(), builder.WithPredicates(c.secretWatcher.Predicate())).
+			WithOptions(ctrlcontroller.Options{
+				MaxConcurrentReconciles: 1,
+			}).
+			Complete(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// The controller is put into a booted state by closing its booted channel
+	// once so users know when to go ahead.
+	select {
+	case <-c.booted:
+	default:
+		close(c.booted)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c.stop = cancel
+
+	setupSignalHandler(func() {
+		c.Stop(ctx)
+	})
+
+	// Start blocks until the given context is done or the manager fails.
+	err = mgr.Start(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Reconcile reconciles the CertConfig of the given request using the
+// operatorkit controller. Resources may ask for the CertConfig to be
+// reconciled again after some time using requeuecontext.
+func (c *Cert) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	ctx = requeuecontext.NewContext(ctx)
+
+	res, err := c.Controller.Reconcile(ctx, req)
+	if err != nil {
+		return reconcile.Result{}, microerror.Mask(err)
+	}
+
+	after, ok := requeuecontext.RequeueAfter(ctx)
+	if ok && (res.RequeueAfter == 0 || after < res.RequeueAfter) {
+		res.RequeueAfter = after
+	}
+
+	return res, nil
+}
+
+func cleanupPKIBackends(logger micrologger.Logger, k8sClient k8sclient.Interface, vaultPKI vaultpki.Interface, trustDomains trustdomain.Mapping) error {
 	mounts, err := vaultPKI.ListBackends()
 	if err != nil {
 		return microerror.Mask(err)
@@ -197,6 +699,23 @@ func cleanupPKIBackends(logger micrologger.Logger, k8sClient k8sclient.Interface
 				}
 			}
 
+			// The PKI backends of trust domains are not listed as backends, so
+			// they are deleted along with the one of the cluster.
+			{
+				var err error
+				for _, d := range trustDomains.Domains() {
+					err = deleteBackendIfExists(vaultPKI, trustdomain.PKIID(id, d))
+					if err != nil {
+						break
+					}
+				}
+				if err != nil {
+					latestError = &err
+					logger.Log("level", "error", "message", fmt.Sprintf("error deleting trust domain PKI backends for Tenant Cluster %#q", id))
+					continue
+				}
+			}
+
 			{
 				err := vaultPKI.DeleteBackend(id)
 				if err != nil {
@@ -206,6 +725,15 @@ func cleanupPKIBackends(logger micrologger.Logger, k8sClient k8sclient.Interface
 				}
 			}
 
+			{
+				err := deleteCAKeySecrets(k8sClient, id, trustDomains)
+				if err != nil {
+					latestError = &err
+					logger.Log("level", "error", "message", fmt.Sprintf("error deleting CA key secrets for Tenant Cluster %#q", id))
+					continue
+				}
+			}
+
 			logger.Log("level", "debug", "message", fmt.Sprintf("deleted PKI backend for Tenant Cluster %#q", id))
 		}
 	}
@@ -219,6 +747,56 @@ func cleanupPKIBackends(logger micrologger.Logger, k8sClient k8sclient.Interface
 	return nil
 }
 
+// deleteCAKeySecrets deletes the secrets holding the exported private keys of
+// the CAs of the given tenant cluster. The namespace of the cluster is not
+// known anymore, so the secrets are looked up in all namespaces.
+func deleteCAKeySecrets(k8sClient k8sclient.Interface, id string, trustDomains trustdomain.Mapping) error {
+	names := map[string]bool{
+		controllerkey.CAKeySecretName(id): true,
+	}
+	for _, d := range trustDomains.Domains() {
+		names[controllerkey.CAKeySecretName(trustdomain.PKIID(id, d))] = true
+	}
+
+	list := &corev1.SecretList{}
+	err := k8sClient.CtrlClient().List(context.Background(), list, client.MatchingLabels{label.Cluster: id, label.ManagedBy: project.Name()})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for i := range list.Items {
+		s := &list.Items[i]
+		if !names[s.GetName()] {
+			continue
+		}
+
+		err := k8sClient.CtrlClient().Delete(context.Background(), s)
+		if errors.IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func deleteBackendIfExists(vaultPKI vaultpki.Interface, id string) error {
+	exists, err := vaultPKI.BackendExists(id)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if exists {
+		err := vaultPKI.DeleteBackend(id)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
 func tenantClusterExists(k8sClient k8sclient.Interface, id string) (bool, error) {
 	var err error
 
@@ -263,3 +841,39 @@ func tenantClusterExists(k8sClient k8sclient.Interface, id string) (bool, error)
 
 	return false, nil
 }
+
+// operatorkitErrorGauge returns the error gauge operatorkit registers, so that
+// the errors of the controller are counted in the same series as the errors of
+// the operatorkit controllers.
+func operatorkitErrorGauge() prometheus.Gauge {
+	g := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: controller.PrometheusNamespace,
+			Subsystem: controller.PrometheusSubsystem,
+			Name:      "error_total",
+			Help:      "Number of reconciliation errors.",
+		},
+	)
+
+	err := prometheus.Register(g)
+	if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		if existing, ok := e.ExistingCollector.(prometheus.Gauge); ok {
+			return existing
+		}
+	}
+
+	return g
+}
+
+// setupSignalHandler calls the given handler on the first interrupt or
+// termination signal and exits on the second one, like operatorkit does.
+func setupSignalHandler(handle func()) {
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		handle()
+		<-c
+		os.Exit(1)
+	}()
+}
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"github.com/giantswarm/k8sclient/v7/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller"
+	"github.com/giantswarm/operatorkit/v7/pkg/resource"
+	"github.com/giantswarm/operatorkit/v7/pkg/resource/wrapper/metricsresource"
+	"github.com/giantswarm/operatorkit/v7/pkg/resource/wrapper/retryresource"
+	"github.com/giantswarm/vaultpki"
+	vaultapi "github.com/hashicorp/vault/api"
+	"k8s.io/apimachinery/pkg/labels"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/project"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultcache"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultmetrics"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultnamespace"
+	"github.com/giantswarm/cert-operator/v3/service/controller/resources/certconfig"
+	"github.com/giantswarm/cert-operator/v3/service/controller/resources/clusterpki"
+	vaultbreakerresource "github.com/giantswarm/cert-operator/v3/service/controller/resources/vaultbreaker"
+)
+
+type ClusterConfig struct {
+	K8sClient    k8sclient.Interface
+	Logger       micrologger.Logger
+	VaultBreaker vaultbreaker.Interface
+	VaultCache   *vaultcache.Cache
+	VaultClient  *vaultapi.Client
+
+	CATTL              string
+	CertConfigTemplate string
+	CleanupPKI         bool
+	CommonNameFormat   string
+	CreateCertConfigs  bool
+	ProjectName        string
+	TrustDomains       string
+	UniqueApp          bool
+	VaultNamespace     string
+}
+
+// Cluster is the controller reconciling CAPI clusters. It optionally creates
+// the CertConfigs of tenant clusters from a template and tears down their PKI
+// once their CAPI cluster got deleted.
+type Cluster struct {
+	*controller.Controller
+}
+
+func NewCluster(config ClusterConfig) (*Cluster, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+
+	trustDomains, err := trustdomain.Parse(config.TrustDomains)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var vaultNamespace *vaultnamespace.Namespace
+	{
+		c := vaultnamespace.Config{
+			Format:       config.VaultNamespace,
+			TrustDomains: trustDomains,
+		}
+
+		vaultNamespace, err = vaultnamespace.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var vaultPKI vaultpki.Interface
+	{
+		c := vaultnamespace.WrapConfig[vaultpki.Interface]{
+			Namespace:   vaultNamespace,
+			VaultClient: config.VaultClient,
+			New: func(vaultClient *vaultapi.Client) (vaultpki.Interface, error) {
+				c := vaultpki.Config{
+					Logger:      config.Logger,
+					VaultClient: vaultClient,
+
+					CATTL:            config.CATTL,
+					CommonNameFormat: config.CommonNameFormat,
+				}
+
+				return vaultpki.New(c)
+			},
+		}
+
+		vaultPKI, err = vaultnamespace.NewPKI(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := vaultmetrics.PKIConfig{
+			VaultPKI: vaultPKI,
+		}
+
+		vaultPKI, err = vaultmetrics.NewPKI(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := vaultcache.PKIConfig{
+			Cache:    config.VaultCache,
+			VaultPKI: vaultPKI,
+		}
+
+		vaultPKI, err = vaultcache.NewPKI(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	controllerName := config.ProjectName + "-cluster"
+
+	var resources []resource.Interface
+
+	if config.CreateCertConfigs {
+		// The CertConfigs are labelled with the operator version selected by
+		// the CertConfig controller, see NewCert.
+		operatorVersion := project.Version()
+		if config.UniqueApp {
+			operatorVersion = project.ManagementClusterAppVersion()
+		}
+
+		c := certconfig.Config{
+			CtrlClient: config.K8sClient.CtrlClient(),
+			Logger:     config.Logger,
+
+			CommonNameFormat: config.CommonNameFormat,
+			OperatorVersion:  operatorVersion,
+			Template:         config.CertConfigTemplate,
+		}
+
+		certConfigResource, err := certconfig.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		resources = append(resources, certConfigResource)
+	}
+
+	if config.CleanupPKI {
+		// Only the cleanup of the PKI talks to Vault, so CertConfigs are
+		// still created while the Vault circuit breaker is open.
+		c := vaultbreakerresource.Config{
+			Logger:       config.Logger,
+			VaultBreaker: config.VaultBreaker,
+		}
+
+		vaultBreakerResource, err := vaultbreakerresource.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		resources = append(resources, vaultBreakerResource)
+	}
+
+	if config.CleanupPKI {
+		c := clusterpki.Config{
+			CtrlClient: config.K8sClient.CtrlClient(),
+			Logger:     config.Logger,
+			VaultPKI:   vaultPKI,
+
+			ControllerName: controllerName,
+			TrustDomains:   trustDomains,
+		}
+
+		clusterPKIResource, err := clusterpki.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		resources = append(resources, clusterPKIResource)
+	}
+
+	{
+		c := retryresource.WrapConfig{
+			Logger: config.Logger,
+		}
+
+		resources, err = retryresource.Wrap(resources, c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := metricsresource.WrapConfig{}
+
+		resources, err = metricsresource.Wrap(resources, c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var operatorkitController *controller.Controller
+	{
+		c := controller.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+			Name:      controllerName,
+			Resources: resources,
+			Selector:  labels.Everything(),
+			NewRuntimeObjectFunc: func() client.Object {
+				return new(capi.Cluster)
+			},
+		}
+
+		operatorkitController, err = controller.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	c := &Cluster{
+		Controller: operatorkitController,
+	}
+
+	return c, nil
+}
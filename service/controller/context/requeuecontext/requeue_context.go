@@ -0,0 +1,62 @@
+// Package requeuecontext stores and accesses the requeue delay in
+// context.Context. Resources use it to ask for the reconciled object to be
+// reconciled again after some time, e.g. once a rate limit allows them to
+// continue, instead of waiting for the next resync.
+package requeuecontext
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// key is an unexported type for keys defined in this package. This prevents
+// collisions with keys defined in other packages.
+type key string
+
+// requeueKey is the key for requeue values in context.Context. Clients use
+// requeuecontext.NewContext and requeuecontext.SetRequeueAfter instead of
+// using this key directly.
+var requeueKey key = "requeue"
+
+type requeue struct {
+	mutex sync.Mutex
+	after time.Duration
+	set   bool
+}
+
+// NewContext returns a new context.Context able to carry a requeue delay.
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requeueKey, &requeue{})
+}
+
+// RequeueAfter returns the requeue delay set in the given context, if any.
+func RequeueAfter(ctx context.Context) (time.Duration, bool) {
+	r, ok := ctx.Value(requeueKey).(*requeue)
+	if !ok {
+		return 0, false
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.after, r.set
+}
+
+// SetRequeueAfter asks for the reconciled object to be reconciled again after
+// the given delay. In case several delays are set, the shortest one wins. It
+// is a no-op in case the given context does not carry a requeue value.
+func SetRequeueAfter(ctx context.Context, after time.Duration) {
+	r, ok := ctx.Value(requeueKey).(*requeue)
+	if !ok {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.set || after < r.after {
+		r.after = after
+		r.set = true
+	}
+}
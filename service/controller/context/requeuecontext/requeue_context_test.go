@@ -0,0 +1,42 @@
+package requeuecontext
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_Controller_RequeueContext(t *testing.T) {
+	// Test 0 ensures contexts without requeue value are ignored.
+	{
+		ctx := context.Background()
+		SetRequeueAfter(ctx, time.Minute)
+
+		_, ok := RequeueAfter(ctx)
+		if ok {
+			t.Fatalf("test 0 expected %t got %t", false, true)
+		}
+	}
+
+	// Test 1 ensures the shortest requeue delay wins.
+	{
+		ctx := NewContext(context.Background())
+
+		_, ok := RequeueAfter(ctx)
+		if ok {
+			t.Fatalf("test 1 expected %t got %t", false, true)
+		}
+
+		SetRequeueAfter(ctx, time.Minute)
+		SetRequeueAfter(ctx, 10*time.Second)
+		SetRequeueAfter(ctx, time.Hour)
+
+		after, ok := RequeueAfter(ctx)
+		if !ok {
+			t.Fatalf("test 1 expected %t got %t", true, false)
+		}
+		if after != 10*time.Second {
+			t.Fatalf("test 1 expected %s got %s", 10*time.Second, after)
+		}
+	}
+}
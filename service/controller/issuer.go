@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"github.com/giantswarm/k8sclient/v7/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller"
+	"github.com/giantswarm/operatorkit/v7/pkg/resource"
+	"github.com/giantswarm/operatorkit/v7/pkg/resource/wrapper/metricsresource"
+	"github.com/giantswarm/operatorkit/v7/pkg/resource/wrapper/retryresource"
+	"github.com/giantswarm/vaultpki"
+	vaultapi "github.com/hashicorp/vault/api"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultcache"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultmetrics"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultnamespace"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultsign"
+	"github.com/giantswarm/cert-operator/v3/service/controller/resources/certificaterequest"
+	vaultbreakerresource "github.com/giantswarm/cert-operator/v3/service/controller/resources/vaultbreaker"
+)
+
+type IssuerConfig struct {
+	K8sClient    k8sclient.Interface
+	Logger       micrologger.Logger
+	VaultBreaker vaultbreaker.Interface
+	VaultCache   *vaultcache.Cache
+	VaultClient  *vaultapi.Client
+
+	CATTL            string
+	CommonNameFormat string
+	ProjectName      string
+	VaultNamespace   string
+}
+
+// Issuer is the controller reconciling cert-manager CertificateRequests. It
+// signs the requests referencing a ClusterPKIIssuer with the Vault PKI
+// backend of the issuer's tenant cluster.
+type Issuer struct {
+	*controller.Controller
+}
+
+func NewIssuer(config IssuerConfig) (*Issuer, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+
+	var err error
+
+	// Issuers sign with the PKI backends of the default trust domain, whose
+	// IDs are the cluster IDs, so no trust domains are given.
+	var vaultNamespace *vaultnamespace.Namespace
+	{
+		c := vaultnamespace.Config{
+			Format: config.VaultNamespace,
+		}
+
+		vaultNamespace, err = vaultnamespace.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var vaultPKI vaultpki.Interface
+	{
+		c := vaultnamespace.WrapConfig[vaultpki.Interface]{
+			Namespace:   vaultNamespace,
+			VaultClient: config.VaultClient,
+			New: func(vaultClient *vaultapi.Client) (vaultpki.Interface, error) {
+				c := vaultpki.Config{
+					Logger:      config.Logger,
+					VaultClient: vaultClient,
+
+					CATTL:            config.CATTL,
+					CommonNameFormat: config.CommonNameFormat,
+				}
+
+				return vaultpki.New(c)
+			},
+		}
+
+		vaultPKI, err = vaultnamespace.NewPKI(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := vaultmetrics.PKIConfig{
+			VaultPKI: vaultPKI,
+		}
+
+		vaultPKI, err = vaultmetrics.NewPKI(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := vaultcache.PKIConfig{
+			Cache:    config.VaultCache,
+			VaultPKI: vaultPKI,
+		}
+
+		vaultPKI, err = vaultcache.NewPKI(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var vaultSign vaultsign.Interface
+	{
+		c := vaultnamespace.WrapConfig[vaultsign.Interface]{
+			Namespace:   vaultNamespace,
+			VaultClient: config.VaultClient,
+			New: func(vaultClient *vaultapi.Client) (vaultsign.Interface, error) {
+				c := vaultsign.Config{
+					Logger:      config.Logger,
+					VaultClient: vaultClient,
+				}
+
+				return vaultsign.New(c)
+			},
+		}
+
+		vaultSign, err = vaultnamespace.NewSign(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var certificateRequestResource resource.Interface
+	{
+		c := certificaterequest.Config{
+			CtrlClient: config.K8sClient.CtrlClient(),
+			Logger:     config.Logger,
+			VaultPKI:   vaultPKI,
+			VaultSign:  vaultSign,
+
+			CommonNameFormat: config.CommonNameFormat,
+		}
+
+		certificateRequestResource, err = certificaterequest.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var vaultBreakerResource resource.Interface
+	{
+		c := vaultbreakerresource.Config{
+			Logger:       config.Logger,
+			VaultBreaker: config.VaultBreaker,
+		}
+
+		vaultBreakerResource, err = vaultbreakerresource.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	resources := []resource.Interface{
+		vaultBreakerResource,
+		certificateRequestResource,
+	}
+
+	{
+		c := retryresource.WrapConfig{
+			Logger: config.Logger,
+		}
+
+		resources, err = retryresource.Wrap(resources, c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := metricsresource.WrapConfig{}
+
+		resources, err = metricsresource.Wrap(resources, c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var operatorkitController *controller.Controller
+	{
+		c := controller.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+			Name:      config.ProjectName + "-issuer",
+			Resources: resources,
+			Selector:  labels.Everything(),
+			NewRuntimeObjectFunc: func() client.Object {
+				obj := &unstructured.Unstructured{}
+				obj.SetGroupVersionKind(certificaterequest.GroupVersionKind)
+				return obj
+			},
+		}
+
+		operatorkitController, err = controller.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	c := &Issuer{
+		Controller: operatorkitController,
+	}
+
+	return c, nil
+}
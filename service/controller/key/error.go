@@ -10,3 +10,12 @@ var wrongTypeError = &microerror.Error{
 func IsWrongTypeError(err error) bool {
 	return microerror.Cause(err) == wrongTypeError
 }
+
+var unknownHashVersionError = &microerror.Error{
+	Kind: "unknownHashVersionError",
+}
+
+// IsUnknownHashVersion asserts unknownHashVersionError.
+func IsUnknownHashVersion(err error) bool {
+	return microerror.Cause(err) == unknownHashVersionError
+}
@@ -2,19 +2,48 @@ package key
 
 import (
 	"crypto/sha1" // nolint: gosec
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
 	"github.com/giantswarm/certs/v4/pkg/certs"
 	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/annotation"
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
 )
 
 const (
 	CAID  = "ca"
 	CrtID = "crt"
 	KeyID = "key"
+
+	// CABundleID is the secret key holding the CA of the current certificate
+	// followed by the CA of the previous certificate, if any.
+	CABundleID = "ca-bundle"
+	// PreviousCAID, PreviousCrtID and PreviousKeyID are the secret keys holding
+	// the certificate material replaced by the last renewal.
+	PreviousCAID  = "ca-previous"
+	PreviousCrtID = "crt-previous"
+	PreviousKeyID = "key-previous"
+)
+
+const (
+	// ConfigHashVersionLegacy is the version of the config hash computed by
+	// CustomObjectHash. Secrets without a config hash version annotation carry
+	// a hash of this version.
+	ConfigHashVersionLegacy = "1"
+	// ConfigHashVersion is the version of the config hash computed by
+	// ConfigHash. It has to be bumped whenever the set of fields or the
+	// encoding covered by ConfigHash changes.
+	ConfigHashVersion = "2"
 )
 
 func AllowBareDomains(customObject v1alpha1.CertConfig) bool {
@@ -45,6 +74,54 @@ func CrtTTL(customObject v1alpha1.CertConfig) string {
 	return customObject.Spec.Cert.TTL
 }
 
+// ConfigHash computes the canonical hash of the issuance relevant fields of
+// the given cert config. Other than CustomObjectHash it does not depend on the
+// JSON representation of the CertConfig type, so new fields or changed tags in
+// apiextensions do not change the hash of existing cert configs. List values
+// are sorted so that reordering them does not cause renewals either.
+func ConfigHash(customObject v1alpha1.CertConfig) (string, error) {
+	fields := []struct {
+		Name  string
+		Value string
+	}{
+		{Name: "altNames", Value: joinSorted(AltNames(customObject))},
+		{Name: "clusterComponent", Value: ClusterComponent(customObject)},
+		{Name: "clusterID", Value: ClusterID(customObject)},
+		{Name: "commonName", Value: CommonName(customObject)},
+		{Name: "ipSans", Value: joinSorted(IPSANs(customObject))},
+		{Name: "organizations", Value: joinSorted(Organizations(customObject))},
+		{Name: "ttl", Value: CrtTTL(customObject)},
+	}
+
+	h := sha256.New()
+	for _, f := range fields {
+		if _, err := fmt.Fprintf(h, "%s=%q\n", f.Name, f.Value); err != nil {
+			return "", microerror.Mask(err)
+		}
+	}
+	bs := h.Sum(nil)
+
+	return fmt.Sprintf("%x", bs), nil
+}
+
+// ConfigHashForVersion computes the config hash of the given cert config
+// using the algorithm of the given hash version. An empty version refers to
+// ConfigHashVersionLegacy.
+func ConfigHashForVersion(customObject v1alpha1.CertConfig, version string) (string, error) {
+	switch version {
+	case "", ConfigHashVersionLegacy:
+		return CustomObjectHash(customObject)
+	case ConfigHashVersion:
+		return ConfigHash(customObject)
+	}
+
+	return "", microerror.Maskf(unknownHashVersionError, "config hash version %#q", version)
+}
+
+// CustomObjectHash computes the legacy config hash of version
+// ConfigHashVersionLegacy. It is only kept in order to compare and migrate
+// existing secrets. Use ConfigHash for new secrets.
+//
 // nolint: gosec
 func CustomObjectHash(customObject v1alpha1.CertConfig) (string, error) {
 	b, err := json.Marshal(customObject.Spec.Cert)
@@ -61,6 +138,12 @@ func CustomObjectHash(customObject v1alpha1.CertConfig) (string, error) {
 	return fmt.Sprintf("%x", bs), nil
 }
 
+// ForceRenew returns true in case the cert config asks for its certificate to
+// be reissued, either using the annotation or the label.
+func ForceRenew(customObject v1alpha1.CertConfig) bool {
+	return isTrue(customObject.GetAnnotations(), annotation.ForceRenew) || isTrue(customObject.GetLabels(), label.ForceRenew)
+}
+
 func IPSANs(customObject v1alpha1.CertConfig) []string {
 	return customObject.Spec.Cert.IPSANs
 }
@@ -69,6 +152,12 @@ func IsDeleted(customObject v1alpha1.CertConfig) bool {
 	return customObject.GetDeletionTimestamp() != nil
 }
 
+// IsPaused returns true in case the cert config must not be reconciled,
+// either because of the annotation or the label.
+func IsPaused(customObject v1alpha1.CertConfig) bool {
+	return isTrue(customObject.GetAnnotations(), annotation.Paused) || isTrue(customObject.GetLabels(), label.Paused)
+}
+
 func Organizations(customObject v1alpha1.CertConfig) []string {
 	a := make([]string, 0)
 
@@ -85,6 +174,18 @@ func Organizations(customObject v1alpha1.CertConfig) []string {
 	return append(a, customObject.Spec.Cert.Organizations...)
 }
 
+// PKIID returns the ID of the Vault PKI backend issuing the certificate of
+// the given cert config, which is the one of its trust domain.
+func PKIID(customObject v1alpha1.CertConfig, mapping trustdomain.Mapping) string {
+	return trustdomain.PKIID(ClusterID(customObject), TrustDomain(customObject, mapping))
+}
+
+// CAKeySecretName returns the name of the secret holding the private key of
+// the CA of the Vault PKI backend of the given ID, in case it was exported.
+func CAKeySecretName(pkiID string) string {
+	return pkiID + "-ca-key"
+}
+
 func RoleTTL(customObject v1alpha1.CertConfig) string {
 	return customObject.Spec.Cert.TTL
 }
@@ -99,6 +200,49 @@ func SecretLabels(customObject v1alpha1.CertConfig) map[string]string {
 	return certs.K8sLabels(ClusterID(customObject), cert)
 }
 
+// SecretOwnerReference returns the controller owner reference of the secret
+// holding the certificate of the given cert config.
+func SecretOwnerReference(customObject v1alpha1.CertConfig) metav1.OwnerReference {
+	gvk := v1alpha1.SchemeGroupVersion.WithKind(v1alpha1.NewCertConfigTypeMeta().Kind)
+	return *metav1.NewControllerRef(&customObject, gvk)
+}
+
+// TenantClusterID returns the ID of the tenant cluster represented by the
+// given CAPI cluster. That is the value of its cluster label, or its name in
+// case the label is not set.
+func TenantClusterID(cluster capi.Cluster) string {
+	id, ok := cluster.Labels[label.Cluster]
+	if ok && id != "" {
+		return id
+	}
+
+	return cluster.Name
+}
+
+// TrustDomain returns the trust domain of the given cert config. That is the
+// value of its trust domain annotation, or the trust domain its cluster
+// component is mapped to. Annotation values which are not a trust domain of
+// the mapping are ignored, so that every PKI backend is known to the teardown
+// of the tenant cluster.
+func TrustDomain(customObject v1alpha1.CertConfig, mapping trustdomain.Mapping) string {
+	d := customObject.GetAnnotations()[annotation.TrustDomain]
+	if d != "" && mapping.Has(d) {
+		return d
+	}
+
+	return mapping.Domain(ClusterComponent(customObject))
+}
+
+func ToCluster(v interface{}) (capi.Cluster, error) {
+	clusterPointer, ok := v.(*capi.Cluster)
+	if !ok {
+		return capi.Cluster{}, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", &capi.Cluster{}, v)
+	}
+	cluster := *clusterPointer
+
+	return cluster, nil
+}
+
 func ToCustomObject(v interface{}) (v1alpha1.CertConfig, error) {
 	customObjectPointer, ok := v.(*v1alpha1.CertConfig)
 	if !ok {
@@ -108,3 +252,15 @@ func ToCustomObject(v interface{}) (v1alpha1.CertConfig, error) {
 
 	return customObject, nil
 }
+
+func joinSorted(l []string) string {
+	c := make([]string, len(l))
+	copy(c, l)
+	sort.Strings(c)
+
+	return strings.Join(c, ",")
+}
+
+func isTrue(m map[string]string, k string) bool {
+	return m[k] == "true"
+}
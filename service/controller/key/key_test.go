@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
 )
 
 func Test_Organization(t *testing.T) {
@@ -187,3 +189,225 @@ func TestOrganizations(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigHash(t *testing.T) {
+	base := v1alpha1.CertConfig{
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				AltNames:         []string{"kubernetes", "kubernetes.default"},
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				CommonName:       "api.al9qy.k8s.gigantic.io",
+				IPSANs:           []string{"10.0.0.1", "127.0.0.1"},
+				Organizations:    []string{"system:masters"},
+				TTL:              "720h",
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		mutate       func(c *v1alpha1.CertConfig)
+		expectChange bool
+	}{
+		{
+			name:         "unchanged cert config",
+			mutate:       func(c *v1alpha1.CertConfig) {},
+			expectChange: false,
+		},
+		{
+			name: "reordered alt names and IP SANs",
+			mutate: func(c *v1alpha1.CertConfig) {
+				c.Spec.Cert.AltNames = []string{"kubernetes.default", "kubernetes"}
+				c.Spec.Cert.IPSANs = []string{"127.0.0.1", "10.0.0.1"}
+			},
+			expectChange: false,
+		},
+		{
+			name: "fields not relevant for issuance",
+			mutate: func(c *v1alpha1.CertConfig) {
+				c.Spec.Cert.AllowBareDomains = true
+				c.Spec.Cert.DisableRegeneration = true
+				c.Spec.VersionBundle.Version = "1.0.0"
+			},
+			expectChange: false,
+		},
+		{
+			name: "changed alt names",
+			mutate: func(c *v1alpha1.CertConfig) {
+				c.Spec.Cert.AltNames = []string{"kubernetes"}
+			},
+			expectChange: true,
+		},
+		{
+			name: "changed TTL",
+			mutate: func(c *v1alpha1.CertConfig) {
+				c.Spec.Cert.TTL = "1440h"
+			},
+			expectChange: true,
+		},
+		{
+			name: "changed organizations",
+			mutate: func(c *v1alpha1.CertConfig) {
+				c.Spec.Cert.Organizations = []string{"giantswarm"}
+			},
+			expectChange: true,
+		},
+	}
+
+	expected, err := ConfigHash(base)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := *base.DeepCopy()
+			tt.mutate(&c)
+
+			got, err := ConfigHash(c)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+			if (got != expected) != tt.expectChange {
+				t.Errorf("ConfigHash() changed = %t, want %t", got != expected, tt.expectChange)
+			}
+		})
+	}
+}
+
+func TestConfigHashForVersion(t *testing.T) {
+	customObject := v1alpha1.CertConfig{}
+
+	legacy, err := CustomObjectHash(customObject)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	current, err := ConfigHash(customObject)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	for version, expected := range map[string]string{"": legacy, ConfigHashVersionLegacy: legacy, ConfigHashVersion: current} {
+		got, err := ConfigHashForVersion(customObject, version)
+		if err != nil {
+			t.Fatalf("version %#q expected %#v got %#v", version, nil, err)
+		}
+		if got != expected {
+			t.Fatalf("version %#q expected %#q got %#q", version, expected, got)
+		}
+	}
+
+	_, err = ConfigHashForVersion(customObject, "99")
+	if !IsUnknownHashVersion(err) {
+		t.Fatalf("expected %#v got %#v", unknownHashVersionError, err)
+	}
+}
+
+func TestForceRenewAndIsPaused(t *testing.T) {
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		labels           map[string]string
+		expectForceRenew bool
+		expectPaused     bool
+	}{
+		{
+			name: "no annotations and labels",
+		},
+		{
+			name:             "annotations",
+			annotations:      map[string]string{"cert-operator.giantswarm.io/force-renew": "true", "cert-operator.giantswarm.io/paused": "true"},
+			expectForceRenew: true,
+			expectPaused:     true,
+		},
+		{
+			name:             "labels",
+			labels:           map[string]string{"cert-operator.giantswarm.io/force-renew": "true", "cert-operator.giantswarm.io/paused": "true"},
+			expectForceRenew: true,
+			expectPaused:     true,
+		},
+		{
+			name:        "other values",
+			annotations: map[string]string{"cert-operator.giantswarm.io/force-renew": "false"},
+			labels:      map[string]string{"cert-operator.giantswarm.io/paused": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customObject := v1alpha1.CertConfig{}
+			customObject.SetAnnotations(tt.annotations)
+			customObject.SetLabels(tt.labels)
+
+			if got := ForceRenew(customObject); got != tt.expectForceRenew {
+				t.Errorf("ForceRenew() = %t, want %t", got, tt.expectForceRenew)
+			}
+			if got := IsPaused(customObject); got != tt.expectPaused {
+				t.Errorf("IsPaused() = %t, want %t", got, tt.expectPaused)
+			}
+		})
+	}
+}
+
+func TestPKIID(t *testing.T) {
+	mapping := trustdomain.Mapping{"etcd": "etcd", "aggregator": "front-proxy"}
+
+	tests := []struct {
+		name        string
+		component   string
+		annotations map[string]string
+		want        string
+	}{
+		{
+			name:      "unmapped component",
+			component: "api",
+			want:      "al9qy",
+		},
+		{
+			name:      "mapped component",
+			component: "etcd",
+			want:      "al9qy-etcd",
+		},
+		{
+			name:        "pinned to the default trust domain",
+			component:   "etcd",
+			annotations: map[string]string{"cert-operator.giantswarm.io/trust-domain": "kubernetes"},
+			want:        "al9qy",
+		},
+		{
+			name:        "pinned to another trust domain",
+			component:   "api",
+			annotations: map[string]string{"cert-operator.giantswarm.io/trust-domain": "front-proxy"},
+			want:        "al9qy-front-proxy",
+		},
+		{
+			name:        "pinned to a trust domain not in the mapping",
+			component:   "api",
+			annotations: map[string]string{"cert-operator.giantswarm.io/trust-domain": "apiserver"},
+			want:        "al9qy",
+		},
+		{
+			name:        "invalid annotation value",
+			component:   "etcd",
+			annotations: map[string]string{"cert-operator.giantswarm.io/trust-domain": "../g8s"},
+			want:        "al9qy-etcd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customObject := v1alpha1.CertConfig{
+				Spec: v1alpha1.CertConfigSpec{
+					Cert: v1alpha1.CertConfigSpecCert{
+						ClusterComponent: tt.component,
+						ClusterID:        "al9qy",
+					},
+				},
+			}
+			customObject.SetAnnotations(tt.annotations)
+
+			if got := PKIID(customObject, mapping); got != tt.want {
+				t.Errorf("PKIID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
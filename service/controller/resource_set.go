@@ -12,38 +12,89 @@ import (
 	"github.com/giantswarm/vaultcrt"
 	"github.com/giantswarm/vaultpki"
 	"github.com/giantswarm/vaultrole"
-	vaultapi "github.com/hashicorp/vault/api"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultauth"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultimport"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultintermediate"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultroot"
+	"github.com/giantswarm/cert-operator/v3/service/controller/resources/capisecret"
+	"github.com/giantswarm/cert-operator/v3/service/controller/resources/pause"
 	"github.com/giantswarm/cert-operator/v3/service/controller/resources/vaultaccess"
+	vaultbreakerresource "github.com/giantswarm/cert-operator/v3/service/controller/resources/vaultbreaker"
 	vaultcrtresource "github.com/giantswarm/cert-operator/v3/service/controller/resources/vaultcrt"
 	vaultpkiresource "github.com/giantswarm/cert-operator/v3/service/controller/resources/vaultpki"
 	vaultroleresource "github.com/giantswarm/cert-operator/v3/service/controller/resources/vaultrole"
 )
 
 type ResourceSetConfig struct {
-	K8sClient   kubernetes.Interface
-	CtrlClient  client.Client
-	Logger      micrologger.Logger
-	VaultClient *vaultapi.Client
-	VaultCrt    vaultcrt.Interface
-	VaultPKI    vaultpki.Interface
-	VaultRole   vaultrole.Interface
-
-	ExpirationThreshold time.Duration
-	Namespace           string
-	ProjectName         string
+	K8sClient    kubernetes.Interface
+	CtrlClient   client.Client
+	Logger       micrologger.Logger
+	VaultAuth    vaultauth.Interface
+	VaultBreaker vaultbreaker.Interface
+	VaultCrt     vaultcrt.Interface
+	VaultImport  vaultimport.Interface
+	VaultPKI     vaultpki.Interface
+	VaultRole    vaultrole.Interface
+	VaultRoot    vaultroot.Interface
+	// VaultIntermediate is nil in case CAs are created as self-signed root
+	// CAs.
+	VaultIntermediate vaultintermediate.Interface
+
+	// CAConstraints is nil in case CAs are created without constraints.
+	CAConstraints           *caconstraint.Policy
+	CAPISecrets             bool
+	CAExpirationThreshold   time.Duration
+	CommonNameFormat        string
+	ExpirationThreshold     time.Duration
+	Namespace               string
+	ReloadWorkloads         bool
+	ProjectName             string
+	RenewalBudget           int
+	RenewalBudgetPerCluster int
+	RenewalJitter           time.Duration
+	RenewalOverlap          time.Duration
+	TrustDomains            trustdomain.Mapping
 }
 
 func NewResourceSet(config ResourceSetConfig) ([]resource.Interface, error) {
 	var err error
 
+	var pauseResource resource.Interface
+	{
+		c := pause.Config{
+			Logger: config.Logger,
+		}
+
+		pauseResource, err = pause.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var vaultBreakerResource resource.Interface
+	{
+		c := vaultbreakerresource.Config{
+			Logger:       config.Logger,
+			VaultBreaker: config.VaultBreaker,
+		}
+
+		vaultBreakerResource, err = vaultbreakerresource.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var vaultAccessResource resource.Interface
 	{
 		c := vaultaccess.Config{
-			Logger:      config.Logger,
-			VaultClient: config.VaultClient,
+			Logger:    config.Logger,
+			VaultAuth: config.VaultAuth,
 		}
 
 		vaultAccessResource, err = vaultaccess.New(c)
@@ -60,9 +111,17 @@ func NewResourceSet(config ResourceSetConfig) ([]resource.Interface, error) {
 			CtrlClient:         config.CtrlClient,
 			Logger:             config.Logger,
 			VaultCrt:           config.VaultCrt,
-
-			ExpirationThreshold: config.ExpirationThreshold,
-			Namespace:           config.Namespace,
+			VaultIntermediate:  config.VaultIntermediate,
+			VaultPKI:           config.VaultPKI,
+
+			ExpirationThreshold:     config.ExpirationThreshold,
+			Namespace:               config.Namespace,
+			ReloadWorkloads:         config.ReloadWorkloads,
+			RenewalBudget:           config.RenewalBudget,
+			RenewalBudgetPerCluster: config.RenewalBudgetPerCluster,
+			RenewalJitter:           config.RenewalJitter,
+			RenewalOverlap:          config.RenewalOverlap,
+			TrustDomains:            config.TrustDomains,
 		}
 
 		ops, err := vaultcrtresource.New(c)
@@ -79,8 +138,18 @@ func NewResourceSet(config ResourceSetConfig) ([]resource.Interface, error) {
 	var vaultPKIResource resource.Interface
 	{
 		c := vaultpkiresource.Config{
-			Logger:   config.Logger,
-			VaultPKI: config.VaultPKI,
+			CtrlClient:         config.CtrlClient,
+			CurrentTimeFactory: func() time.Time { return time.Now() },
+			Logger:             config.Logger,
+			VaultImport:        config.VaultImport,
+			VaultIntermediate:  config.VaultIntermediate,
+			VaultPKI:           config.VaultPKI,
+			CAConstraints:      config.CAConstraints,
+			VaultRoot:          config.VaultRoot,
+
+			ExportCAKeys:          config.CAPISecrets,
+			CAExpirationThreshold: config.CAExpirationThreshold,
+			TrustDomains:          config.TrustDomains,
 		}
 
 		ops, err := vaultpkiresource.New(c)
@@ -99,6 +168,9 @@ func NewResourceSet(config ResourceSetConfig) ([]resource.Interface, error) {
 		c := vaultroleresource.Config{
 			Logger:    config.Logger,
 			VaultRole: config.VaultRole,
+
+			CommonNameFormat: config.CommonNameFormat,
+			TrustDomains:     config.TrustDomains,
 		}
 
 		ops, err := vaultroleresource.New(c)
@@ -113,12 +185,31 @@ func NewResourceSet(config ResourceSetConfig) ([]resource.Interface, error) {
 	}
 
 	resources := []resource.Interface{
+		pauseResource,
+		vaultBreakerResource,
 		vaultAccessResource,
 		vaultPKIResource,
 		vaultRoleResource,
 		vaultCrtResource,
 	}
 
+	if config.CAPISecrets {
+		c := capisecret.Config{
+			CtrlClient: config.CtrlClient,
+			K8sClient:  config.K8sClient,
+			Logger:     config.Logger,
+
+			TrustDomains: config.TrustDomains,
+		}
+
+		capiSecretResource, err := capisecret.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		resources = append(resources, capiSecretResource)
+	}
+
 	{
 		c := retryresource.WrapConfig{
 			Logger: config.Logger,
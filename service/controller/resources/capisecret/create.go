@@ -0,0 +1,408 @@
+package capisecret
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/certs/v4/pkg/certs"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/project"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var source *corev1.Secret
+	{
+		source, err = r.k8sClient.CoreV1().Secrets(customObject.GetNamespace()).Get(ctx, key.SecretName(customObject), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			r.logger.LogCtx(ctx, "level", "debug", "message", "the certificate secret does not exist yet")
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if len(source.Data[key.CAID]) == 0 {
+			r.logger.LogCtx(ctx, "level", "debug", "message", "the certificate secret does not contain a CA yet")
+			return nil
+		}
+	}
+
+	id := key.ClusterID(customObject)
+
+	caKey, err := r.findCAKey(ctx, customObject, source.Data[key.CAID])
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	purposes, err := r.ownPurposes(ctx, customObject)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Every CA secret is written from the CA of the trust domain issuing the
+	// certificates of its purpose, e.g. the etcd CA secret from the CA of the
+	// trust domain of etcd. All cert configs of that trust domain own the
+	// secret, so that it is only garbage collected once the last of them is
+	// gone.
+	for _, purpose := range purposes {
+		data := map[string][]byte{
+			tlsCrtDataName: source.Data[key.CAID],
+		}
+		if caKey != nil {
+			data[tlsKeyDataName] = caKey
+		}
+
+		desired := newSecret(customObject, secretName(id, purpose), data)
+
+		err = r.ensureSecret(ctx, customObject, desired, false)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// The admin kubeconfig can only be written with the private key of
+		// the cluster CA, which signs its client certificate.
+		if purpose == purposeClusterCA && caKey != nil {
+			err = r.ensureKubeconfig(ctx, customObject, source.Data[key.CAID], caKey)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	// The service account key pair is taken from the service account
+	// certificate, which is solely owned by its cert config.
+	if key.ClusterComponent(customObject) == certs.ServiceAccountCert.String() && len(source.Data[key.KeyID]) != 0 {
+		publicKey, err := publicKeyPEM(source.Data[key.KeyID])
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		desired := newSecret(customObject, secretName(id, purposeServiceAccount), map[string][]byte{
+			tlsCrtDataName: publicKey,
+			tlsKeyDataName: source.Data[key.KeyID],
+		})
+
+		err = r.ensureSecret(ctx, customObject, desired, true)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Resource) ensureSecret(ctx context.Context, customObject v1alpha1.CertConfig, desired *corev1.Secret, controller bool) error {
+	owner := key.SecretOwnerReference(customObject)
+	if !controller {
+		owner.Controller = nil
+		owner.BlockOwnerDeletion = nil
+	}
+
+	current, err := r.k8sClient.CoreV1().Secrets(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("creating Cluster API secret %#q", desired.Name))
+
+		desired.OwnerReferences = []metav1.OwnerReference{owner}
+
+		_, err = r.k8sClient.CoreV1().Secrets(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			// The secret got created by another cert config of the cluster in
+			// the meantime. It is updated with the next reconciliation.
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("created Cluster API secret %#q", desired.Name))
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// The certificate secret of the etcd cert config has the name of the etcd
+	// CA secret. The CA is merged into it, see mergeCertificateSecret.
+	if _, ok := current.Labels[label.Certificate]; ok {
+		err = r.mergeCertificateSecret(ctx, current, desired)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+	// Secrets written by Cluster API itself or by anyone else are left
+	// untouched.
+	if current.Labels[label.ManagedBy] != project.Name() {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not updating Cluster API secret %#q managed by %#q", current.Name, current.Labels[label.ManagedBy]))
+		return nil
+	}
+
+	update := current.DeepCopy()
+	changed := false
+
+	if !reflect.DeepEqual(current.Data, desired.Data) {
+		update.Data = desired.Data
+		changed = true
+	}
+	if !hasOwner(current.OwnerReferences, owner) {
+		update.OwnerReferences = append(update.OwnerReferences, owner)
+		changed = true
+	}
+
+	if !changed {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Cluster API secret %#q is up to date", current.Name))
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("updating Cluster API secret %#q", current.Name))
+
+	_, err = r.k8sClient.CoreV1().Secrets(update.Namespace).Update(ctx, update, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("updated Cluster API secret %#q", current.Name))
+
+	return nil
+}
+
+// mergeCertificateSecret writes the CA of the given desired Cluster API secret
+// into the given certificate secret of the same name. Only the tls.crt and
+// tls.key keys Cluster API reads are written, so the certificate of the cert
+// config remains untouched. The vaultcrt resource keeps these keys when it
+// renews the certificate. The owners of the secret are not changed, since the
+// cert config controls its certificate secret.
+func (r *Resource) mergeCertificateSecret(ctx context.Context, current *corev1.Secret, desired *corev1.Secret) error {
+	update := current.DeepCopy()
+	if update.Data == nil {
+		update.Data = map[string][]byte{}
+	}
+
+	for _, k := range []string{tlsCrtDataName, tlsKeyDataName} {
+		if v, ok := desired.Data[k]; ok {
+			update.Data[k] = v
+		} else {
+			delete(update.Data, k)
+		}
+	}
+
+	if reflect.DeepEqual(current.Data, update.Data) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Cluster API CA in certificate secret %#q is up to date", current.Name))
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("merging Cluster API CA into certificate secret %#q", current.Name))
+
+	_, err := r.k8sClient.CoreV1().Secrets(update.Namespace).Update(ctx, update, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("merged Cluster API CA into certificate secret %#q", current.Name))
+
+	return nil
+}
+
+// secretName returns the name Cluster API uses for the secret of the given
+// purpose of the given cluster.
+func secretName(clusterName, purpose string) string {
+	return fmt.Sprintf("%s-%s", clusterName, purpose)
+}
+
+func newSecret(customObject v1alpha1.CertConfig, name string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: customObject.GetNamespace(),
+			Labels: map[string]string{
+				capi.ClusterLabelName: key.ClusterID(customObject),
+				label.Cluster:         key.ClusterID(customObject),
+				label.ManagedBy:       project.Name(),
+			},
+		},
+		Type: capi.ClusterSecretType,
+		Data: data,
+	}
+}
+
+func hasOwner(refs []metav1.OwnerReference, owner metav1.OwnerReference) bool {
+	for _, ref := range refs {
+		if ref.UID == owner.UID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ownPurposes returns the purposes of the CA secrets whose CA is the one of the
+// trust domain of the given cert config. The trust domain of a purpose is the
+// one of the cert config of its cluster component, falling back to the trust
+// domain mapping in case the cluster has no such cert config.
+func (r *Resource) ownPurposes(ctx context.Context, customObject v1alpha1.CertConfig) ([]string, error) {
+	list := &v1alpha1.CertConfigList{}
+	err := r.ctrlClient.List(ctx, list, client.InNamespace(customObject.GetNamespace()), client.MatchingLabels{label.Cluster: key.ClusterID(customObject)})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	domains := map[string]string{}
+	for _, cc := range list.Items {
+		domains[key.ClusterComponent(cc)] = key.TrustDomain(cc, r.trustDomains)
+	}
+
+	own := key.TrustDomain(customObject, r.trustDomains)
+
+	var purposes []string
+	for _, purpose := range []string{purposeClusterCA, purposeEtcdCA, purposeFrontProxyCA} {
+		component := purposeComponents[purpose]
+
+		d, ok := domains[component]
+		if !ok {
+			d = r.trustDomains.Domain(component)
+		}
+
+		if d == own {
+			purposes = append(purposes, purpose)
+		}
+	}
+
+	return purposes, nil
+}
+
+// findCAKey returns the private key of the CA of the PKI backend of the given
+// cert config, in case it got exported from Vault and still matches the given
+// CA certificate. Otherwise nil is returned.
+func (r *Resource) findCAKey(ctx context.Context, customObject v1alpha1.CertConfig, caCrt []byte) ([]byte, error) {
+	name := key.CAKeySecretName(key.PKIID(customObject, r.trustDomains))
+
+	secret, err := r.k8sClient.CoreV1().Secrets(customObject.GetNamespace()).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the private key of the CA is not known")
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	caKey := secret.Data[tlsKeyDataName]
+
+	matches, err := keyMatchesCertificate(caKey, caCrt)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if !matches {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("the private key of CA key secret %#q does not match the CA", name))
+		return nil, nil
+	}
+
+	return caKey, nil
+}
+
+// keyMatchesCertificate returns whether the given PEM encoded private key
+// belongs to the given PEM encoded certificate.
+func keyMatchesCertificate(privateKey []byte, certificate []byte) (bool, error) {
+	signer, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	crt, err := parseCertificate(certificate)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	p, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return false, nil
+	}
+
+	return p.Equal(crt.PublicKey), nil
+}
+
+func parseCertificate(certificate []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certificate)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, microerror.Maskf(invalidCertificateError, "certificate must be PEM encoded")
+	}
+
+	crt, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, microerror.Maskf(invalidCertificateError, err.Error())
+	}
+
+	return crt, nil
+}
+
+func parsePrivateKey(privateKey []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, microerror.Maskf(invalidPrivateKeyError, "private key must be PEM encoded")
+	}
+
+	var k interface{}
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		k, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		k, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		k, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, microerror.Maskf(invalidPrivateKeyError, err.Error())
+	}
+
+	switch k := k.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, microerror.Maskf(invalidPrivateKeyError, "unsupported private key type %T", k)
+	}
+}
+
+// publicKeyPEM returns the PEM encoded public key of the given PEM encoded
+// private key, which is the format Cluster API expects in the service account
+// secret.
+func publicKeyPEM(privateKey []byte) ([]byte, error) {
+	signer, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	b, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var buf bytes.Buffer
+	err = pem.Encode(&buf, &pem.Block{Type: "PUBLIC KEY", Bytes: b})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return buf.Bytes(), nil
+}
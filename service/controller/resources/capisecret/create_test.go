@@ -0,0 +1,503 @@
+package capisecret
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+func Test_Resource_CAPISecret_EnsureCreated(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	caPEM, caKeyPEM := newCA(t)
+
+	certConfig := func(component string, uid types.UID) *v1alpha1.CertConfig {
+		return &v1alpha1.CertConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "al9qy-" + component,
+				Namespace: "org-giantswarm",
+				UID:       uid,
+			},
+			Spec: v1alpha1.CertConfigSpec{
+				Cert: v1alpha1.CertConfigSpecCert{
+					ClusterComponent: component,
+					ClusterID:        "al9qy",
+				},
+			},
+		}
+	}
+	sourceSecret := func(customObject *v1alpha1.CertConfig) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.SecretName(*customObject),
+				Namespace: "org-giantswarm",
+			},
+			Data: map[string][]byte{
+				key.CAID:  caPEM,
+				key.CrtID: []byte("crt"),
+				key.KeyID: keyPEM,
+			},
+		}
+	}
+
+	api := certConfig("api", "8d0b2c40-95b5-4f54-a5c3-0bd3c6b5f0a1")
+	sa := certConfig("service-account", "5b0f4a3e-0f53-4e2e-8a5e-4f6d2c1a7b9c")
+
+	cluster := &capi.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "al9qy",
+			Namespace: "org-giantswarm",
+		},
+		Spec: capi.ClusterSpec{
+			ControlPlaneEndpoint: capi.APIEndpoint{
+				Host: "api.al9qy.k8s.gigantic.io",
+				Port: 443,
+			},
+		},
+	}
+
+	k8sClient := fake.NewSimpleClientset()
+
+	var newResource *Resource
+	{
+		scheme := runtime.NewScheme()
+		_ = v1alpha1.AddToScheme(scheme)
+		_ = capi.AddToScheme(scheme)
+
+		c := Config{
+			CtrlClient: fakectrl.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build(),
+			K8sClient:  k8sClient,
+			Logger:     microloggertest.New(),
+		}
+
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	getSecret := func(name string) (*corev1.Secret, error) {
+		return k8sClient.CoreV1().Secrets("org-giantswarm").Get(context.Background(), name, metav1.GetOptions{})
+	}
+
+	// Test 0 ensures nothing is written as long as the certificate secret does
+	// not exist.
+	{
+		err := newResource.EnsureCreated(context.Background(), api)
+		if err != nil {
+			t.Fatal("test 0 expected", nil, "got", err)
+		}
+
+		_, err = getSecret("al9qy-ca")
+		if !apierrors.IsNotFound(err) {
+			t.Fatal("test 0 expected not found error got", err)
+		}
+	}
+
+	// Test 1 ensures the CA secrets are written, owned by the cert config
+	// without it being their controller. As long as the private key of the CA
+	// is not known, they only contain the CA certificate and no kubeconfig is
+	// written.
+	{
+		_, err := k8sClient.CoreV1().Secrets("org-giantswarm").Create(context.Background(), sourceSecret(api), metav1.CreateOptions{})
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+
+		err = newResource.EnsureCreated(context.Background(), api)
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+
+		for _, name := range []string{"al9qy-ca", "al9qy-etcd", "al9qy-proxy"} {
+			s, err := getSecret(name)
+			if err != nil {
+				t.Fatalf("test 1 expected secret %#q got %#v", name, err)
+			}
+			if string(s.Data[tlsCrtDataName]) != string(caPEM) || len(s.Data[tlsKeyDataName]) != 0 {
+				t.Fatalf("test 1 expected secret %#q to contain the CA certificate only got %#v", name, s.Data)
+			}
+			if len(s.OwnerReferences) != 1 || s.OwnerReferences[0].UID != api.UID || s.OwnerReferences[0].Controller != nil {
+				t.Fatalf("test 1 expected secret %#q to be owned by the cert config got %#v", name, s.OwnerReferences)
+			}
+		}
+
+		for _, name := range []string{"al9qy-sa", "al9qy-kubeconfig"} {
+			_, err = getSecret(name)
+			if !apierrors.IsNotFound(err) {
+				t.Fatalf("test 1 expected secret %#q to not exist got %#v", name, err)
+			}
+		}
+	}
+
+	// Test 2 ensures the service account secret is written for the service
+	// account cert config and the CA secrets get another owner.
+	{
+		_, err := k8sClient.CoreV1().Secrets("org-giantswarm").Create(context.Background(), sourceSecret(sa), metav1.CreateOptions{})
+		if err != nil {
+			t.Fatal("test 2 expected", nil, "got", err)
+		}
+
+		err = newResource.EnsureCreated(context.Background(), sa)
+		if err != nil {
+			t.Fatal("test 2 expected", nil, "got", err)
+		}
+
+		s, err := getSecret("al9qy-sa")
+		if err != nil {
+			t.Fatal("test 2 expected", nil, "got", err)
+		}
+		if string(s.Data[tlsKeyDataName]) != string(keyPEM) {
+			t.Fatalf("test 2 expected the private key got %#q", s.Data[tlsKeyDataName])
+		}
+
+		block, _ := pem.Decode(s.Data[tlsCrtDataName])
+		if block == nil || block.Type != "PUBLIC KEY" {
+			t.Fatalf("test 2 expected a PEM encoded public key got %#q", s.Data[tlsCrtDataName])
+		}
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			t.Fatal("test 2 expected", nil, "got", err)
+		}
+		if !privateKey.PublicKey.Equal(publicKey) {
+			t.Fatalf("test 2 expected the public key of the private key")
+		}
+		if len(s.OwnerReferences) != 1 || s.OwnerReferences[0].UID != sa.UID || s.OwnerReferences[0].Controller == nil || !*s.OwnerReferences[0].Controller {
+			t.Fatalf("test 2 expected secret to be controlled by the cert config got %#v", s.OwnerReferences)
+		}
+
+		ca, err := getSecret("al9qy-ca")
+		if err != nil {
+			t.Fatal("test 2 expected", nil, "got", err)
+		}
+		if len(ca.OwnerReferences) != 2 {
+			t.Fatalf("test 2 expected %d owners got %#v", 2, ca.OwnerReferences)
+		}
+	}
+
+	// Test 3 ensures secrets not managed by cert-operator are left untouched.
+	{
+		s, err := getSecret("al9qy-etcd")
+		if err != nil {
+			t.Fatal("test 3 expected", nil, "got", err)
+		}
+		s.Labels = nil
+		s.Data = map[string][]byte{tlsCrtDataName: []byte("other"), tlsKeyDataName: []byte("other")}
+		_, err = k8sClient.CoreV1().Secrets("org-giantswarm").Update(context.Background(), s, metav1.UpdateOptions{})
+		if err != nil {
+			t.Fatal("test 3 expected", nil, "got", err)
+		}
+
+		err = newResource.EnsureCreated(context.Background(), api)
+		if err != nil {
+			t.Fatal("test 3 expected", nil, "got", err)
+		}
+
+		s, err = getSecret("al9qy-etcd")
+		if err != nil {
+			t.Fatal("test 3 expected", nil, "got", err)
+		}
+		if string(s.Data[tlsCrtDataName]) != "other" {
+			t.Fatalf("test 3 expected secret to be untouched got %#v", s.Data)
+		}
+	}
+
+	// Test 4 ensures the CA secrets contain the private key of the CA once it
+	// is known and the kubeconfig is written with a client certificate signed
+	// by the CA.
+	{
+		caKeySecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.CAKeySecretName("al9qy"),
+				Namespace: "org-giantswarm",
+			},
+			Data: map[string][]byte{
+				tlsKeyDataName: caKeyPEM,
+			},
+		}
+		_, err := k8sClient.CoreV1().Secrets("org-giantswarm").Create(context.Background(), caKeySecret, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatal("test 4 expected", nil, "got", err)
+		}
+
+		err = newResource.EnsureCreated(context.Background(), api)
+		if err != nil {
+			t.Fatal("test 4 expected", nil, "got", err)
+		}
+
+		s, err := getSecret("al9qy-ca")
+		if err != nil {
+			t.Fatal("test 4 expected", nil, "got", err)
+		}
+		if string(s.Data[tlsCrtDataName]) != string(caPEM) || string(s.Data[tlsKeyDataName]) != string(caKeyPEM) {
+			t.Fatalf("test 4 expected secret to contain the CA key pair got %#v", s.Data)
+		}
+
+		s, err = getSecret("al9qy-kubeconfig")
+		if err != nil {
+			t.Fatal("test 4 expected", nil, "got", err)
+		}
+		if s.Type != capi.ClusterSecretType {
+			t.Fatalf("test 4 expected type %#q got %#q", capi.ClusterSecretType, s.Type)
+		}
+		if !kubeconfigUpToDate(s.Data[kubeconfigDataName], "https://api.al9qy.k8s.gigantic.io:443", caPEM, time.Now()) {
+			t.Fatalf("test 4 expected a valid kubeconfig got %s", s.Data[kubeconfigDataName])
+		}
+	}
+
+	// Test 5 ensures the kubeconfig is not regenerated as long as it is up to
+	// date.
+	{
+		before, err := getSecret("al9qy-kubeconfig")
+		if err != nil {
+			t.Fatal("test 5 expected", nil, "got", err)
+		}
+
+		err = newResource.EnsureCreated(context.Background(), sa)
+		if err != nil {
+			t.Fatal("test 5 expected", nil, "got", err)
+		}
+
+		after, err := getSecret("al9qy-kubeconfig")
+		if err != nil {
+			t.Fatal("test 5 expected", nil, "got", err)
+		}
+		if string(after.Data[kubeconfigDataName]) != string(before.Data[kubeconfigDataName]) {
+			t.Fatalf("test 5 expected the kubeconfig to be kept")
+		}
+		if len(after.OwnerReferences) != 2 {
+			t.Fatalf("test 5 expected %d owners got %#v", 2, after.OwnerReferences)
+		}
+	}
+}
+
+func Test_Resource_CAPISecret_EnsureCreated_trustDomains(t *testing.T) {
+	caPEM, _ := newCA(t)
+	etcdCAPEM, _ := newCA(t)
+
+	certConfig := func(component string, uid types.UID) *v1alpha1.CertConfig {
+		return &v1alpha1.CertConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "al9qy-" + component,
+				Namespace: "org-giantswarm",
+				UID:       uid,
+				Labels:    map[string]string{label.Cluster: "al9qy"},
+			},
+			Spec: v1alpha1.CertConfigSpec{
+				Cert: v1alpha1.CertConfigSpecCert{
+					ClusterComponent: component,
+					ClusterID:        "al9qy",
+				},
+			},
+		}
+	}
+	sourceSecret := func(customObject *v1alpha1.CertConfig, ca []byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.SecretName(*customObject),
+				Namespace: "org-giantswarm",
+				Labels:    key.SecretLabels(*customObject),
+			},
+			Data: map[string][]byte{
+				key.CAID:  ca,
+				key.CrtID: []byte("crt"),
+			},
+		}
+	}
+
+	api := certConfig("api", "8d0b2c40-95b5-4f54-a5c3-0bd3c6b5f0a1")
+	// The certificate secret of the etcd cert config has the name of the etcd
+	// CA secret, so the CA is merged into it.
+	etcd := certConfig("etcd", "1c7e9a2b-3d4f-4a5b-8c6d-7e8f9a0b1c2d")
+
+	k8sClient := fake.NewSimpleClientset(sourceSecret(api, caPEM), sourceSecret(etcd, etcdCAPEM))
+
+	var newResource *Resource
+	{
+		scheme := runtime.NewScheme()
+		_ = v1alpha1.AddToScheme(scheme)
+		_ = capi.AddToScheme(scheme)
+
+		c := Config{
+			CtrlClient: fakectrl.NewClientBuilder().WithScheme(scheme).WithObjects(api, etcd).Build(),
+			K8sClient:  k8sClient,
+			Logger:     microloggertest.New(),
+
+			TrustDomains: trustdomain.Mapping{"etcd": "etcd"},
+		}
+
+		var err error
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	for _, cc := range []*v1alpha1.CertConfig{api, etcd} {
+		err := newResource.EnsureCreated(context.Background(), cc)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	// Every CA secret holds the CA of the trust domain of its purpose and is
+	// only owned by the cert configs of that trust domain.
+	expected := map[string]struct {
+		ca    []byte
+		owner types.UID
+	}{
+		"al9qy-ca":    {ca: caPEM, owner: api.UID},
+		"al9qy-proxy": {ca: caPEM, owner: api.UID},
+	}
+
+	for name, e := range expected {
+		s, err := k8sClient.CoreV1().Secrets("org-giantswarm").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected secret %#q got %#v", name, err)
+		}
+		if string(s.Data[tlsCrtDataName]) != string(e.ca) {
+			t.Fatalf("expected secret %#q to contain the CA of its trust domain", name)
+		}
+		if len(s.OwnerReferences) != 1 || s.OwnerReferences[0].UID != e.owner {
+			t.Fatalf("expected secret %#q to be owned by %#q got %#v", name, e.owner, s.OwnerReferences)
+		}
+	}
+
+	// The etcd CA is merged into the certificate secret of the etcd cert
+	// config, whose certificate and owners are left untouched.
+	{
+		s, err := k8sClient.CoreV1().Secrets("org-giantswarm").Get(context.Background(), "al9qy-etcd", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected secret %#q got %#v", "al9qy-etcd", err)
+		}
+		if string(s.Data[tlsCrtDataName]) != string(etcdCAPEM) {
+			t.Fatalf("expected secret %#q to contain the etcd CA", "al9qy-etcd")
+		}
+		if string(s.Data[key.CrtID]) != "crt" || string(s.Data[key.CAID]) != string(etcdCAPEM) {
+			t.Fatalf("expected secret %#q to keep the etcd certificate got %#v", "al9qy-etcd", s.Data)
+		}
+		if len(s.OwnerReferences) != 0 {
+			t.Fatalf("expected the owners of secret %#q to be kept got %#v", "al9qy-etcd", s.OwnerReferences)
+		}
+	}
+}
+
+func Test_kubeconfigUpToDate(t *testing.T) {
+	caPEM, caKeyPEM := newCA(t)
+	otherCAPEM, _ := newCA(t)
+
+	now := time.Now()
+
+	kubeconfig, err := newKubeconfig("al9qy", "https://api.al9qy.k8s.gigantic.io:443", caPEM, caKeyPEM, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		server   string
+		ca       []byte
+		now      time.Time
+		expected bool
+	}{
+		{
+			name:     "case 0: up to date",
+			server:   "https://api.al9qy.k8s.gigantic.io:443",
+			ca:       caPEM,
+			now:      now,
+			expected: true,
+		},
+		{
+			name:     "case 1: the control plane endpoint changed",
+			server:   "https://api.al9qy.k8s.gigantic.io:6443",
+			ca:       caPEM,
+			now:      now,
+			expected: false,
+		},
+		{
+			name:     "case 2: the CA changed",
+			server:   "https://api.al9qy.k8s.gigantic.io:443",
+			ca:       otherCAPEM,
+			now:      now,
+			expected: false,
+		},
+		{
+			name:     "case 3: less than half of the lifetime is left",
+			server:   "https://api.al9qy.k8s.gigantic.io:443",
+			ca:       caPEM,
+			now:      now.Add(kubeconfigCertificateTTL / 2),
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := kubeconfigUpToDate(kubeconfig, tc.server, tc.ca, tc.now)
+			if result != tc.expected {
+				t.Fatalf("expected %t got %t", tc.expected, result)
+			}
+		})
+	}
+}
+
+func Test_publicKeyPEM(t *testing.T) {
+	_, err := publicKeyPEM([]byte("foo"))
+	if !IsInvalidPrivateKey(err) {
+		t.Fatalf("expected invalid private key error got %#v", err)
+	}
+}
+
+func newCA(t *testing.T) ([]byte, []byte) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		NotBefore:             time.Now().Add(-time.Hour),
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "al9qy.k8s.gigantic.io"},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, privateKey.Public(), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b})
+}
@@ -0,0 +1,12 @@
+package capisecret
+
+import (
+	"context"
+)
+
+// EnsureDeleted does nothing. The secrets are owned by the cert configs and
+// therefore removed by the garbage collector once all of their owners are
+// gone.
+func (r *Resource) EnsureDeleted(ctx context.Context, obj interface{}) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package capisecret
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidCertificateError = &microerror.Error{
+	Kind: "invalidCertificateError",
+}
+
+// IsInvalidCertificate asserts invalidCertificateError.
+func IsInvalidCertificate(err error) bool {
+	return microerror.Cause(err) == invalidCertificateError
+}
+
+var invalidPrivateKeyError = &microerror.Error{
+	Kind: "invalidPrivateKeyError",
+}
+
+// IsInvalidPrivateKey asserts invalidPrivateKeyError.
+func IsInvalidPrivateKey(err error) bool {
+	return microerror.Cause(err) == invalidPrivateKeyError
+}
@@ -0,0 +1,221 @@
+package capisecret
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/project"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+const (
+	// The subject of the client certificate is the one of the admin
+	// kubeconfig Cluster API generates itself.
+	kubeconfigCommonName   = "kubernetes-admin"
+	kubeconfigOrganization = "system:masters"
+
+	// kubeconfigCertificateTTL is the lifetime of the client certificate of
+	// the kubeconfig. The kubeconfig is regenerated once less than half of it
+	// remains.
+	kubeconfigCertificateTTL = 365 * 24 * time.Hour
+)
+
+// ensureKubeconfig writes the admin kubeconfig of the CAPI cluster of the
+// given cert config, whose client certificate is signed with the given CA. The
+// kubeconfig is only regenerated in case the CA or the control plane endpoint
+// changed, or its client certificate is about to expire. Clusters without CAPI
+// cluster or control plane endpoint are skipped.
+func (r *Resource) ensureKubeconfig(ctx context.Context, customObject v1alpha1.CertConfig, caCrt []byte, caKey []byte) error {
+	id := key.ClusterID(customObject)
+	name := secretName(id, purposeKubeconfig)
+
+	var server string
+	{
+		cluster := &capi.Cluster{}
+		err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: id, Namespace: customObject.GetNamespace()}, cluster)
+		if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			r.logger.LogCtx(ctx, "level", "debug", "message", "not writing the kubeconfig of a cluster without CAPI cluster")
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		endpoint := cluster.Spec.ControlPlaneEndpoint
+		if !endpoint.IsValid() {
+			r.logger.LogCtx(ctx, "level", "debug", "message", "not writing the kubeconfig of a cluster without control plane endpoint")
+			return nil
+		}
+
+		server = fmt.Sprintf("https://%s", net.JoinHostPort(endpoint.Host, strconv.Itoa(int(endpoint.Port))))
+	}
+
+	current, err := r.k8sClient.CoreV1().Secrets(customObject.GetNamespace()).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// fall through
+	} else if err != nil {
+		return microerror.Mask(err)
+	} else if current.Labels[label.ManagedBy] != project.Name() {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not updating Cluster API secret %#q managed by %#q", current.Name, current.Labels[label.ManagedBy]))
+		return nil
+	} else if kubeconfigUpToDate(current.Data[kubeconfigDataName], server, caCrt, time.Now()) {
+		// The current kubeconfig is kept, so that the secret only gets
+		// another owner.
+		desired := newSecret(customObject, name, current.Data)
+
+		err = r.ensureSecret(ctx, customObject, desired, false)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("generating the kubeconfig of Tenant Cluster %#q", id))
+
+	kubeconfig, err := newKubeconfig(id, server, caCrt, caKey, time.Now())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	desired := newSecret(customObject, name, map[string][]byte{
+		kubeconfigDataName: kubeconfig,
+	})
+
+	err = r.ensureSecret(ctx, customObject, desired, false)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// newKubeconfig returns the admin kubeconfig of the given cluster, named the
+// way Cluster API names the kubeconfigs it generates.
+func newKubeconfig(clusterName string, server string, caCrt []byte, caKey []byte, now time.Time) ([]byte, error) {
+	ca, err := parseCertificate(caCrt)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	signer, err := parsePrivateKey(caKey)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// The client certificate must not outlive the CA.
+	notAfter := now.Add(kubeconfigCertificateTTL)
+	if notAfter.After(ca.NotAfter) {
+		notAfter = ca.NotAfter
+	}
+
+	template := &x509.Certificate{
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		NotAfter:     notAfter,
+		NotBefore:    now.Add(-30 * time.Second),
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   kubeconfigCommonName,
+			Organization: []string{kubeconfigOrganization},
+		},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, privateKey.Public(), signer)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	userName := fmt.Sprintf("%s-admin", clusterName)
+	contextName := fmt.Sprintf("%s@%s", userName, clusterName)
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters[clusterName] = &clientcmdapi.Cluster{
+		CertificateAuthorityData: caCrt,
+		Server:                   server,
+	}
+	config.AuthInfos[userName] = &clientcmdapi.AuthInfo{
+		ClientCertificateData: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		ClientKeyData:         pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}),
+	}
+	config.Contexts[contextName] = &clientcmdapi.Context{
+		AuthInfo: userName,
+		Cluster:  clusterName,
+	}
+	config.CurrentContext = contextName
+
+	b, err := clientcmd.Write(*config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return b, nil
+}
+
+// kubeconfigUpToDate returns whether the given kubeconfig points to the given
+// server, trusts the given CA and has a client certificate signed by it which
+// has more than half of its lifetime left.
+func kubeconfigUpToDate(kubeconfig []byte, server string, caCrt []byte, now time.Time) bool {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return false
+	}
+
+	c, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return false
+	}
+	cluster, ok := config.Clusters[c.Cluster]
+	if !ok || cluster.Server != server || !bytes.Equal(cluster.CertificateAuthorityData, caCrt) {
+		return false
+	}
+	authInfo, ok := config.AuthInfos[c.AuthInfo]
+	if !ok {
+		return false
+	}
+
+	crt, err := parseCertificate(authInfo.ClientCertificateData)
+	if err != nil {
+		return false
+	}
+	ca, err := parseCertificate(caCrt)
+	if err != nil {
+		return false
+	}
+	if crt.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+
+	halfLife := crt.NotAfter.Sub(crt.NotBefore) / 2
+
+	return now.Before(crt.NotAfter.Add(-halfLife))
+}
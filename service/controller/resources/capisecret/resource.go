@@ -0,0 +1,92 @@
+package capisecret
+
+import (
+	"github.com/giantswarm/certs/v4/pkg/certs"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+)
+
+const (
+	Name = "capisecret"
+)
+
+// The secret name suffixes and data keys below are the ones of Cluster API's
+// util/secret package, which we do not import in order to not pull in the
+// dependencies of the kubeadm bootstrap provider.
+const (
+	purposeClusterCA      = "ca"
+	purposeEtcdCA         = "etcd"
+	purposeFrontProxyCA   = "proxy"
+	purposeServiceAccount = "sa"
+	purposeKubeconfig     = "kubeconfig"
+
+	kubeconfigDataName = "value"
+	tlsCrtDataName     = "tls.crt"
+	tlsKeyDataName     = "tls.key"
+)
+
+// purposeComponents maps the purposes of the CA secrets to the cluster
+// components whose trust domain issues the certificates of the purpose. The
+// front proxy is not a cert config known to cert-operator, its trust domain is
+// the one the aggregator component is mapped to.
+var purposeComponents = map[string]string{
+	purposeClusterCA:    certs.APICert.String(),
+	purposeEtcdCA:       certs.EtcdCert.String(),
+	purposeFrontProxyCA: "aggregator",
+}
+
+type Config struct {
+	CtrlClient client.Client
+	K8sClient  kubernetes.Interface
+	Logger     micrologger.Logger
+
+	// TrustDomains maps cluster components to the trust domains whose PKI
+	// backend issues their certificates.
+	TrustDomains trustdomain.Mapping
+}
+
+// Resource writes the certificate material of tenant clusters into secrets
+// named and laid out the way Cluster API expects them, so that Cluster API
+// controllers can consume the PKI of clusters backed by Vault. The CA secrets
+// contain the private key of the CA in case it got exported from Vault when
+// the CA was created, see vaultpki.Config.ExportCAKeys. Otherwise they only
+// contain the CA certificate, which is what Cluster API calls an external CA.
+// With the private key of the CA at hand, the admin kubeconfig of the cluster
+// is written as well.
+type Resource struct {
+	ctrlClient client.Client
+	k8sClient  kubernetes.Interface
+	logger     micrologger.Logger
+
+	trustDomains trustdomain.Mapping
+}
+
+func New(config Config) (*Resource, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	r := &Resource{
+		ctrlClient: config.CtrlClient,
+		k8sClient:  config.K8sClient,
+		logger:     config.Logger,
+
+		trustDomains: config.TrustDomains,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
@@ -0,0 +1,226 @@
+package certconfig
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/project"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	cluster, err := key.ToCluster(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	id := key.TenantClusterID(cluster)
+	desired := r.desiredCertConfigs(cluster)
+
+	for i := range desired {
+		err := r.ensureCertConfig(ctx, &desired[i])
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// CertConfigs of certs which got removed from the template are deleted.
+	// Only the CertConfigs created by this resource are considered.
+	{
+		list := &v1alpha1.CertConfigList{}
+		err := r.ctrlClient.List(ctx, list, client.InNamespace(cluster.GetNamespace()), client.MatchingLabels{label.Cluster: id, label.ManagedBy: project.Name()})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for i := range list.Items {
+			cc := &list.Items[i]
+			if containsCertConfig(desired, cc.GetName()) || cc.GetDeletionTimestamp() != nil {
+				continue
+			}
+
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleting cert config %#q not given in the template anymore", cc.GetName()))
+
+			err := r.ctrlClient.Delete(ctx, cc)
+			if apierrors.IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted cert config %#q", cc.GetName()))
+		}
+	}
+
+	return nil
+}
+
+func (r *Resource) ensureCertConfig(ctx context.Context, desired *v1alpha1.CertConfig) error {
+	current := &v1alpha1.CertConfig{}
+	err := r.ctrlClient.Get(ctx, client.ObjectKeyFromObject(desired), current)
+	if apierrors.IsNotFound(err) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("creating cert config %#q", desired.GetName()))
+
+		err = r.ctrlClient.Create(ctx, desired)
+		if apierrors.IsAlreadyExists(err) {
+			// The cert config got created in the meantime and is brought in
+			// sync with the next reconciliation.
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("created cert config %#q", desired.GetName()))
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Cert configs created by someone else, e.g. cluster-operator, are left
+	// untouched in order to not fight over them.
+	if current.GetLabels()[label.ManagedBy] != project.Name() {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not updating cert config %#q managed by %#q", current.GetName(), current.GetLabels()[label.ManagedBy]))
+		return nil
+	}
+
+	if !shouldCertConfigBeUpdated(*current, *desired) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("cert config %#q is up to date", current.GetName()))
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("updating cert config %#q", current.GetName()))
+
+	update := current.DeepCopy()
+	update.Spec = desired.Spec
+	update.SetOwnerReferences(desired.GetOwnerReferences())
+	if update.Labels == nil {
+		update.Labels = map[string]string{}
+	}
+	for k, v := range desired.GetLabels() {
+		update.Labels[k] = v
+	}
+
+	err = r.ctrlClient.Update(ctx, update)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("updated cert config %#q", current.GetName()))
+
+	return nil
+}
+
+// desiredCertConfigs renders the template for the given cluster.
+func (r *Resource) desiredCertConfigs(cluster capi.Cluster) []v1alpha1.CertConfig {
+	id := key.TenantClusterID(cluster)
+	owner := *metav1.NewControllerRef(&cluster, capi.GroupVersion.WithKind("Cluster"))
+	host := cluster.Spec.ControlPlaneEndpoint.Host
+
+	var certConfigs []v1alpha1.CertConfig
+	for _, t := range r.template {
+		altNames := copyStrings(t.AltNames)
+		ipSANs := copyStrings(t.IPSANs)
+		if t.APIEndpointSANs && host != "" {
+			if net.ParseIP(host) != nil {
+				ipSANs = appendMissing(ipSANs, host)
+			} else {
+				altNames = appendMissing(altNames, host)
+			}
+		}
+
+		cc := v1alpha1.CertConfig{
+			TypeMeta: v1alpha1.NewCertConfigTypeMeta(),
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s", id, t.Cert),
+				Namespace: cluster.GetNamespace(),
+				Labels: map[string]string{
+					label.Certificate:     t.Cert,
+					label.Cluster:         id,
+					label.ManagedBy:       project.Name(),
+					label.OperatorVersion: r.operatorVersion,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					owner,
+				},
+			},
+			Spec: v1alpha1.CertConfigSpec{
+				Cert: v1alpha1.CertConfigSpecCert{
+					AllowBareDomains:    t.AllowBareDomains,
+					AltNames:            altNames,
+					ClusterComponent:    t.Cert,
+					ClusterID:           id,
+					CommonName:          fmt.Sprintf("%s.%s", t.Cert, fmt.Sprintf(r.commonNameFormat, id)),
+					DisableRegeneration: t.DisableRegeneration,
+					IPSANs:              ipSANs,
+					Organizations:       copyStrings(t.Organizations),
+					TTL:                 t.TTL,
+				},
+				VersionBundle: v1alpha1.CertConfigSpecVersionBundle{
+					Version: project.Version(),
+				},
+			},
+		}
+
+		certConfigs = append(certConfigs, cc)
+	}
+
+	return certConfigs
+}
+
+func shouldCertConfigBeUpdated(current, desired v1alpha1.CertConfig) bool {
+	if !reflect.DeepEqual(current.Spec, desired.Spec) {
+		return true
+	}
+	if !reflect.DeepEqual(current.GetOwnerReferences(), desired.GetOwnerReferences()) {
+		return true
+	}
+	for k, v := range desired.GetLabels() {
+		if current.GetLabels()[k] != v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copyStrings copies the given list. Empty lists result in nil, which is what
+// the cert configs read from the API contain for empty lists, so that they do
+// not look like having changed.
+func copyStrings(l []string) []string {
+	if len(l) == 0 {
+		return nil
+	}
+
+	return append([]string{}, l...)
+}
+
+func appendMissing(l []string, s string) []string {
+	for _, e := range l {
+		if e == s {
+			return l
+		}
+	}
+
+	return append(l, s)
+}
+
+func containsCertConfig(l []v1alpha1.CertConfig, name string) bool {
+	for _, cc := range l {
+		if cc.GetName() == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,188 @@
+package certconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/project"
+)
+
+func Test_Resource_CertConfig_EnsureCreated(t *testing.T) {
+	cluster := &capi.Cluster{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "al9qy",
+			Namespace: "org-giantswarm",
+			UID:       "f1a0e2f6-9c4b-4d6a-8f0e-2b7c1b0a6d3e",
+		},
+		Spec: capi.ClusterSpec{
+			ControlPlaneEndpoint: capi.APIEndpoint{
+				Host: "api.al9qy.k8s.gigantic.io",
+				Port: 443,
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = capi.AddToScheme(scheme)
+
+	ctrlClient := fakectrl.NewClientBuilder().WithScheme(scheme).WithObjects(
+		// Cert config created by cluster-operator, which must be left
+		// untouched.
+		&v1alpha1.CertConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      "al9qy-etcd",
+				Namespace: "org-giantswarm",
+				Labels: map[string]string{
+					label.Cluster:   "al9qy",
+					label.ManagedBy: "cluster-operator",
+				},
+			},
+			Spec: v1alpha1.CertConfigSpec{
+				Cert: v1alpha1.CertConfigSpecCert{
+					TTL: "720h",
+				},
+			},
+		},
+		// Cert config created by cert-operator for a cert which is not given
+		// in the template anymore.
+		&v1alpha1.CertConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      "al9qy-prometheus",
+				Namespace: "org-giantswarm",
+				Labels: map[string]string{
+					label.Cluster:   "al9qy",
+					label.ManagedBy: project.Name(),
+				},
+			},
+		},
+	).Build()
+
+	newResource := func(template string) *Resource {
+		c := Config{
+			CtrlClient: ctrlClient,
+			Logger:     microloggertest.New(),
+
+			CommonNameFormat: "%s.k8s.gigantic.io",
+			OperatorVersion:  project.ManagementClusterAppVersion(),
+			Template:         template,
+		}
+
+		r, err := New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+
+		return r
+	}
+
+	getCertConfig := func(name string) (*v1alpha1.CertConfig, error) {
+		cc := &v1alpha1.CertConfig{}
+		err := ctrlClient.Get(context.Background(), types.NamespacedName{Namespace: "org-giantswarm", Name: name}, cc)
+		return cc, err
+	}
+
+	// Test 0 ensures the cert configs given in the template are created, cert
+	// configs managed by others are left untouched and stale cert configs are
+	// deleted.
+	{
+		template := `
+- cert: api
+  allowBareDomains: true
+  altNames:
+  - kubernetes
+  apiEndpointSANs: true
+  organizations:
+  - system:masters
+  ttl: 4320h
+- cert: etcd
+  ttl: 4320h
+`
+		err := newResource(template).EnsureCreated(context.Background(), cluster)
+		if err != nil {
+			t.Fatal("test 0 expected", nil, "got", err)
+		}
+
+		api, err := getCertConfig("al9qy-api")
+		if err != nil {
+			t.Fatal("test 0 expected", nil, "got", err)
+		}
+
+		expectedSpec := v1alpha1.CertConfigSpecCert{
+			AllowBareDomains: true,
+			AltNames:         []string{"kubernetes", "api.al9qy.k8s.gigantic.io"},
+			ClusterComponent: "api",
+			ClusterID:        "al9qy",
+			CommonName:       "api.al9qy.k8s.gigantic.io",
+			Organizations:    []string{"system:masters"},
+			TTL:              "4320h",
+		}
+		if !reflect.DeepEqual(api.Spec.Cert, expectedSpec) {
+			t.Fatalf("test 0 expected %#v got %#v", expectedSpec, api.Spec.Cert)
+		}
+		if api.Labels[label.OperatorVersion] != project.ManagementClusterAppVersion() {
+			t.Fatalf("test 0 expected version label %#q got %#q", project.ManagementClusterAppVersion(), api.Labels[label.OperatorVersion])
+		}
+		if len(api.OwnerReferences) != 1 || api.OwnerReferences[0].UID != cluster.UID {
+			t.Fatalf("test 0 expected the cluster as owner got %#v", api.OwnerReferences)
+		}
+
+		etcd, err := getCertConfig("al9qy-etcd")
+		if err != nil {
+			t.Fatal("test 0 expected", nil, "got", err)
+		}
+		if etcd.Spec.Cert.TTL != "720h" || etcd.Labels[label.ManagedBy] != "cluster-operator" {
+			t.Fatalf("test 0 expected cert config managed by cluster-operator to be untouched got %#v", etcd)
+		}
+
+		_, err = getCertConfig("al9qy-prometheus")
+		if !apierrors.IsNotFound(err) {
+			t.Fatal("test 0 expected stale cert config to be deleted got", err)
+		}
+	}
+
+	// Test 1 ensures the cert configs are updated when the template changes.
+	{
+		template := `
+- cert: api
+  allowBareDomains: true
+  altNames:
+  - kubernetes
+  apiEndpointSANs: true
+  ttl: 720h
+`
+		err := newResource(template).EnsureCreated(context.Background(), cluster)
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+
+		api, err := getCertConfig("al9qy-api")
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+		if api.Spec.Cert.TTL != "720h" || api.Spec.Cert.Organizations != nil {
+			t.Fatalf("test 1 expected updated cert config got %#v", api.Spec.Cert)
+		}
+
+		list := &v1alpha1.CertConfigList{}
+		err = ctrlClient.List(context.Background(), list, client.InNamespace("org-giantswarm"))
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+		if len(list.Items) != 2 {
+			t.Fatalf("test 1 expected %d cert configs got %d", 2, len(list.Items))
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package certconfig
+
+import (
+	"context"
+)
+
+// EnsureDeleted does nothing. The CertConfigs are owned by the cluster and
+// therefore removed by the garbage collector, or earlier by the clusterpki
+// resource in case the PKI teardown is enabled.
+func (r *Resource) EnsureDeleted(ctx context.Context, obj interface{}) error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package certconfig
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	Name = "certconfig"
+)
+
+type Config struct {
+	CtrlClient client.Client
+	Logger     micrologger.Logger
+
+	// CommonNameFormat is the format of the Vault PKI common name of tenant
+	// clusters, e.g. "%s.k8s.gigantic.io". The common names of the
+	// certificates are made up of the cluster component and the common name
+	// of their tenant cluster.
+	CommonNameFormat string
+	// OperatorVersion is the value of the operator version label of the
+	// created CertConfigs. It must match the selector of the CertConfig
+	// controller, so that the CertConfigs get reconciled.
+	OperatorVersion string
+	// Template is the YAML list of TemplateCerts to create CertConfigs for.
+	Template string
+}
+
+// Resource creates the CertConfigs described by the configured template for
+// every CAPI cluster and keeps them in sync with the template and the
+// cluster's control plane endpoint.
+type Resource struct {
+	ctrlClient client.Client
+	logger     micrologger.Logger
+
+	commonNameFormat string
+	operatorVersion  string
+	template         []TemplateCert
+}
+
+func New(config Config) (*Resource, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.CommonNameFormat == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CommonNameFormat must not be empty", config)
+	}
+	if config.OperatorVersion == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.OperatorVersion must not be empty", config)
+	}
+
+	template, err := ParseTemplate(config.Template)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r := &Resource{
+		ctrlClient: config.CtrlClient,
+		logger:     config.Logger,
+
+		commonNameFormat: config.CommonNameFormat,
+		operatorVersion:  config.OperatorVersion,
+		template:         template,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
@@ -0,0 +1,63 @@
+package certconfig
+
+import (
+	"time"
+
+	"github.com/giantswarm/certs/v4/pkg/certs"
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+)
+
+// TemplateCert describes a CertConfig created for every CAPI cluster.
+type TemplateCert struct {
+	AllowBareDomains bool     `json:"allowBareDomains"`
+	AltNames         []string `json:"altNames"`
+	// APIEndpointSANs adds the host of the cluster's control plane endpoint
+	// to the SANs of the certificate, either as DNS or as IP SAN.
+	APIEndpointSANs bool `json:"apiEndpointSANs"`
+	// Cert is the cluster component to issue the certificate for. It must be
+	// one of certs.AllCerts.
+	Cert                string   `json:"cert"`
+	DisableRegeneration bool     `json:"disableRegeneration"`
+	IPSANs              []string `json:"ipSans"`
+	Organizations       []string `json:"organizations"`
+	TTL                 string   `json:"ttl"`
+}
+
+// ParseTemplate parses the given YAML or JSON list of TemplateCerts and
+// validates it.
+func ParseTemplate(s string) ([]TemplateCert, error) {
+	var template []TemplateCert
+	err := yaml.UnmarshalStrict([]byte(s), &template)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "template must be a list of certs: %s", err)
+	}
+
+	seen := map[string]bool{}
+	for _, t := range template {
+		if !isKnownCert(t.Cert) {
+			return nil, microerror.Maskf(invalidConfigError, "template cert %#q must be one of %v", t.Cert, certs.AllCerts)
+		}
+		if seen[t.Cert] {
+			return nil, microerror.Maskf(invalidConfigError, "template cert %#q must not be given twice", t.Cert)
+		}
+		seen[t.Cert] = true
+
+		d, err := time.ParseDuration(t.TTL)
+		if err != nil || d <= 0 {
+			return nil, microerror.Maskf(invalidConfigError, "template cert %#q must have a positive TTL", t.Cert)
+		}
+	}
+
+	return template, nil
+}
+
+func isKnownCert(name string) bool {
+	for _, c := range certs.AllCerts {
+		if c.String() == name {
+			return true
+		}
+	}
+
+	return false
+}
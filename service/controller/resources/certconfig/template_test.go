@@ -0,0 +1,76 @@
+package certconfig
+
+import (
+	"testing"
+)
+
+func Test_ParseTemplate(t *testing.T) {
+	testCases := []struct {
+		name         string
+		template     string
+		expectedLen  int
+		errorMatcher func(error) bool
+	}{
+		{
+			name:        "case 0: empty template",
+			template:    "[]",
+			expectedLen: 0,
+		},
+		{
+			name: "case 1: valid YAML template",
+			template: `
+- cert: api
+  apiEndpointSANs: true
+  altNames:
+  - kubernetes
+  ttl: 4320h
+- cert: etcd
+  ttl: 720h
+`,
+			expectedLen: 2,
+		},
+		{
+			name:        "case 2: valid JSON template",
+			template:    `[{"cert":"worker","organizations":["system:nodes"],"ttl":"4320h"}]`,
+			expectedLen: 1,
+		},
+		{
+			name:         "case 3: unknown cert",
+			template:     `[{"cert":"foo","ttl":"4320h"}]`,
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 4: duplicate cert",
+			template:     `[{"cert":"api","ttl":"4320h"},{"cert":"api","ttl":"720h"}]`,
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 5: invalid TTL",
+			template:     `[{"cert":"api","ttl":"180d"}]`,
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 6: unknown field",
+			template:     `[{"cert":"api","ttl":"4320h","commonName":"api.example.com"}]`,
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			template, err := ParseTemplate(tc.template)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected error matcher to match, got %#v", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("expected nil error, got %#v", err)
+			}
+
+			if len(template) != tc.expectedLen {
+				t.Fatalf("expected %d certs, got %d", tc.expectedLen, len(template))
+			}
+		})
+	}
+}
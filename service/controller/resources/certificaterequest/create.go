@@ -0,0 +1,160 @@
+package certificaterequest
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/apis/certoperator/v1alpha1"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultsign"
+)
+
+const (
+	// defaultDuration is the duration of certificates neither the
+	// CertificateRequest nor the ClusterPKIIssuer specify a duration for. It
+	// matches the default of cert-manager.
+	defaultDuration = 90 * 24 * time.Hour
+)
+
+func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	cr, err := toCertificateRequest(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	group, kind, name := issuerRef(cr)
+	if group != v1alpha1.SchemeGroupVersion.Group || kind != v1alpha1.NewClusterPKIIssuerTypeMeta().Kind {
+		return nil
+	}
+
+	if isFinished(cr) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the certificate request is already finished")
+		return nil
+	}
+	if hasCondition(cr, conditionDenied, metav1.ConditionTrue) {
+		return r.updateReadyCondition(ctx, cr, metav1.ConditionFalse, reasonDenied, "The certificate request has been denied.")
+	}
+	if !hasCondition(cr, conditionApproved, metav1.ConditionTrue) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the certificate request is not approved yet")
+		return nil
+	}
+
+	issuer := &v1alpha1.ClusterPKIIssuer{}
+	{
+		err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: name}, issuer)
+		if apierrors.IsNotFound(err) {
+			return r.updateReadyCondition(ctx, cr, metav1.ConditionFalse, reasonPending, fmt.Sprintf("ClusterPKIIssuer %#q not found.", name))
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	id := issuer.Spec.ClusterID
+	{
+		exists, err := r.vaultPKI.BackendExists(id)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if !exists {
+			return r.updateReadyCondition(ctx, cr, metav1.ConditionFalse, reasonPending, fmt.Sprintf("Vault PKI backend of cluster %#q not found.", id))
+		}
+	}
+
+	if isCA(cr) {
+		return r.updateReadyCondition(ctx, cr, metav1.ConditionFalse, reasonFailed, "ClusterPKIIssuer does not issue CA certificates.")
+	}
+
+	csr, csrPEM, err := certificateSigningRequest(cr)
+	if IsInvalidRequest(err) {
+		return r.updateReadyCondition(ctx, cr, metav1.ConditionFalse, reasonFailed, microerror.Pretty(err, false))
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	ttl, err := duration(cr)
+	if IsInvalidRequest(err) {
+		return r.updateReadyCondition(ctx, cr, metav1.ConditionFalse, reasonFailed, microerror.Pretty(err, false))
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+	if ttl == 0 && issuer.Spec.Duration != nil {
+		ttl = issuer.Spec.Duration.Duration
+	}
+	if ttl == 0 {
+		ttl = defaultDuration
+	}
+
+	var ipSANs []string
+	for _, ip := range csr.IPAddresses {
+		ipSANs = append(ipSANs, ip.String())
+	}
+
+	names := csr.DNSNames
+	if csr.Subject.CommonName != "" {
+		names = append([]string{csr.Subject.CommonName}, names...)
+	}
+
+	if l := disallowedNames(r.allowedDomains(issuer), names); len(l) != 0 {
+		return r.updateReadyCondition(ctx, cr, metav1.ConditionFalse, reasonFailed, fmt.Sprintf("ClusterPKIIssuer %#q does not allow the names %s.", name, strings.Join(l, ", ")))
+	}
+	if l := disallowedOrganizations(issuer.Spec.Organizations, csr.Subject.Organization); len(l) != 0 {
+		return r.updateReadyCondition(ctx, cr, metav1.ConditionFalse, reasonFailed, fmt.Sprintf("ClusterPKIIssuer %#q does not allow the organizations %s.", name, strings.Join(l, ", ")))
+	}
+
+	err = r.ensureRole(ctx, issuer)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("signing the certificate request with the Vault PKI backend of cluster %#q", id))
+
+	c := vaultsign.SignConfig{
+		AltNames:   csr.DNSNames,
+		CommonName: csr.Subject.CommonName,
+		CSR:        csrPEM,
+		ID:         id,
+		IPSANs:     ipSANs,
+		Role:       roleName(issuer),
+		TTL:        ttl.String(),
+	}
+	result, err := r.vaultSign.Sign(c)
+	if vaultsign.IsRejected(err) {
+		return r.updateReadyCondition(ctx, cr, metav1.ConditionFalse, reasonFailed, fmt.Sprintf("Vault rejected the certificate request: %s", err))
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("signed the certificate request with serial number %#q", result.SerialNumber))
+
+	_ = unstructured.SetNestedField(cr.Object, base64.StdEncoding.EncodeToString([]byte(result.Crt)), "status", "certificate")
+	_ = unstructured.SetNestedField(cr.Object, base64.StdEncoding.EncodeToString([]byte(result.CA)), "status", "ca")
+
+	return r.updateReadyCondition(ctx, cr, metav1.ConditionTrue, reasonIssued, "Certificate fetched from issuer successfully")
+}
+
+func (r *Resource) updateReadyCondition(ctx context.Context, cr *unstructured.Unstructured, status metav1.ConditionStatus, reason, message string) error {
+	changed := setReadyCondition(cr, status, reason, message, time.Now())
+	if !changed {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the certificate request status does not need to be updated")
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("updating the certificate request status to %s: %s", reason, message))
+
+	err := r.ctrlClient.Status().Update(ctx, cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "updated the certificate request status")
+
+	return nil
+}
@@ -0,0 +1,278 @@
+package certificaterequest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/vaultpki/vaultpkitest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/pkg/apis/certoperator/v1alpha1"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultsign"
+)
+
+type fakeVaultPKI struct {
+	*vaultpkitest.VaultPKITest
+}
+
+func (p *fakeVaultPKI) BackendExists(ID string) (bool, error) {
+	return ID == "al9qy", nil
+}
+
+type fakeVaultSign struct {
+	roles  []vaultsign.RoleConfig
+	signed []vaultsign.SignConfig
+}
+
+func (s *fakeVaultSign) EnsureRole(config vaultsign.RoleConfig) error {
+	s.roles = append(s.roles, config)
+	return nil
+}
+
+func (s *fakeVaultSign) Sign(config vaultsign.SignConfig) (vaultsign.SignResult, error) {
+	s.signed = append(s.signed, config)
+
+	result := vaultsign.SignResult{
+		CA:           "ca",
+		Crt:          "crt",
+		SerialNumber: "01:02",
+	}
+
+	return result, nil
+}
+
+func Test_Resource_CertificateRequest_EnsureCreated(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var request string
+	{
+		template := &x509.CertificateRequest{
+			Subject:  pkix.Name{CommonName: "ingress.al9qy.k8s.gigantic.io", Organization: []string{"giantswarm"}},
+			DNSNames: []string{"ingress.al9qy.k8s.gigantic.io", "hello.example.com"},
+		}
+		b, err := x509.CreateCertificateRequest(rand.Reader, template, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request = base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: b}))
+	}
+
+	newCertificateRequest := func(name, issuerKind, issuerName string, isCA bool, conditions ...string) *unstructured.Unstructured {
+		cr := &unstructured.Unstructured{}
+		cr.SetGroupVersionKind(GroupVersionKind)
+		cr.SetName(name)
+		cr.SetNamespace("default")
+
+		_ = unstructured.SetNestedMap(cr.Object, map[string]interface{}{
+			"group": v1alpha1.SchemeGroupVersion.Group,
+			"kind":  issuerKind,
+			"name":  issuerName,
+		}, "spec", "issuerRef")
+		_ = unstructured.SetNestedField(cr.Object, request, "spec", "request")
+		_ = unstructured.SetNestedField(cr.Object, isCA, "spec", "isCA")
+
+		var list []interface{}
+		for _, c := range conditions {
+			list = append(list, map[string]interface{}{"type": c, "status": "True"})
+		}
+		if len(list) != 0 {
+			_ = unstructured.SetNestedSlice(cr.Object, list, "status", "conditions")
+		}
+
+		return cr
+	}
+
+	testCases := []struct {
+		name               string
+		certificateRequest *unstructured.Unstructured
+		expectedReason     string
+		expectedSigned     bool
+	}{
+		{
+			name:               "case 0: requests of other issuers are ignored",
+			certificateRequest: newCertificateRequest("other", "Issuer", "al9qy", false, conditionApproved),
+			expectedReason:     "",
+		},
+		{
+			name:               "case 1: requests which are not approved yet are not signed",
+			certificateRequest: newCertificateRequest("pending", "ClusterPKIIssuer", "al9qy", false),
+			expectedReason:     "",
+		},
+		{
+			name:               "case 2: denied requests are marked as denied",
+			certificateRequest: newCertificateRequest("denied", "ClusterPKIIssuer", "al9qy", false, conditionDenied),
+			expectedReason:     reasonDenied,
+		},
+		{
+			name:               "case 3: requests of missing issuers are pending",
+			certificateRequest: newCertificateRequest("missing", "ClusterPKIIssuer", "p1j4w", false, conditionApproved),
+			expectedReason:     reasonPending,
+		},
+		{
+			name:               "case 4: requests of issuers without PKI backend are pending",
+			certificateRequest: newCertificateRequest("backend", "ClusterPKIIssuer", "p1j4w-issuer", false, conditionApproved),
+			expectedReason:     reasonPending,
+		},
+		{
+			name:               "case 5: CA requests fail",
+			certificateRequest: newCertificateRequest("ca", "ClusterPKIIssuer", "al9qy", true, conditionApproved),
+			expectedReason:     reasonFailed,
+		},
+		{
+			name:               "case 6: requests for names the issuer does not allow fail",
+			certificateRequest: newCertificateRequest("names", "ClusterPKIIssuer", "al9qy-default", false, conditionApproved),
+			expectedReason:     reasonFailed,
+		},
+		{
+			name:               "case 7: requests for organizations the issuer does not allow fail",
+			certificateRequest: newCertificateRequest("organizations", "ClusterPKIIssuer", "al9qy-masters", false, conditionApproved),
+			expectedReason:     reasonFailed,
+		},
+		{
+			name:               "case 8: approved requests are signed",
+			certificateRequest: newCertificateRequest("approved", "ClusterPKIIssuer", "al9qy", false, conditionApproved),
+			expectedReason:     reasonIssued,
+			expectedSigned:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = v1alpha1.AddToScheme(scheme)
+			scheme.AddKnownTypeWithName(GroupVersionKind, &unstructured.Unstructured{})
+
+			issuers := []*v1alpha1.ClusterPKIIssuer{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "al9qy"},
+					Spec: v1alpha1.ClusterPKIIssuerSpec{
+						AllowedDomains: []string{"al9qy.k8s.gigantic.io", "example.com"},
+						ClusterID:      "al9qy",
+						Duration:       &metav1.Duration{Duration: 24 * time.Hour},
+						Organizations:  []string{"giantswarm"},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "al9qy-default"},
+					Spec:       v1alpha1.ClusterPKIIssuerSpec{ClusterID: "al9qy"},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "al9qy-masters"},
+					Spec: v1alpha1.ClusterPKIIssuerSpec{
+						AllowedDomains: []string{"al9qy.k8s.gigantic.io", "example.com"},
+						ClusterID:      "al9qy",
+						Organizations:  []string{"system:masters"},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "p1j4w-issuer"},
+					Spec:       v1alpha1.ClusterPKIIssuerSpec{ClusterID: "p1j4w"},
+				},
+			}
+
+			ctrlClient := fakectrl.NewClientBuilder().WithScheme(scheme).WithObjects(issuers[0], issuers[1], issuers[2], issuers[3], tc.certificateRequest).Build()
+			vaultSign := &fakeVaultSign{}
+
+			var newResource *Resource
+			{
+				c := Config{
+					CtrlClient: ctrlClient,
+					Logger:     microloggertest.New(),
+					VaultPKI:   &fakeVaultPKI{VaultPKITest: vaultpkitest.New()},
+					VaultSign:  vaultSign,
+
+					CommonNameFormat: "%s.k8s.gigantic.io",
+				}
+
+				newResource, err = New(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cr := &unstructured.Unstructured{}
+			{
+				cr.SetGroupVersionKind(GroupVersionKind)
+				err = ctrlClient.Get(context.Background(), types.NamespacedName{Name: tc.certificateRequest.GetName(), Namespace: "default"}, cr)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err = newResource.EnsureCreated(context.Background(), cr)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			updated := &unstructured.Unstructured{}
+			{
+				updated.SetGroupVersionKind(GroupVersionKind)
+				err = ctrlClient.Get(context.Background(), types.NamespacedName{Name: tc.certificateRequest.GetName(), Namespace: "default"}, updated)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var reason string
+			if c := findCondition(updated, conditionReady); c != nil {
+				reason, _ = c["reason"].(string)
+			}
+			if reason != tc.expectedReason {
+				t.Fatalf("expected Ready condition reason %#q got %#q", tc.expectedReason, reason)
+			}
+
+			if !tc.expectedSigned {
+				if len(vaultSign.roles) != 0 {
+					t.Fatalf("expected %d written roles got %d", 0, len(vaultSign.roles))
+				}
+				if len(vaultSign.signed) != 0 {
+					t.Fatalf("expected %d signed requests got %d", 0, len(vaultSign.signed))
+				}
+				return
+			}
+
+			if len(vaultSign.signed) != 1 {
+				t.Fatalf("expected %d signed requests got %d", 1, len(vaultSign.signed))
+			}
+			if vaultSign.signed[0].TTL != "24h0m0s" {
+				t.Fatalf("expected TTL %#q got %#q", "24h0m0s", vaultSign.signed[0].TTL)
+			}
+			crt, _, _ := unstructured.NestedString(updated.Object, "status", "certificate")
+			if crt != base64.StdEncoding.EncodeToString([]byte("crt")) {
+				t.Fatalf("expected certificate %#q got %#q", base64.StdEncoding.EncodeToString([]byte("crt")), crt)
+			}
+
+			// The request is signed with the role of the issuer, which only
+			// allows the domains and organizations of the issuer.
+			expectedRole := vaultsign.RoleConfig{
+				AllowedDomains: []string{"al9qy.k8s.gigantic.io", "example.com"},
+				ID:             "al9qy",
+				Name:           "issuer-al9qy",
+				Organizations:  []string{"giantswarm"},
+			}
+			if len(vaultSign.roles) != 1 || !reflect.DeepEqual(vaultSign.roles[0], expectedRole) {
+				t.Fatalf("expected role %#v got %#v", expectedRole, vaultSign.roles)
+			}
+			if vaultSign.signed[0].Role != expectedRole.Name {
+				t.Fatalf("expected role %#q got %#q", expectedRole.Name, vaultSign.signed[0].Role)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package certificaterequest
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// The condition types and reasons below are the ones of cert-manager's
+// CertificateRequest API.
+const (
+	conditionApproved = "Approved"
+	conditionDenied   = "Denied"
+	conditionReady    = "Ready"
+
+	reasonDenied  = "Denied"
+	reasonFailed  = "Failed"
+	reasonIssued  = "Issued"
+	reasonPending = "Pending"
+)
+
+func toCertificateRequest(v interface{}) (*unstructured.Unstructured, error) {
+	cr, ok := v.(*unstructured.Unstructured)
+	if !ok {
+		return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", &unstructured.Unstructured{}, v)
+	}
+
+	return cr, nil
+}
+
+func issuerRef(cr *unstructured.Unstructured) (group, kind, name string) {
+	group, _, _ = unstructured.NestedString(cr.Object, "spec", "issuerRef", "group")
+	kind, _, _ = unstructured.NestedString(cr.Object, "spec", "issuerRef", "kind")
+	name, _, _ = unstructured.NestedString(cr.Object, "spec", "issuerRef", "name")
+	return group, kind, name
+}
+
+func isCA(cr *unstructured.Unstructured) bool {
+	v, _, _ := unstructured.NestedBool(cr.Object, "spec", "isCA")
+	return v
+}
+
+// duration returns the requested duration of the certificate, if any.
+func duration(cr *unstructured.Unstructured) (time.Duration, error) {
+	s, ok, _ := unstructured.NestedString(cr.Object, "spec", "duration")
+	if !ok || s == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, microerror.Maskf(invalidRequestError, "spec.duration must be a duration: %s", err)
+	}
+
+	return d, nil
+}
+
+// certificateSigningRequest parses the base64 encoded PEM certificate
+// signing request of the given CertificateRequest and checks its signature.
+func certificateSigningRequest(cr *unstructured.Unstructured) (*x509.CertificateRequest, string, error) {
+	s, _, _ := unstructured.NestedString(cr.Object, "spec", "request")
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, "", microerror.Maskf(invalidRequestError, "spec.request must be base64 encoded")
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, "", microerror.Maskf(invalidRequestError, "spec.request must contain a PEM encoded certificate request")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, "", microerror.Maskf(invalidRequestError, "spec.request must contain a valid certificate request: %s", err)
+	}
+	err = csr.CheckSignature()
+	if err != nil {
+		return nil, "", microerror.Maskf(invalidRequestError, "spec.request must be signed by its private key: %s", err)
+	}
+
+	return csr, string(b), nil
+}
+
+func findCondition(cr *unstructured.Unstructured, conditionType string) map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(cr.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if ok && m["type"] == conditionType {
+			return m
+		}
+	}
+
+	return nil
+}
+
+func hasCondition(cr *unstructured.Unstructured, conditionType string, status metav1.ConditionStatus) bool {
+	c := findCondition(cr, conditionType)
+	return c != nil && c["status"] == string(status)
+}
+
+// isFinished returns true in case the CertificateRequest got already issued,
+// failed or denied. cert-manager creates a new CertificateRequest to retry.
+func isFinished(cr *unstructured.Unstructured) bool {
+	c := findCondition(cr, conditionReady)
+	if c == nil {
+		return false
+	}
+	if c["status"] == string(metav1.ConditionTrue) {
+		return true
+	}
+
+	return c["reason"] == reasonFailed || c["reason"] == reasonDenied
+}
+
+// setReadyCondition sets the Ready condition of the given CertificateRequest
+// and returns whether it changed.
+func setReadyCondition(cr *unstructured.Unstructured, status metav1.ConditionStatus, reason, message string, now time.Time) bool {
+	conditions, _, _ := unstructured.NestedSlice(cr.Object, "status", "conditions")
+
+	condition := map[string]interface{}{
+		"type":               conditionReady,
+		"status":             string(status),
+		"reason":             reason,
+		"message":            message,
+		"lastTransitionTime": now.UTC().Format(time.RFC3339),
+	}
+
+	index := -1
+	for i, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if ok && m["type"] == conditionReady {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		conditions = append(conditions, condition)
+	} else {
+		current := conditions[index].(map[string]interface{})
+		if current["status"] == condition["status"] && current["reason"] == condition["reason"] && current["message"] == condition["message"] {
+			return false
+		}
+		if current["status"] == condition["status"] {
+			condition["lastTransitionTime"] = current["lastTransitionTime"]
+		}
+		conditions[index] = condition
+	}
+
+	_ = unstructured.SetNestedSlice(cr.Object, conditions, "status", "conditions")
+
+	if reason == reasonFailed || reason == reasonDenied {
+		_ = unstructured.SetNestedField(cr.Object, now.UTC().Format(time.RFC3339), "status", "failureTime")
+	}
+
+	return true
+}
@@ -0,0 +1,83 @@
+package certificaterequest
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/vaultpki"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultsign"
+)
+
+const (
+	Name = "certificaterequest"
+)
+
+// GroupVersionKind is the kind of the cert-manager CertificateRequests
+// reconciled by this resource. cert-manager's types are not vendored, so the
+// CertificateRequests are handled as unstructured objects.
+var GroupVersionKind = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "CertificateRequest",
+}
+
+type Config struct {
+	CtrlClient client.Client
+	Logger     micrologger.Logger
+	VaultPKI   vaultpki.Interface
+	VaultSign  vaultsign.Interface
+
+	// CommonNameFormat is the format of the Vault PKI common name of tenant
+	// clusters, e.g. "%s.k8s.gigantic.io". Issuers without allowed domains
+	// issue certificates for it and its subdomains.
+	CommonNameFormat string
+}
+
+// Resource implements the cert-manager external issuer contract for the
+// ClusterPKIIssuer kind. It signs approved CertificateRequests referencing a
+// ClusterPKIIssuer with the Vault PKI backend of the issuer's tenant cluster,
+// using a role of the issuer which only allows its domains and organizations.
+type Resource struct {
+	ctrlClient client.Client
+	logger     micrologger.Logger
+	vaultPKI   vaultpki.Interface
+	vaultSign  vaultsign.Interface
+
+	commonNameFormat string
+}
+
+func New(config Config) (*Resource, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.VaultPKI == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultPKI must not be empty", config)
+	}
+	if config.VaultSign == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultSign must not be empty", config)
+	}
+
+	if config.CommonNameFormat == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CommonNameFormat must not be empty", config)
+	}
+
+	r := &Resource{
+		ctrlClient: config.CtrlClient,
+		logger:     config.Logger,
+		vaultPKI:   config.VaultPKI,
+		vaultSign:  config.VaultSign,
+
+		commonNameFormat: config.CommonNameFormat,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
@@ -0,0 +1,97 @@
+package certificaterequest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/apis/certoperator/v1alpha1"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultsign"
+)
+
+// ensureRole writes the role of the given issuer, which only allows the
+// domains and organizations of the issuer. Every issuer has a role of its
+// own, so that the roles vaultrole maintains for the cert configs of the
+// tenant cluster are never touched.
+func (r *Resource) ensureRole(ctx context.Context, issuer *v1alpha1.ClusterPKIIssuer) error {
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("writing role %#q in the Vault API", roleName(issuer)))
+
+	c := vaultsign.RoleConfig{
+		AllowedDomains: r.allowedDomains(issuer),
+		ID:             issuer.Spec.ClusterID,
+		Name:           roleName(issuer),
+		Organizations:  issuer.Spec.Organizations,
+	}
+	err := r.vaultSign.EnsureRole(c)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("wrote role %#q in the Vault API", roleName(issuer)))
+
+	return nil
+}
+
+// allowedDomains returns the domains the given issuer issues certificates
+// for, which default to the common name of its tenant cluster.
+func (r *Resource) allowedDomains(issuer *v1alpha1.ClusterPKIIssuer) []string {
+	if len(issuer.Spec.AllowedDomains) != 0 {
+		return issuer.Spec.AllowedDomains
+	}
+
+	return []string{fmt.Sprintf(r.commonNameFormat, issuer.Spec.ClusterID)}
+}
+
+// roleName returns the name of the role of the given issuer. The prefix
+// differs from the "role-" prefix of the roles vaultrole maintains.
+func roleName(issuer *v1alpha1.ClusterPKIIssuer) string {
+	return fmt.Sprintf("issuer-%s", issuer.GetName())
+}
+
+// disallowedNames returns the names which are neither one of the given
+// domains nor a subdomain of them.
+func disallowedNames(domains []string, names []string) []string {
+	var disallowed []string
+	for _, n := range names {
+		if !isAllowedName(domains, n) && !contains(disallowed, n) {
+			disallowed = append(disallowed, n)
+		}
+	}
+
+	return disallowed
+}
+
+func isAllowedName(domains []string, name string) bool {
+	for _, d := range domains {
+		if name == d || strings.HasSuffix(name, "."+d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// disallowedOrganizations returns the given organizations which are not in
+// the list of allowed organizations.
+func disallowedOrganizations(allowed []string, organizations []string) []string {
+	var disallowed []string
+	for _, o := range organizations {
+		if !contains(allowed, o) && !contains(disallowed, o) {
+			disallowed = append(disallowed, o)
+		}
+	}
+
+	return disallowed
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,11 @@
+package clusterpki
+
+import (
+	"context"
+)
+
+// EnsureCreated does nothing. The PKI of tenant clusters is created by the
+// cert config controller.
+func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	return nil
+}
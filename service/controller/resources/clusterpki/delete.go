@@ -0,0 +1,163 @@
+package clusterpki
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/context/finalizerskeptcontext"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/context/reconciliationcanceledcontext"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+	vaultpkiresource "github.com/giantswarm/cert-operator/v3/service/controller/resources/vaultpki"
+)
+
+const (
+	// operatorkitFinalizerPrefix is the prefix of the finalizers managed by
+	// operatorkit controllers, followed by the name of the controller.
+	operatorkitFinalizerPrefix = "operatorkit.giantswarm.io/"
+)
+
+func (r *Resource) EnsureDeleted(ctx context.Context, obj interface{}) error {
+	cluster, err := key.ToCluster(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	id := key.TenantClusterID(cluster)
+
+	// The certificates of the tenant cluster may still be used while its
+	// infrastructure is torn down. We therefore wait for all other
+	// controllers, e.g. the CAPI ones or the operatorkit based ones of other
+	// operators, to remove their finalizers before tearing down the PKI.
+	{
+		pending := pendingFinalizers(cluster.GetFinalizers(), r.finalizer)
+		if len(pending) != 0 {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("cluster still has finalizers %v", pending))
+			r.keepFinalizers(ctx)
+			return nil
+		}
+	}
+
+	{
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleting the cert configs of Tenant Cluster %#q", id))
+
+		list := &v1alpha1.CertConfigList{}
+		err := r.ctrlClient.List(ctx, list, client.MatchingLabels{label.Cluster: id})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for i := range list.Items {
+			cc := &list.Items[i]
+			if cc.GetDeletionTimestamp() != nil {
+				continue
+			}
+
+			// Paused cert configs must not be touched, so the teardown of the
+			// PKI waits until they are unpaused.
+			if key.IsPaused(*cc) {
+				r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not deleting paused cert config %#q", cc.GetName()))
+				continue
+			}
+
+			err := r.ctrlClient.Delete(ctx, cc)
+			if apierrors.IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		// The cert configs are only gone once the cert config controller
+		// deleted their secrets. Until then we keep the finalizers of the
+		// cluster.
+		if len(list.Items) != 0 {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("waiting for %d cert configs of Tenant Cluster %#q to be deleted", len(list.Items), id))
+			r.keepFinalizers(ctx)
+			return nil
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted the cert configs of Tenant Cluster %#q", id))
+	}
+
+	// The PKI backends of the trust domains are deleted before the one of the
+	// cluster, so that they are not left behind in case the deletion fails
+	// half way.
+	pkiIDs := []string{}
+	for _, d := range r.trustDomains.Domains() {
+		pkiIDs = append(pkiIDs, trustdomain.PKIID(id, d))
+	}
+	pkiIDs = append(pkiIDs, id)
+
+	for _, pkiID := range pkiIDs {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleting the Vault PKI %#q of Tenant Cluster %#q", pkiID, id))
+
+		exists, err := r.vaultPKI.BackendExists(pkiID)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// Deleting the PKI backend also deletes the roles stored in it.
+		if exists {
+			err := r.vaultPKI.DeleteBackend(pkiID)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted the Vault PKI %#q of Tenant Cluster %#q", pkiID, id))
+		} else {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("the Vault PKI %#q of Tenant Cluster %#q does not need to be deleted", pkiID, id))
+		}
+
+		vaultpkiresource.DeleteCAExpiry(id, pkiID)
+
+		// The exported private key of the CA is useless without the PKI
+		// backend.
+		{
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.CAKeySecretName(pkiID),
+					Namespace: cluster.GetNamespace(),
+				},
+			}
+
+			err := r.ctrlClient.Delete(ctx, secret)
+			if apierrors.IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			} else {
+				r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted CA key secret %#q", secret.GetName()))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Resource) keepFinalizers(ctx context.Context) {
+	r.logger.LogCtx(ctx, "level", "debug", "message", "keeping finalizers")
+	finalizerskeptcontext.SetKept(ctx)
+	r.logger.LogCtx(ctx, "level", "debug", "message", "canceling reconciliation")
+	reconciliationcanceledcontext.SetCanceled(ctx)
+}
+
+// pendingFinalizers returns the given finalizers except the given own one.
+func pendingFinalizers(finalizers []string, own string) []string {
+	var pending []string
+	for _, f := range finalizers {
+		if f != own {
+			pending = append(pending, f)
+		}
+	}
+
+	return pending
+}
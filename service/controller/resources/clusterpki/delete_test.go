@@ -0,0 +1,215 @@
+package clusterpki
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/context/finalizerskeptcontext"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/context/reconciliationcanceledcontext"
+	"github.com/giantswarm/vaultpki/vaultpkitest"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+)
+
+type fakeVaultPKI struct {
+	*vaultpkitest.VaultPKITest
+
+	deleted []string
+}
+
+func (p *fakeVaultPKI) BackendExists(ID string) (bool, error) {
+	return true, nil
+}
+
+func (p *fakeVaultPKI) DeleteBackend(ID string) error {
+	p.deleted = append(p.deleted, ID)
+	return nil
+}
+
+func Test_Resource_ClusterPKI_EnsureDeleted(t *testing.T) {
+	newContext := func() context.Context {
+		ctx := context.Background()
+		ctx = finalizerskeptcontext.NewContext(ctx, make(chan struct{}))
+		ctx = reconciliationcanceledcontext.NewContext(ctx, make(chan struct{}))
+		return ctx
+	}
+
+	certConfig := func(name, cluster string) *v1alpha1.CertConfig {
+		return &v1alpha1.CertConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      name,
+				Namespace: "org-giantswarm",
+				Labels:    map[string]string{label.Cluster: cluster},
+			},
+		}
+	}
+
+	paused := certConfig("al9qy-etcd", "al9qy")
+	paused.Labels[label.Paused] = "true"
+
+	cluster := &capi.Cluster{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:       "al9qy",
+			Namespace:  "org-giantswarm",
+			Finalizers: []string{"cluster.cluster.x-k8s.io", "operatorkit.giantswarm.io/cert-operator-cluster"},
+		},
+	}
+
+	caKeySecret := &corev1.Secret{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "al9qy-etcd-ca-key",
+			Namespace: "org-giantswarm",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = capi.AddToScheme(scheme)
+
+	ctrlClient := fakectrl.NewClientBuilder().WithScheme(scheme).WithObjects(
+		certConfig("al9qy-api", "al9qy"),
+		certConfig("al9qy-worker", "al9qy"),
+		certConfig("p1j4w-api", "p1j4w"),
+		paused,
+		caKeySecret,
+	).Build()
+	vaultPKI := &fakeVaultPKI{VaultPKITest: vaultpkitest.New()}
+
+	var err error
+	var newResource *Resource
+	{
+		c := Config{
+			CtrlClient: ctrlClient,
+			Logger:     microloggertest.New(),
+			VaultPKI:   vaultPKI,
+
+			ControllerName: "cert-operator-cluster",
+			TrustDomains:   trustdomain.Mapping{"etcd": "etcd"},
+		}
+
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	countCertConfigs := func() int {
+		list := &v1alpha1.CertConfigList{}
+		err := ctrlClient.List(context.Background(), list)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+		return len(list.Items)
+	}
+
+	// Test 0 ensures nothing is torn down while other controllers still have
+	// finalizers on the cluster.
+	{
+		ctx := newContext()
+		err := newResource.EnsureDeleted(ctx, cluster)
+		if err != nil {
+			t.Fatal("test 0 expected", nil, "got", err)
+		}
+		if !finalizerskeptcontext.IsKept(ctx) {
+			t.Fatalf("test 0 expected finalizers to be kept")
+		}
+		if countCertConfigs() != 4 {
+			t.Fatalf("test 0 expected %d cert configs got %d", 4, countCertConfigs())
+		}
+	}
+
+	cluster.Finalizers = []string{"operatorkit.giantswarm.io/kvm-operator-cluster-controller", "operatorkit.giantswarm.io/cert-operator-cluster"}
+
+	// Test 1 ensures nothing is torn down while the operatorkit controllers of
+	// other operators still have finalizers on the cluster.
+	{
+		ctx := newContext()
+		err := newResource.EnsureDeleted(ctx, cluster)
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+		if !finalizerskeptcontext.IsKept(ctx) {
+			t.Fatalf("test 1 expected finalizers to be kept")
+		}
+		if countCertConfigs() != 4 {
+			t.Fatalf("test 1 expected %d cert configs got %d", 4, countCertConfigs())
+		}
+	}
+
+	cluster.Finalizers = []string{"operatorkit.giantswarm.io/cert-operator-cluster"}
+
+	// Test 2 ensures the cert configs of the cluster are deleted first and the
+	// finalizers are kept until they are gone. Paused cert configs are not
+	// deleted.
+	{
+		ctx := newContext()
+		err := newResource.EnsureDeleted(ctx, cluster)
+		if err != nil {
+			t.Fatal("test 2 expected", nil, "got", err)
+		}
+		if !finalizerskeptcontext.IsKept(ctx) {
+			t.Fatalf("test 2 expected finalizers to be kept")
+		}
+		if countCertConfigs() != 2 {
+			t.Fatalf("test 2 expected %d cert configs got %d", 2, countCertConfigs())
+		}
+		if len(vaultPKI.deleted) != 0 {
+			t.Fatalf("test 2 expected no deleted PKI backends got %v", vaultPKI.deleted)
+		}
+	}
+
+	delete(paused.Labels, label.Paused)
+	err = ctrlClient.Update(context.Background(), paused)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	// Test 3 ensures cert configs are deleted once they got unpaused.
+	{
+		ctx := newContext()
+		err := newResource.EnsureDeleted(ctx, cluster)
+		if err != nil {
+			t.Fatal("test 3 expected", nil, "got", err)
+		}
+		if !finalizerskeptcontext.IsKept(ctx) {
+			t.Fatalf("test 3 expected finalizers to be kept")
+		}
+		if countCertConfigs() != 1 {
+			t.Fatalf("test 3 expected %d cert configs got %d", 1, countCertConfigs())
+		}
+	}
+
+	// Test 4 ensures the PKI backends of the trust domains and the cluster are
+	// deleted once the cert configs are gone, along with the private keys of
+	// their CAs.
+	{
+		ctx := newContext()
+		err := newResource.EnsureDeleted(ctx, cluster)
+		if err != nil {
+			t.Fatal("test 4 expected", nil, "got", err)
+		}
+		if finalizerskeptcontext.IsKept(ctx) {
+			t.Fatalf("test 4 expected finalizers not to be kept")
+		}
+		expected := []string{"al9qy-etcd", "al9qy"}
+		if !reflect.DeepEqual(vaultPKI.deleted, expected) {
+			t.Fatalf("test 4 expected deleted PKI backends %v got %v", expected, vaultPKI.deleted)
+		}
+		err = ctrlClient.Get(context.Background(), client.ObjectKeyFromObject(caKeySecret), &corev1.Secret{})
+		if !apierrors.IsNotFound(err) {
+			t.Fatalf("test 4 expected the CA key secret to be deleted got %#v", err)
+		}
+	}
+}
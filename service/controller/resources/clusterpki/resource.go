@@ -0,0 +1,70 @@
+package clusterpki
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/vaultpki"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+)
+
+const (
+	Name = "clusterpki"
+)
+
+type Config struct {
+	CtrlClient client.Client
+	Logger     micrologger.Logger
+	VaultPKI   vaultpki.Interface
+
+	// ControllerName is the name of the operatorkit controller the resource
+	// is executed by. The finalizer of the controller is derived from it.
+	ControllerName string
+	// TrustDomains maps cluster components to trust domains. The PKI backends
+	// of all trust domains of the mapping are torn down.
+	TrustDomains trustdomain.Mapping
+}
+
+// Resource tears down the PKI of CAPI clusters once they got deleted. It
+// deletes the cert configs of the tenant cluster and, once they are gone, its
+// Vault PKI backends including the roles stored in them.
+type Resource struct {
+	ctrlClient client.Client
+	logger     micrologger.Logger
+	vaultPKI   vaultpki.Interface
+
+	finalizer    string
+	trustDomains trustdomain.Mapping
+}
+
+func New(config Config) (*Resource, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.VaultPKI == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultPKI must not be empty", config)
+	}
+
+	if config.ControllerName == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ControllerName must not be empty", config)
+	}
+
+	r := &Resource{
+		ctrlClient: config.CtrlClient,
+		logger:     config.Logger,
+		vaultPKI:   config.VaultPKI,
+
+		finalizer:    operatorkitFinalizerPrefix + config.ControllerName,
+		trustDomains: config.TrustDomains,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
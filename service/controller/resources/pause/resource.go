@@ -0,0 +1,37 @@
+package pause
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+)
+
+const (
+	Name = "pause"
+)
+
+type Config struct {
+	Logger micrologger.Logger
+}
+
+// Resource cancels the reconciliation of cert configs carrying the pause
+// annotation or label. It has to be the first resource of the resource set so
+// that none of the other resources touch paused cert configs.
+type Resource struct {
+	logger micrologger.Logger
+}
+
+func New(config Config) (*Resource, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	r := &Resource{
+		logger: config.Logger,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
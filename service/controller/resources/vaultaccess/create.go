@@ -9,7 +9,7 @@ import (
 
 func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
 	r.logger.LogCtx(ctx, "level", "debug", "message", "renewing the Vault token")
-	_, err := r.vaultClient.Auth().Token().RenewSelf(0)
+	err := r.vaultAuth.Renew(ctx)
 	if IsVaultAccess(err) {
 		r.logger.LogCtx(ctx, "level", "debug", "message", "vault not reachable")
 		r.logger.LogCtx(ctx, "level", "debug", "message", "vault upgrade in progress")
@@ -3,7 +3,8 @@ package vaultaccess
 import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
-	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultauth"
 )
 
 const (
@@ -11,26 +12,26 @@ const (
 )
 
 type Config struct {
-	Logger      micrologger.Logger
-	VaultClient *vaultapi.Client
+	Logger    micrologger.Logger
+	VaultAuth vaultauth.Interface
 }
 
 type Resource struct {
-	logger      micrologger.Logger
-	vaultClient *vaultapi.Client
+	logger    micrologger.Logger
+	vaultAuth vaultauth.Interface
 }
 
 func New(config Config) (*Resource, error) {
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
-	if config.VaultClient == nil {
-		return nil, microerror.Maskf(invalidConfigError, "%T.VaultClient must not be empty", config)
+	if config.VaultAuth == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultAuth must not be empty", config)
 	}
 
 	r := &Resource{
-		logger:      config.Logger,
-		vaultClient: config.VaultClient,
+		logger:    config.Logger,
+		vaultAuth: config.VaultAuth,
 	}
 
 	return r, nil
@@ -0,0 +1,18 @@
+package vaultbreaker
+
+import (
+	"context"
+
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/context/reconciliationcanceledcontext"
+)
+
+func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	if r.vaultBreaker.Open() {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "vault circuit breaker is open")
+		r.logger.LogCtx(ctx, "level", "debug", "message", "canceling reconciliation")
+		reconciliationcanceledcontext.SetCanceled(ctx)
+		return nil
+	}
+
+	return nil
+}
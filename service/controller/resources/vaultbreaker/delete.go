@@ -0,0 +1,23 @@
+package vaultbreaker
+
+import (
+	"context"
+
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/context/finalizerskeptcontext"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/context/reconciliationcanceledcontext"
+)
+
+func (r *Resource) EnsureDeleted(ctx context.Context, obj interface{}) error {
+	// Finalizers are kept, so that the deletion is reconciled again once
+	// Vault is operational.
+	if r.vaultBreaker.Open() {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "vault circuit breaker is open")
+		r.logger.LogCtx(ctx, "level", "debug", "message", "keeping finalizers")
+		finalizerskeptcontext.SetKept(ctx)
+		r.logger.LogCtx(ctx, "level", "debug", "message", "canceling reconciliation")
+		reconciliationcanceledcontext.SetCanceled(ctx)
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package vaultbreaker
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
+)
+
+const (
+	Name = "vaultbreaker"
+)
+
+type Config struct {
+	Logger       micrologger.Logger
+	VaultBreaker vaultbreaker.Interface
+}
+
+// Resource cancels reconciliation while the Vault circuit breaker is open,
+// so that reconciliations are neither failed nor retried while Vault
+// recovers. It has to precede all resources talking to Vault.
+type Resource struct {
+	logger       micrologger.Logger
+	vaultBreaker vaultbreaker.Interface
+}
+
+func New(config Config) (*Resource, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.VaultBreaker == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultBreaker must not be empty", config)
+	}
+
+	r := &Resource{
+		logger:       config.Logger,
+		vaultBreaker: config.VaultBreaker,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
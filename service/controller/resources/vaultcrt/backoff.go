@@ -0,0 +1,85 @@
+package vaultcrt
+
+import (
+	"sync"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+)
+
+const (
+	verificationBackoffInitial = time.Minute
+	verificationBackoffMax     = time.Hour
+)
+
+// verificationBackoff delays the issuance of certificates of which the last
+// issued certificate failed verification, e.g. because the CA of the cert
+// config does not permit its names. Such a certificate fails verification
+// again and again, so without the backoff it would be reissued from Vault on
+// every reconciliation. The delay doubles with every failure up to
+// verificationBackoffMax.
+type verificationBackoff struct {
+	mutex    sync.Mutex
+	failures map[string]verificationFailure
+}
+
+type verificationFailure struct {
+	count int
+	next  time.Time
+}
+
+func newVerificationBackoff() *verificationBackoff {
+	b := &verificationBackoff{
+		failures: map[string]verificationFailure{},
+	}
+
+	return b
+}
+
+// Delay returns the time left at the given time until the certificate of the
+// given ID may be issued again.
+func (b *verificationBackoff) Delay(id string, now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	f, ok := b.failures[id]
+	if !ok || !now.Before(f.next) {
+		return 0
+	}
+
+	return f.next.Sub(now)
+}
+
+// Failed records the failed verification of the certificate of the given ID
+// issued at the given time.
+func (b *verificationBackoff) Failed(id string, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	f := b.failures[id]
+	f.count++
+
+	delay := verificationBackoffInitial
+	for i := 1; i < f.count && delay < verificationBackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > verificationBackoffMax {
+		delay = verificationBackoffMax
+	}
+
+	f.next = now.Add(delay)
+	b.failures[id] = f
+}
+
+// Reset forgets the failures of the certificate of the given ID, e.g. because
+// its last issued certificate passed verification.
+func (b *verificationBackoff) Reset(id string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.failures, id)
+}
+
+func verificationBackoffID(customObject v1alpha1.CertConfig) string {
+	return customObject.GetNamespace() + "/" + customObject.GetName()
+}
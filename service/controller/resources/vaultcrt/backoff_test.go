@@ -0,0 +1,45 @@
+package vaultcrt
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Resource_VaultCrt_verificationBackoff(t *testing.T) {
+	now := time.Unix(100, 0).In(time.UTC)
+
+	b := newVerificationBackoff()
+
+	// Test 0 ensures certificates without failures are not delayed.
+	if d := b.Delay("default/al9qy-api", now); d != 0 {
+		t.Fatalf("test 0 expected %s got %s", time.Duration(0), d)
+	}
+
+	// Test 1 ensures the delay doubles with every failure up to the maximum.
+	expected := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, e := range expected {
+		b.Failed("default/al9qy-api", now)
+		if d := b.Delay("default/al9qy-api", now); d != e {
+			t.Fatalf("test 1 failure %d expected %s got %s", i, e, d)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		b.Failed("default/al9qy-api", now)
+	}
+	if d := b.Delay("default/al9qy-api", now); d != verificationBackoffMax {
+		t.Fatalf("test 1 expected %s got %s", verificationBackoffMax, d)
+	}
+	if d := b.Delay("default/al9qy-api", now.Add(verificationBackoffMax)); d != 0 {
+		t.Fatalf("test 1 expected %s got %s", time.Duration(0), d)
+	}
+
+	// Test 2 ensures failures of other certificates are tracked separately and
+	// resets forget the failures.
+	if d := b.Delay("default/al9qy-worker", now); d != 0 {
+		t.Fatalf("test 2 expected %s got %s", time.Duration(0), d)
+	}
+	b.Reset("default/al9qy-api")
+	if d := b.Delay("default/al9qy-api", now); d != 0 {
+		t.Fatalf("test 2 expected %s got %s", time.Duration(0), d)
+	}
+}
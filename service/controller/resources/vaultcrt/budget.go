@@ -0,0 +1,135 @@
+package vaultcrt
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	budgetScopeCluster = "cluster"
+	budgetScopeGlobal  = "global"
+)
+
+// renewalBudget is a token bucket based rate limiter for certificate renewals.
+// It limits renewals globally and per tenant cluster in order to prevent all
+// certificates created during the same rollout from being renewed within the
+// same reconciliation wave. A zero rate disables the respective limit.
+type renewalBudget struct {
+	global *rate.Limiter
+
+	clusterMutex sync.Mutex
+	clusters     map[string]*rate.Limiter
+	perCluster   int
+}
+
+func newRenewalBudget(perMinute, perClusterPerMinute int) *renewalBudget {
+	b := &renewalBudget{
+		clusters:   map[string]*rate.Limiter{},
+		perCluster: perClusterPerMinute,
+	}
+
+	if perMinute > 0 {
+		b.global = newPerMinuteLimiter(perMinute)
+	}
+
+	return b
+}
+
+// renewalReservation holds the tokens taken from the renewal budget for a
+// renewal.
+type renewalReservation struct {
+	reservations []*rate.Reservation
+}
+
+// Cancel returns the tokens of the reservation to the renewal budget, e.g.
+// because the certificate could not be issued. It is a no-op for nil
+// reservations.
+func (r *renewalReservation) Cancel(now time.Time) {
+	if r == nil {
+		return
+	}
+
+	for _, reservation := range r.reservations {
+		reservation.CancelAt(now)
+	}
+}
+
+// Reserve takes the tokens for a renewal for the given tenant cluster from the
+// global and the per cluster budget at the given time. In case the renewal
+// does not fit into the budget, no tokens are taken and the delay until the
+// budget is refilled is returned along with the scope of the exhausted budget.
+func (b *renewalBudget) Reserve(clusterID string, now time.Time) (*renewalReservation, time.Duration, string) {
+	reservation := &renewalReservation{}
+
+	if l := b.clusterLimiter(clusterID); l != nil {
+		r := l.ReserveN(now, 1)
+		if delay := r.DelayFrom(now); delay > 0 {
+			r.CancelAt(now)
+			return nil, delay, budgetScopeCluster
+		}
+
+		reservation.reservations = append(reservation.reservations, r)
+	}
+
+	if b.global != nil {
+		r := b.global.ReserveN(now, 1)
+		if delay := r.DelayFrom(now); delay > 0 {
+			r.CancelAt(now)
+			reservation.Cancel(now)
+			return nil, delay, budgetScopeGlobal
+		}
+
+		reservation.reservations = append(reservation.reservations, r)
+	}
+
+	return reservation, 0, ""
+}
+
+// Available returns the number of renewals the global budget currently
+// allows. It returns -1 in case the global budget is disabled.
+func (b *renewalBudget) Available(now time.Time) float64 {
+	if b.global == nil {
+		return -1
+	}
+
+	return b.global.TokensAt(now)
+}
+
+func (b *renewalBudget) clusterLimiter(clusterID string) *rate.Limiter {
+	if b.perCluster <= 0 {
+		return nil
+	}
+
+	b.clusterMutex.Lock()
+	defer b.clusterMutex.Unlock()
+
+	l, ok := b.clusters[clusterID]
+	if !ok {
+		l = newPerMinuteLimiter(b.perCluster)
+		b.clusters[clusterID] = l
+	}
+
+	return l
+}
+
+func newPerMinuteLimiter(perMinute int) *rate.Limiter {
+	return rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), perMinute)
+}
+
+// renewalJitter computes a deterministic delay within [0, max) for the
+// certificate identified by the given ID. The same certificate always gets the
+// same delay, while certificates created at the same time get different ones,
+// which spreads their renewals across the renewal window.
+func renewalJitter(id string, max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(id))
+
+	return time.Duration(h.Sum64() % uint64(max))
+}
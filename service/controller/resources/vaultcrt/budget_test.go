@@ -0,0 +1,108 @@
+package vaultcrt
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Resource_VaultCrt_renewalBudget_Reserve(t *testing.T) {
+	now := time.Unix(100, 0).In(time.UTC)
+
+	// Test 0 ensures a disabled budget allows any number of renewals.
+	{
+		b := newRenewalBudget(0, 0)
+		for i := 0; i < 100; i++ {
+			_, delay, _ := b.Reserve("al9qy", now)
+			if delay != 0 {
+				t.Fatalf("test 0 renewal %d expected delay %s got %s", i, time.Duration(0), delay)
+			}
+		}
+	}
+
+	// Test 1 ensures the global budget is shared across clusters and refilled
+	// over time, and the delay until it is refilled is returned.
+	{
+		b := newRenewalBudget(2, 0)
+		for i, id := range []string{"al9qy", "p1j4w"} {
+			_, delay, _ := b.Reserve(id, now)
+			if delay != 0 {
+				t.Fatalf("test 1 renewal %d expected delay %s got %s", i, time.Duration(0), delay)
+			}
+		}
+
+		reservation, delay, scope := b.Reserve("x7b3k", now)
+		if reservation != nil {
+			t.Fatalf("test 1 expected no reservation")
+		}
+		if delay != 30*time.Second {
+			t.Fatalf("test 1 expected delay %s got %s", 30*time.Second, delay)
+		}
+		if scope != budgetScopeGlobal {
+			t.Fatalf("test 1 expected scope %#q got %#q", budgetScopeGlobal, scope)
+		}
+
+		_, delay, _ = b.Reserve("x7b3k", now.Add(30*time.Second))
+		if delay != 0 {
+			t.Fatalf("test 1 expected delay %s got %s", time.Duration(0), delay)
+		}
+	}
+
+	// Test 2 ensures the per cluster budget only limits the cluster it got
+	// exhausted for and does not consume global tokens when exceeded.
+	{
+		b := newRenewalBudget(3, 1)
+
+		_, delay, _ := b.Reserve("al9qy", now)
+		if delay != 0 {
+			t.Fatalf("test 2 expected delay %s got %s", time.Duration(0), delay)
+		}
+
+		_, delay, scope := b.Reserve("al9qy", now)
+		if delay != time.Minute {
+			t.Fatalf("test 2 expected delay %s got %s", time.Minute, delay)
+		}
+		if scope != budgetScopeCluster {
+			t.Fatalf("test 2 expected scope %#q got %#q", budgetScopeCluster, scope)
+		}
+
+		for i, id := range []string{"p1j4w", "x7b3k"} {
+			_, delay, _ := b.Reserve(id, now)
+			if delay != 0 {
+				t.Fatalf("test 2 renewal %d expected delay %s got %s", i, time.Duration(0), delay)
+			}
+		}
+	}
+
+	// Test 3 ensures canceled reservations return their tokens to the global
+	// and the per cluster budget.
+	{
+		b := newRenewalBudget(1, 1)
+
+		reservation, _, _ := b.Reserve("al9qy", now)
+		reservation.Cancel(now)
+
+		_, delay, _ := b.Reserve("al9qy", now)
+		if delay != 0 {
+			t.Fatalf("test 3 expected delay %s got %s", time.Duration(0), delay)
+		}
+	}
+}
+
+func Test_Resource_VaultCrt_renewalJitter(t *testing.T) {
+	max := 24 * time.Hour
+
+	if j := renewalJitter("al9qy/api", 0); j != 0 {
+		t.Fatalf("expected %s got %s", time.Duration(0), j)
+	}
+
+	a := renewalJitter("al9qy/api", max)
+	if a < 0 || a >= max {
+		t.Fatalf("expected jitter within [0, %s) got %s", max, a)
+	}
+	if b := renewalJitter("al9qy/api", max); a != b {
+		t.Fatalf("expected deterministic jitter %s got %s", a, b)
+	}
+	if b := renewalJitter("al9qy/worker", max); a == b {
+		t.Fatalf("expected different jitter for different certificates, got %s for both", a)
+	}
+}
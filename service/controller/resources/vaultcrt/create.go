@@ -39,6 +39,11 @@ func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange inte
 				customObject.Namespace)
 		}
 
+		err = r.verifySecret(ctx, customObject, secretToCreate)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
 		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the secret in the Kubernetes API")
 
 		_, err = r.k8sClient.CoreV1().Secrets(customObject.GetNamespace()).Create(ctx, secretToCreate, metav1.CreateOptions{})
@@ -47,6 +52,11 @@ func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange inte
 		}
 
 		r.logger.LogCtx(ctx, "level", "debug", "message", "created the secret in the Kubernetes API")
+
+		err = r.removeForceRenew(ctx, customObject)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 	} else {
 		r.logger.LogCtx(ctx, "level", "debug", "message", "the secret does not need to be created in the Kubernetes API")
 	}
@@ -72,7 +82,13 @@ func (r *Resource) newCreateChange(ctx context.Context, obj, currentState, desir
 
 	var secretToCreate *apiv1.Secret
 	if currentSecret == nil {
-		ca, crt, k, err := r.issueCertificate(customObject)
+		delay := r.verificationBackoff.Delay(verificationBackoffID(customObject), r.currentTimeFactory())
+		if delay != 0 {
+			r.deferIssuance(ctx, delay, "the failed verification of the previously issued certificate")
+			return nil, nil
+		}
+
+		ca, crt, k, err := r.issueCertificate(ctx, customObject)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
@@ -81,6 +97,7 @@ func (r *Resource) newCreateChange(ctx context.Context, obj, currentState, desir
 		secretToCreate.StringData[key.CAID] = ca
 		secretToCreate.StringData[key.CrtID] = crt
 		secretToCreate.StringData[key.KeyID] = k
+		r.addOverlap(nil, secretToCreate)
 	}
 
 	r.logger.LogCtx(ctx, "level", "debug", "message", "found out if the secret has to be created")
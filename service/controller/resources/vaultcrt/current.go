@@ -35,6 +35,17 @@ func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interf
 			// fall through
 		} else if err != nil {
 			return nil, microerror.Mask(err)
+		} else if isControlledByOther(customObject, manifest) {
+			ref := metav1.GetControllerOf(manifest)
+
+			// In case the cert config is deleted, we must not delete the secret
+			// of another object. We thus act as if there is no secret.
+			if key.IsDeleted(customObject) {
+				r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not deleting the secret controlled by %s %#q", ref.Kind, ref.Name))
+				return nil, nil
+			}
+
+			return nil, microerror.Maskf(conflictError, "secret %#q is controlled by %s %#q", manifest.Name, ref.Kind, ref.Name)
 		} else {
 			r.logger.LogCtx(ctx, "level", "debug", "message", "found the secret in the Kubernetes API")
 			secret = manifest
@@ -71,6 +82,15 @@ func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interf
 
 			return nil, nil
 		}
+
+		return secret, nil
+	}
+
+	if secret != nil {
+		err := r.inspectSecret(ctx, customObject, secret)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
 	}
 
 	return secret, nil
@@ -0,0 +1,265 @@
+package vaultcrt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/vaultcrt"
+	"github.com/giantswarm/vaultcrt/vaultcrttest"
+	"github.com/giantswarm/vaultpki/vaultpkitest"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+func Test_Resource_VaultCrt_GetCurrentState_inspection(t *testing.T) {
+	now := time.Now()
+
+	customObject := v1alpha1.CertConfig{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "al9qy-api",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				AltNames:         []string{"kubernetes", "kubernetes.default"},
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				CommonName:       "api.al9qy.k8s.gigantic.io",
+				IPSANs:           []string{"172.31.0.1"},
+				TTL:              "720h",
+			},
+		},
+	}
+
+	f := newFakeVaultCrt(t, now)
+	other := newFakeVaultCrt(t, now)
+
+	issue := func(f *fakeVaultCrt, c v1alpha1.CertConfig) vaultcrt.CreateResult {
+		r, err := f.Create(vaultcrt.CreateConfig{
+			AltNames:      c.Spec.Cert.AltNames,
+			CommonName:    c.Spec.Cert.CommonName,
+			IPSANs:        c.Spec.Cert.IPSANs,
+			Organizations: key.Organizations(c),
+			TTL:           c.Spec.Cert.TTL,
+		})
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+		return r
+	}
+
+	valid := issue(f, customObject)
+
+	emptied := valid
+	emptied.Crt = ""
+	emptied.Key = ""
+
+	mismatchingKey := valid
+	mismatchingKey.Key = issue(f, customObject).Key
+
+	otherCA := issue(other, customObject)
+
+	drifted := func() vaultcrt.CreateResult {
+		c := *customObject.DeepCopy()
+		c.Spec.Cert.AltNames = []string{"kubernetes"}
+		return issue(f, c)
+	}()
+
+	testCases := []struct {
+		Name           string
+		Result         vaultcrt.CreateResult
+		ExpectedReason string
+	}{
+		{
+			Name:           "case 0: valid certificate",
+			Result:         valid,
+			ExpectedReason: "",
+		},
+		{
+			Name:           "case 1: emptied certificate",
+			Result:         emptied,
+			ExpectedReason: verificationReasonParse,
+		},
+		{
+			Name:           "case 2: mismatching private key",
+			Result:         mismatchingKey,
+			ExpectedReason: verificationReasonKey,
+		},
+		{
+			Name:           "case 3: certificate and CA replaced with material of another CA",
+			Result:         otherCA,
+			ExpectedReason: verificationReasonChain,
+		},
+		{
+			Name:           "case 4: SANs drifted from the cert config",
+			Result:         drifted,
+			ExpectedReason: verificationReasonSANs,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var err error
+			var newResource *Resource
+			{
+				c := DefaultConfig()
+
+				c.CurrentTimeFactory = func() time.Time { return now }
+				c.K8sClient = fake.NewSimpleClientset(&apiv1.Secret{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name:      "al9qy-api",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						key.CAID:  []byte(tc.Result.CA),
+						key.CrtID: []byte(tc.Result.Crt),
+						key.KeyID: []byte(tc.Result.Key),
+					},
+				})
+				c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+				c.Logger = microloggertest.New()
+				c.VaultCrt = f
+				c.VaultPKI = newFakeVaultPKI(f)
+
+				c.ExpirationThreshold = 24 * time.Hour
+				c.Namespace = "default"
+
+				newResource, err = New(c)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			result, err := newResource.GetCurrentState(context.TODO(), &customObject)
+			if err != nil {
+				t.Fatal("expected", nil, "got", err)
+			}
+			secret, err := toSecret(result)
+			if err != nil {
+				t.Fatal("expected", nil, "got", err)
+			}
+			if secret == nil {
+				t.Fatal("expected", "secret", "got", nil)
+			}
+
+			reason := secret.Annotations[InvalidCertificateAnnotation]
+			if reason != tc.ExpectedReason {
+				t.Fatalf("expected reason %#q got %#q", tc.ExpectedReason, reason)
+			}
+		})
+	}
+}
+
+func Test_Resource_VaultCrt_GetCurrentState_ownership(t *testing.T) {
+	now := time.Now()
+	deletionTimestamp := apismetav1.NewTime(now)
+
+	customObject := v1alpha1.CertConfig{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "al9qy-api",
+			Namespace: "default",
+			UID:       "5f3c2a3e-5c1e-4b0a-9d0c-1b1c2a3d4e5f",
+		},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				TTL:              "720h",
+			},
+		},
+	}
+
+	other := *customObject.DeepCopy()
+	other.Name = "al9qy-api-copy"
+	other.UID = "8b2d7a41-0f5e-4c3b-a1d2-6e7f8a9b0c1d"
+
+	testCases := []struct {
+		Name              string
+		OwnerReferences   []apismetav1.OwnerReference
+		DeletionTimestamp *apismetav1.Time
+		ExpectedSecret    bool
+		ErrorMatcher      func(error) bool
+	}{
+		{
+			Name:            "case 0: secret controlled by the cert config",
+			OwnerReferences: []apismetav1.OwnerReference{key.SecretOwnerReference(customObject)},
+			ExpectedSecret:  true,
+		},
+		{
+			Name:            "case 1: unowned secret",
+			OwnerReferences: nil,
+			ExpectedSecret:  true,
+		},
+		{
+			Name:            "case 2: secret controlled by another cert config",
+			OwnerReferences: []apismetav1.OwnerReference{key.SecretOwnerReference(other)},
+			ExpectedSecret:  false,
+			ErrorMatcher:    IsConflict,
+		},
+		{
+			Name:              "case 3: secret controlled by another cert config while deleting",
+			OwnerReferences:   []apismetav1.OwnerReference{key.SecretOwnerReference(other)},
+			DeletionTimestamp: &deletionTimestamp,
+			ExpectedSecret:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var err error
+			var newResource *Resource
+			{
+				c := DefaultConfig()
+
+				c.CurrentTimeFactory = func() time.Time { return now }
+				c.K8sClient = fake.NewSimpleClientset(&apiv1.Secret{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name:            "al9qy-api",
+						Namespace:       "default",
+						OwnerReferences: tc.OwnerReferences,
+					},
+				})
+				c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+				c.Logger = microloggertest.New()
+				c.VaultCrt = vaultcrttest.New()
+				c.VaultPKI = vaultpkitest.New()
+
+				c.ExpirationThreshold = 24 * time.Hour
+				c.Namespace = "default"
+
+				newResource, err = New(c)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			obj := customObject.DeepCopy()
+			obj.DeletionTimestamp = tc.DeletionTimestamp
+
+			result, err := newResource.GetCurrentState(context.TODO(), obj)
+			if tc.ErrorMatcher != nil {
+				if !tc.ErrorMatcher(err) {
+					t.Fatalf("expected %#v got %#v", true, false)
+				}
+			} else if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			secret, err := toSecret(result)
+			if err != nil {
+				t.Fatal("expected", nil, "got", err)
+			}
+			if (secret != nil) != tc.ExpectedSecret {
+				t.Fatalf("expected secret %t got %#v", tc.ExpectedSecret, secret)
+			}
+		})
+	}
+}
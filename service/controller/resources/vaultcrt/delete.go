@@ -32,6 +32,8 @@ func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteChange inte
 			return microerror.Mask(err)
 		}
 
+		r.verificationBackoff.Reset(verificationBackoffID(customObject))
+
 		r.logger.LogCtx(ctx, "level", "debug", "message", "deleted the sercet in the Kubernetes API")
 	} else {
 		r.logger.LogCtx(ctx, "level", "debug", "message", "the sercet does not need to be deleted from the Kubernetes API")
@@ -9,6 +9,7 @@ import (
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
 	"github.com/giantswarm/micrologger/microloggertest"
 	"github.com/giantswarm/vaultcrt/vaultcrttest"
+	"github.com/giantswarm/vaultpki/vaultpkitest"
 	apiv1 "k8s.io/api/core/v1"
 	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -106,6 +107,7 @@ func Test_Resource_VaultCrt_newDeleteChange(t *testing.T) {
 		c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(scheme).Build()
 		c.Logger = microloggertest.New()
 		c.VaultCrt = vaultcrttest.New()
+		c.VaultPKI = vaultpkitest.New()
 
 		c.ExpirationThreshold = 24 * time.Hour
 		c.Namespace = "default"
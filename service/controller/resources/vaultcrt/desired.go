@@ -21,7 +21,7 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 
 	r.logger.LogCtx(ctx, "level", "debug", "message", "computing the desired secret")
 
-	hash, err := key.CustomObjectHash(customObject)
+	hash, err := key.ConfigHash(customObject)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
@@ -37,10 +37,14 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 		ObjectMeta: apismetav1.ObjectMeta{
 			Name: key.SecretName(customObject),
 			Annotations: map[string]string{
-				ConfigHashAnnotation:      hash,
-				UpdateTimestampAnnotation: r.currentTimeFactory().In(time.UTC).Format(UpdateTimestampLayout),
+				ConfigHashAnnotation:        hash,
+				ConfigHashVersionAnnotation: key.ConfigHashVersion,
+				UpdateTimestampAnnotation:   r.currentTimeFactory().In(time.UTC).Format(UpdateTimestampLayout),
 			},
 			Labels: labels,
+			OwnerReferences: []apismetav1.OwnerReference{
+				key.SecretOwnerReference(customObject),
+			},
 		},
 		StringData: map[string]string{
 			key.CAID:  "",
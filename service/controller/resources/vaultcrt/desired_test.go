@@ -8,7 +8,9 @@ import (
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
 	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/to"
 	"github.com/giantswarm/vaultcrt/vaultcrttest"
+	"github.com/giantswarm/vaultpki/vaultpkitest"
 	apiv1 "k8s.io/api/core/v1"
 	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -28,6 +30,8 @@ func Test_Resource_VaultCrt_GetDesiredState(t *testing.T) {
 		{
 			Obj: &v1alpha1.CertConfig{
 				ObjectMeta: apismetav1.ObjectMeta{
+					Name: "foobar-api",
+					UID:  "5f3c2a3e-5c1e-4b0a-9d0c-1b1c2a3d4e5f",
 					Labels: map[string]string{
 						"cert-operator.giantswarm.io/version": project.Version(),
 					},
@@ -43,14 +47,25 @@ func Test_Resource_VaultCrt_GetDesiredState(t *testing.T) {
 				ObjectMeta: apismetav1.ObjectMeta{
 					Name: "foobar-api",
 					Annotations: map[string]string{
-						ConfigHashAnnotation:      "001ad3d32b3f7d64e00ec0a3d5592fbb791849c2",
-						UpdateTimestampAnnotation: (time.Time{}).Format(UpdateTimestampLayout),
+						ConfigHashAnnotation:        "5db4db34271c29eea303604a11c6c15ca6f697d394ae171a3e8f495602257468",
+						ConfigHashVersionAnnotation: "2",
+						UpdateTimestampAnnotation:   (time.Time{}).Format(UpdateTimestampLayout),
 					},
 					Labels: map[string]string{
 						"giantswarm.io/cluster":               "foobar",
 						"giantswarm.io/certificate":           "api",
 						"cert-operator.giantswarm.io/version": project.Version(),
 					},
+					OwnerReferences: []apismetav1.OwnerReference{
+						{
+							APIVersion:         "core.giantswarm.io/v1alpha1",
+							Kind:               "CertConfig",
+							Name:               "foobar-api",
+							UID:                "5f3c2a3e-5c1e-4b0a-9d0c-1b1c2a3d4e5f",
+							Controller:         to.BoolP(true),
+							BlockOwnerDeletion: to.BoolP(true),
+						},
+					},
 				},
 				StringData: map[string]string{
 					"ca":  "",
@@ -64,6 +79,8 @@ func Test_Resource_VaultCrt_GetDesiredState(t *testing.T) {
 		{
 			Obj: &v1alpha1.CertConfig{
 				ObjectMeta: apismetav1.ObjectMeta{
+					Name: "al9qy-worker",
+					UID:  "8b2d7a41-0f5e-4c3b-a1d2-6e7f8a9b0c1d",
 					Labels: map[string]string{
 						"cert-operator.giantswarm.io/version": project.Version(),
 					},
@@ -79,14 +96,25 @@ func Test_Resource_VaultCrt_GetDesiredState(t *testing.T) {
 				ObjectMeta: apismetav1.ObjectMeta{
 					Name: "al9qy-worker",
 					Annotations: map[string]string{
-						ConfigHashAnnotation:      "4bf7b5296ba01161f182de54b243e1400ae6660e",
-						UpdateTimestampAnnotation: (time.Time{}).Format(UpdateTimestampLayout),
+						ConfigHashAnnotation:        "5dec6efb97c4f35c486ab6738d3c37440d69ae4a6397d3dd088d9b18715abba1",
+						ConfigHashVersionAnnotation: "2",
+						UpdateTimestampAnnotation:   (time.Time{}).Format(UpdateTimestampLayout),
 					},
 					Labels: map[string]string{
 						"giantswarm.io/cluster":               "al9qy",
 						"giantswarm.io/certificate":           "worker",
 						"cert-operator.giantswarm.io/version": project.Version(),
 					},
+					OwnerReferences: []apismetav1.OwnerReference{
+						{
+							APIVersion:         "core.giantswarm.io/v1alpha1",
+							Kind:               "CertConfig",
+							Name:               "al9qy-worker",
+							UID:                "8b2d7a41-0f5e-4c3b-a1d2-6e7f8a9b0c1d",
+							Controller:         to.BoolP(true),
+							BlockOwnerDeletion: to.BoolP(true),
+						},
+					},
 				},
 				StringData: map[string]string{
 					"ca":  "",
@@ -109,6 +137,7 @@ func Test_Resource_VaultCrt_GetDesiredState(t *testing.T) {
 		c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(scheme).Build()
 		c.Logger = microloggertest.New()
 		c.VaultCrt = vaultcrttest.New()
+		c.VaultPKI = vaultpkitest.New()
 
 		c.ExpirationThreshold = 24 * time.Hour
 		c.Namespace = "default"
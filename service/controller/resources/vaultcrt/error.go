@@ -4,6 +4,32 @@ import (
 	"github.com/giantswarm/microerror"
 )
 
+// invalidCertificateError is returned when the certificate material issued by
+// Vault does not pass verification. Its description is emitted as event on the
+// cert config by operatorkit.
+var invalidCertificateError = &microerror.Error{
+	Desc: "The certificate issued by Vault did not pass verification and was not written to the secret.",
+	Kind: "invalidCertificateError",
+}
+
+// IsInvalidCertificate asserts invalidCertificateError.
+func IsInvalidCertificate(err error) bool {
+	return microerror.Cause(err) == invalidCertificateError
+}
+
+// conflictError is returned when the secret of a cert config is controlled by
+// another object, e.g. another cert config resolving to the same secret name.
+// Its description is emitted as event on the cert config by operatorkit.
+var conflictError = &microerror.Error{
+	Desc: "The secret of the cert config is controlled by another object and is left untouched.",
+	Kind: "conflictError",
+}
+
+// IsConflict asserts conflictError.
+func IsConflict(err error) bool {
+	return microerror.Cause(err) == conflictError
+}
+
 var missingAnnotationError = &microerror.Error{
 	Kind: "missingAnnotationError",
 }
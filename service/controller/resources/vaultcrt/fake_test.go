@@ -0,0 +1,140 @@
+package vaultcrt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/vaultcrt"
+	"github.com/giantswarm/vaultpki"
+	"github.com/giantswarm/vaultpki/vaultpkitest"
+)
+
+// fakeVaultCrt is a vaultcrt.Interface implementation issuing real
+// certificates signed by a self-signed CA, so that the issued material passes
+// verification.
+type fakeVaultCrt struct {
+	ca    *x509.Certificate
+	caKey *ecdsa.PrivateKey
+	caPEM string
+	now   time.Time
+}
+
+func newFakeVaultCrt(t *testing.T, now time.Time) *fakeVaultCrt {
+	return newFakeVaultCrtWithCAExpiry(t, now, now.Add(10*365*24*time.Hour))
+}
+
+func newFakeVaultCrtWithCAExpiry(t *testing.T, now, caNotAfter time.Time) *fakeVaultCrt {
+	t.Helper()
+
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	template := &x509.Certificate{
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		NotAfter:              caNotAfter,
+		NotBefore:             now.Add(-time.Minute),
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake CA"},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &k.PublicKey, k)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	f := &fakeVaultCrt{
+		ca:    ca,
+		caKey: k,
+		caPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		now:   now,
+	}
+
+	return f
+}
+
+func (f *fakeVaultCrt) Create(config vaultcrt.CreateConfig) (vaultcrt.CreateResult, error) {
+	ttl, err := time.ParseDuration(config.TTL)
+	if err != nil {
+		return vaultcrt.CreateResult{}, err
+	}
+
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return vaultcrt.CreateResult{}, err
+	}
+
+	var ips []net.IP
+	for _, s := range config.IPSANs {
+		ips = append(ips, net.ParseIP(s))
+	}
+
+	template := &x509.Certificate{
+		DNSNames:     append([]string{config.CommonName}, config.AltNames...),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		IPAddresses:  ips,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		NotAfter:     f.now.Add(ttl),
+		NotBefore:    f.now.Add(-30 * time.Second),
+		SerialNumber: big.NewInt(f.now.UnixNano()),
+		Subject: pkix.Name{
+			CommonName:   config.CommonName,
+			Organization: config.Organizations,
+		},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, f.ca, &k.PublicKey, f.caKey)
+	if err != nil {
+		return vaultcrt.CreateResult{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(k)
+	if err != nil {
+		return vaultcrt.CreateResult{}, err
+	}
+
+	result := vaultcrt.CreateResult{
+		CA:           f.caPEM,
+		Crt:          string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		Key:          string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+		SerialNumber: template.SerialNumber.Text(16),
+	}
+
+	return result, nil
+}
+
+// fakeVaultPKI is a vaultpki.Interface implementation returning the CA of the
+// given fakeVaultCrt as the CA of every PKI backend.
+type fakeVaultPKI struct {
+	*vaultpkitest.VaultPKITest
+
+	crt *fakeVaultCrt
+}
+
+func newFakeVaultPKI(crt *fakeVaultCrt) *fakeVaultPKI {
+	p := &fakeVaultPKI{
+		VaultPKITest: vaultpkitest.New(),
+
+		crt: crt,
+	}
+
+	return p
+}
+
+func (p *fakeVaultPKI) GetCACertificate(ID string) (vaultpki.CertificateAuthority, error) {
+	return vaultpki.CertificateAuthority{Certificate: p.crt.caPEM}, nil
+}
@@ -19,6 +19,81 @@ var versionGauge = prometheus.NewGaugeVec(
 	[]string{"major", "minor", "patch"},
 )
 
+var renewalCounter = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "renewals_total",
+		Help:      "A metric counting the certificate renewals issued.",
+	},
+)
+
+var renewalDeferredCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "renewals_deferred_total",
+		Help:      "A metric counting the certificate renewals deferred due to an exhausted renewal budget, labeled by the budget scope.",
+	},
+	[]string{"scope"},
+)
+
+var renewalBudgetGauge = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "renewal_budget_available",
+		Help:      "A metric of the renewals the global renewal budget currently allows, -1 if the budget is disabled.",
+	},
+)
+
+var renewalJitterHistogram = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "renewal_jitter_seconds",
+		Help:      "A metric of the jitter applied to the renewal time of certificates due for renewal.",
+		Buckets:   []float64{0, 60, 300, 900, 3600, 6 * 3600, 24 * 3600, 7 * 24 * 3600, 30 * 24 * 3600},
+	},
+)
+
+var verificationFailureCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "verification_failures_total",
+		Help:      "A metric counting issued certificates which did not pass verification, labeled by the failure reason.",
+	},
+	[]string{"reason"},
+)
+
+var invalidSecretCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "invalid_secrets_total",
+		Help:      "A metric counting secrets found with corrupted or tampered certificate material, labeled by the inspection failure reason.",
+	},
+	[]string{"reason"},
+)
+
+var workloadRestartCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "workload_restarts_total",
+		Help:      "A metric counting the restarts of workloads consuming renewed certificate secrets, labeled by the workload kind and the outcome.",
+	},
+	[]string{"kind", "outcome"},
+)
+
 func init() {
 	prometheus.MustRegister(versionGauge)
+	prometheus.MustRegister(renewalCounter)
+	prometheus.MustRegister(renewalDeferredCounter)
+	prometheus.MustRegister(renewalBudgetGauge)
+	prometheus.MustRegister(renewalJitterHistogram)
+	prometheus.MustRegister(verificationFailureCounter)
+	prometheus.MustRegister(invalidSecretCounter)
+	prometheus.MustRegister(workloadRestartCounter)
 }
@@ -0,0 +1,115 @@
+package vaultcrt
+
+import (
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+// addOverlap keeps the certificate material of the current secret under the
+// previous keys of the given secret, which holds newly issued certificate
+// material, and adds the CA bundle of both CAs. Invalid certificate material
+// is not kept. In case the overlap is disabled, no previous material is kept,
+// but the CA bundle of current secrets carrying one is reset to the new CA, so
+// that consumers do not lose it, see updateOverlap.
+func (r *Resource) addOverlap(currentSecret, secret *apiv1.Secret) {
+	if r.renewalOverlap == 0 {
+		if currentSecret != nil {
+			if _, ok := currentSecret.Data[key.CABundleID]; ok {
+				secret.StringData[key.CABundleID] = caBundle(secret.StringData[key.CAID])
+			}
+		}
+		return
+	}
+
+	var previousCA string
+	if currentSecret != nil && len(currentSecret.Data[key.CrtID]) != 0 && currentSecret.Annotations[InvalidCertificateAnnotation] == "" {
+		previousCA = string(currentSecret.Data[key.CAID])
+
+		secret.StringData[key.PreviousCAID] = previousCA
+		secret.StringData[key.PreviousCrtID] = string(currentSecret.Data[key.CrtID])
+		secret.StringData[key.PreviousKeyID] = string(currentSecret.Data[key.KeyID])
+
+		pruneTime := r.currentTimeFactory().Add(r.renewalOverlap)
+		secret.Annotations[PreviousPruneTimestampAnnotation] = pruneTime.In(time.UTC).Format(UpdateTimestampLayout)
+	}
+
+	secret.StringData[key.CABundleID] = caBundle(secret.StringData[key.CAID], previousCA)
+}
+
+// shouldOverlapBeUpdated returns true in case the previous certificate
+// material of the given current secret is due to be pruned, or in case the
+// overlap is enabled and the secret does not yet carry a CA bundle.
+func (r *Resource) shouldOverlapBeUpdated(currentSecret *apiv1.Secret) bool {
+	if currentSecret == nil || currentSecret.Data == nil {
+		return false
+	}
+
+	if r.shouldPreviousBePruned(currentSecret) {
+		return true
+	}
+
+	_, ok := currentSecret.Data[key.CABundleID]
+
+	return r.renewalOverlap != 0 && !ok
+}
+
+func (r *Resource) shouldPreviousBePruned(currentSecret *apiv1.Secret) bool {
+	_, ok := currentSecret.Data[key.PreviousCrtID]
+	if !ok {
+		return false
+	}
+	if r.renewalOverlap == 0 {
+		return true
+	}
+
+	a, ok := currentSecret.Annotations[PreviousPruneTimestampAnnotation]
+	if !ok {
+		return true
+	}
+
+	t, err := time.ParseInLocation(UpdateTimestampLayout, a, time.UTC)
+	if err != nil {
+		return true
+	}
+
+	return !t.After(r.currentTimeFactory())
+}
+
+// updateOverlap prunes the previous certificate material of the given secret,
+// if due, and resets the CA bundle to the CA of the current certificate. The
+// CA bundle is kept when the overlap got disabled, so that consumers do not
+// lose it.
+func (r *Resource) updateOverlap(secret *apiv1.Secret) {
+	if r.shouldPreviousBePruned(secret) {
+		delete(secret.Data, key.PreviousCAID)
+		delete(secret.Data, key.PreviousCrtID)
+		delete(secret.Data, key.PreviousKeyID)
+		delete(secret.Annotations, PreviousPruneTimestampAnnotation)
+	}
+
+	secret.Data[key.CABundleID] = []byte(caBundle(string(secret.Data[key.CAID]), string(secret.Data[key.PreviousCAID])))
+}
+
+// caBundle concatenates the given PEM encoded CAs, omitting empty and
+// duplicated ones.
+func caBundle(cas ...string) string {
+	var bundle []string
+	for _, ca := range cas {
+		ca = strings.TrimSpace(ca)
+		if ca == "" || containsString(bundle, ca) {
+			continue
+		}
+
+		bundle = append(bundle, ca)
+	}
+
+	if len(bundle) == 0 {
+		return ""
+	}
+
+	return strings.Join(bundle, "\n") + "\n"
+}
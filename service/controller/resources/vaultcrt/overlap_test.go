@@ -0,0 +1,244 @@
+package vaultcrt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/vaultpki/vaultpkitest"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+func Test_Resource_VaultCrt_overlap(t *testing.T) {
+	now := time.Now()
+	overlap := time.Hour
+
+	customObject := &v1alpha1.CertConfig{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "al9qy-api",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				CommonName:       "api.al9qy.k8s.gigantic.io",
+				TTL:              "720h",
+			},
+		},
+	}
+
+	newTestResource := func(t *testing.T, now time.Time, overlap time.Duration) *Resource {
+		c := DefaultConfig()
+
+		c.CurrentTimeFactory = func() time.Time { return now }
+		c.K8sClient = fake.NewSimpleClientset()
+		c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+		c.Logger = microloggertest.New()
+		c.VaultCrt = newFakeVaultCrt(t, now)
+		c.VaultPKI = vaultpkitest.New()
+
+		c.ExpirationThreshold = 24 * time.Hour
+		c.Namespace = "default"
+		c.RenewalOverlap = overlap
+
+		r, err := New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+
+		return r
+	}
+
+	// expiredSecret returns a secret holding certificate material which is due
+	// for renewal at the given time.
+	expiredSecret := func(t *testing.T, r *Resource) (*apiv1.Secret, *apiv1.Secret) {
+		desiredSecret, err := r.GetDesiredState(context.TODO(), customObject)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+		currentSecret := desiredSecret.(*apiv1.Secret).DeepCopy()
+		currentSecret.Annotations[UpdateTimestampAnnotation] = now.Add(-720 * time.Hour).In(time.UTC).Format(UpdateTimestampLayout)
+		currentSecret.StringData = nil
+		currentSecret.Data = map[string][]byte{
+			key.CAID:  []byte("previous CA"),
+			key.CrtID: []byte("previous crt"),
+			key.KeyID: []byte("previous key"),
+		}
+
+		return currentSecret, desiredSecret.(*apiv1.Secret)
+	}
+
+	// Test 0 ensures renewals keep the previous certificate material and add
+	// the CA bundle in case the overlap is enabled.
+	{
+		r := newTestResource(t, now, overlap)
+		currentSecret, desiredSecret := expiredSecret(t, r)
+
+		result, err := r.newUpdateChange(context.TODO(), customObject, currentSecret, desiredSecret)
+		if err != nil {
+			t.Fatal("test 0 expected", nil, "got", err)
+		}
+		secret, err := toSecret(result)
+		if err != nil {
+			t.Fatal("test 0 expected", nil, "got", err)
+		}
+		if secret == nil {
+			t.Fatal("test 0 expected", "renewed secret", "got", nil)
+		}
+
+		if secret.StringData[key.PreviousCrtID] != "previous crt" {
+			t.Fatalf("test 0 expected previous crt %#q got %#q", "previous crt", secret.StringData[key.PreviousCrtID])
+		}
+		if secret.StringData[key.PreviousKeyID] != "previous key" {
+			t.Fatalf("test 0 expected previous key %#q got %#q", "previous key", secret.StringData[key.PreviousKeyID])
+		}
+		expectedBundle := caBundle(secret.StringData[key.CAID], "previous CA")
+		if secret.StringData[key.CABundleID] != expectedBundle {
+			t.Fatalf("test 0 expected CA bundle %#q got %#q", expectedBundle, secret.StringData[key.CABundleID])
+		}
+		expectedPrune := now.Add(overlap).In(time.UTC).Format(UpdateTimestampLayout)
+		if secret.Annotations[PreviousPruneTimestampAnnotation] != expectedPrune {
+			t.Fatalf("test 0 expected prune timestamp %#q got %#q", expectedPrune, secret.Annotations[PreviousPruneTimestampAnnotation])
+		}
+	}
+
+	// Test 1 ensures renewals overwrite the certificate material in place in
+	// case the overlap is disabled.
+	{
+		r := newTestResource(t, now, 0)
+		currentSecret, desiredSecret := expiredSecret(t, r)
+
+		result, err := r.newUpdateChange(context.TODO(), customObject, currentSecret, desiredSecret)
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+		secret, err := toSecret(result)
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+		for _, k := range []string{key.CABundleID, key.PreviousCAID, key.PreviousCrtID, key.PreviousKeyID} {
+			if _, ok := secret.StringData[k]; ok {
+				t.Fatalf("test 1 expected key %#q not to be set", k)
+			}
+		}
+	}
+
+	// Test 2 ensures renewals keep the CA bundle of secrets carrying one in
+	// case the overlap got disabled. The bundle only holds the new CA.
+	{
+		r := newTestResource(t, now, 0)
+		currentSecret, desiredSecret := expiredSecret(t, r)
+		currentSecret.Data[key.CABundleID] = []byte("previous CA\nolder CA\n")
+
+		result, err := r.newUpdateChange(context.TODO(), customObject, currentSecret, desiredSecret)
+		if err != nil {
+			t.Fatal("test 2 expected", nil, "got", err)
+		}
+		secret, err := toSecret(result)
+		if err != nil {
+			t.Fatal("test 2 expected", nil, "got", err)
+		}
+		expectedBundle := caBundle(secret.StringData[key.CAID])
+		if secret.StringData[key.CABundleID] != expectedBundle {
+			t.Fatalf("test 2 expected CA bundle %#q got %#q", expectedBundle, secret.StringData[key.CABundleID])
+		}
+		for _, k := range []string{key.PreviousCAID, key.PreviousCrtID, key.PreviousKeyID} {
+			if _, ok := secret.StringData[k]; ok {
+				t.Fatalf("test 2 expected key %#q not to be set", k)
+			}
+		}
+	}
+
+	// Test 3 ensures the previous certificate material is only pruned once the
+	// overlap period passed.
+	{
+		renewed := &apiv1.Secret{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Annotations: map[string]string{
+					PreviousPruneTimestampAnnotation: now.Add(overlap).In(time.UTC).Format(UpdateTimestampLayout),
+				},
+			},
+			Data: map[string][]byte{
+				key.CAID:          []byte("CA"),
+				key.CrtID:         []byte("crt"),
+				key.KeyID:         []byte("key"),
+				key.CABundleID:    []byte("CA\nprevious CA\n"),
+				key.PreviousCAID:  []byte("previous CA"),
+				key.PreviousCrtID: []byte("previous crt"),
+				key.PreviousKeyID: []byte("previous key"),
+			},
+		}
+
+		r := newTestResource(t, now.Add(overlap/2), overlap)
+		if r.shouldOverlapBeUpdated(renewed) {
+			t.Fatalf("test 3 expected %t got %t", false, true)
+		}
+
+		r = newTestResource(t, now.Add(overlap), overlap)
+		if !r.shouldOverlapBeUpdated(renewed) {
+			t.Fatalf("test 3 expected %t got %t", true, false)
+		}
+
+		pruned := renewed.DeepCopy()
+		r.updateOverlap(pruned)
+
+		for _, k := range []string{key.PreviousCAID, key.PreviousCrtID, key.PreviousKeyID} {
+			if _, ok := pruned.Data[k]; ok {
+				t.Fatalf("test 3 expected key %#q to be pruned", k)
+			}
+		}
+		if _, ok := pruned.Annotations[PreviousPruneTimestampAnnotation]; ok {
+			t.Fatalf("test 3 expected annotation %#q to be removed", PreviousPruneTimestampAnnotation)
+		}
+		if string(pruned.Data[key.CABundleID]) != "CA\n" {
+			t.Fatalf("test 3 expected CA bundle %#q got %#q", "CA\n", pruned.Data[key.CABundleID])
+		}
+	}
+}
+
+func Test_Resource_VaultCrt_caBundle(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		CAs      []string
+		Expected string
+	}{
+		{
+			Name:     "case 0: no CAs",
+			CAs:      []string{"", ""},
+			Expected: "",
+		},
+		{
+			Name:     "case 1: current CA only",
+			CAs:      []string{"CA\n", ""},
+			Expected: "CA\n",
+		},
+		{
+			Name:     "case 2: current and previous CA",
+			CAs:      []string{"CA\n", "previous CA\n"},
+			Expected: "CA\nprevious CA\n",
+		},
+		{
+			Name:     "case 3: unchanged CA",
+			CAs:      []string{"CA\n", "CA"},
+			Expected: "CA\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			bundle := caBundle(tc.CAs...)
+			if bundle != tc.Expected {
+				t.Fatalf("expected %#q got %#q", tc.Expected, bundle)
+			}
+		})
+	}
+}
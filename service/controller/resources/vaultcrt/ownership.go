@@ -0,0 +1,36 @@
+package vaultcrt
+
+import (
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+// isControlledByOther returns true in case the given secret is controlled by
+// another object than the given cert config, e.g. by another cert config
+// resolving to the same secret name.
+func isControlledByOther(customObject v1alpha1.CertConfig, secret *apiv1.Secret) bool {
+	ref := metav1.GetControllerOf(secret)
+	if ref == nil {
+		return false
+	}
+
+	return ref.UID != customObject.GetUID()
+}
+
+// shouldSecretBeAdopted returns true in case the given secret is not
+// controlled by any object yet. This is the case for secrets created by
+// earlier versions of the operator.
+func shouldSecretBeAdopted(secret *apiv1.Secret) bool {
+	if secret == nil {
+		return false
+	}
+
+	return metav1.GetControllerOf(secret) == nil
+}
+
+func adoptSecret(customObject v1alpha1.CertConfig, secret *apiv1.Secret) {
+	secret.OwnerReferences = append(secret.OwnerReferences, key.SecretOwnerReference(customObject))
+}
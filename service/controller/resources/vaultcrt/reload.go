@@ -0,0 +1,200 @@
+package vaultcrt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/annotation"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+const (
+	workloadKindDaemonSet   = "DaemonSet"
+	workloadKindDeployment  = "Deployment"
+	workloadKindStatefulSet = "StatefulSet"
+)
+
+type workload struct {
+	Kind    string
+	Name    string
+	Labels  map[string]string
+	PodSpec apiv1.PodSpec
+}
+
+// restartWorkloads restarts the Deployments, StatefulSets and DaemonSets
+// consuming the given renewed secret by patching the pod template annotation
+// annotation.RestartedForSerial with the serial number of the renewed
+// certificate. Workloads consume the secret when they reference it in their
+// pod spec or when their labels match the selector given in the
+// annotation.ReloadSelector annotation of the cert config. Failures to restart
+// single workloads are logged and counted but do not fail the update, because
+// the secret got already renewed at this point.
+func (r *Resource) restartWorkloads(ctx context.Context, customObject v1alpha1.CertConfig, secret *apiv1.Secret) error {
+	if !r.reloadWorkloads {
+		return nil
+	}
+	if secret.StringData == nil || secret.StringData[key.CrtID] == "" {
+		return nil
+	}
+
+	certificate, err := parseCertificate(secret.StringData[key.CrtID])
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	serial := certificate.SerialNumber.Text(16)
+
+	var selector labels.Selector
+	if s, ok := customObject.Annotations[annotation.ReloadSelector]; ok {
+		selector, err = labels.Parse(s)
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("ignoring invalid reload selector %#q", s), "stack", fmt.Sprintf("%#v", err))
+			selector = nil
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding the workloads consuming the secret")
+
+	workloads, err := r.listWorkloads(ctx, customObject.GetNamespace())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var consumers []workload
+	for _, w := range workloads {
+		if referencesSecret(w.PodSpec, secret.Name) || (selector != nil && selector.Matches(labels.Set(w.Labels))) {
+			consumers = append(consumers, w)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d workloads consuming the secret", len(consumers)))
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						annotation.RestartedForSerial: serial,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, w := range consumers {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("restarting %s %#q", w.Kind, w.Name))
+
+		err := r.patchWorkload(ctx, customObject.GetNamespace(), w, patch)
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to restart %s %#q", w.Kind, w.Name), "stack", fmt.Sprintf("%#v", err))
+			workloadRestartCounter.WithLabelValues(w.Kind, "failure").Inc()
+			continue
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("restarted %s %#q", w.Kind, w.Name))
+		workloadRestartCounter.WithLabelValues(w.Kind, "success").Inc()
+	}
+
+	return nil
+}
+
+func (r *Resource) listWorkloads(ctx context.Context, namespace string) ([]workload, error) {
+	var workloads []workload
+
+	{
+		list, err := r.k8sClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, d := range list.Items {
+			workloads = append(workloads, workload{Kind: workloadKindDeployment, Name: d.Name, Labels: d.Labels, PodSpec: d.Spec.Template.Spec})
+		}
+	}
+
+	{
+		list, err := r.k8sClient.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, s := range list.Items {
+			workloads = append(workloads, workload{Kind: workloadKindStatefulSet, Name: s.Name, Labels: s.Labels, PodSpec: s.Spec.Template.Spec})
+		}
+	}
+
+	{
+		list, err := r.k8sClient.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, d := range list.Items {
+			workloads = append(workloads, workload{Kind: workloadKindDaemonSet, Name: d.Name, Labels: d.Labels, PodSpec: d.Spec.Template.Spec})
+		}
+	}
+
+	return workloads, nil
+}
+
+func (r *Resource) patchWorkload(ctx context.Context, namespace string, w workload, patch []byte) error {
+	var err error
+	switch w.Kind {
+	case workloadKindDeployment:
+		_, err = r.k8sClient.AppsV1().Deployments(namespace).Patch(ctx, w.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	case workloadKindStatefulSet:
+		_, err = r.k8sClient.AppsV1().StatefulSets(namespace).Patch(ctx, w.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	case workloadKindDaemonSet:
+		_, err = r.k8sClient.AppsV1().DaemonSets(namespace).Patch(ctx, w.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	default:
+		return microerror.Maskf(wrongTypeError, "unknown workload kind %#q", w.Kind)
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// referencesSecret returns true in case the given pod spec mounts the secret
+// with the given name as volume or consumes it via environment variables.
+func referencesSecret(spec apiv1.PodSpec, name string) bool {
+	for _, v := range spec.Volumes {
+		if v.Secret != nil && v.Secret.SecretName == name {
+			return true
+		}
+		if v.Projected != nil {
+			for _, s := range v.Projected.Sources {
+				if s.Secret != nil && s.Secret.Name == name {
+					return true
+				}
+			}
+		}
+	}
+
+	var containers []apiv1.Container
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+
+	for _, c := range containers {
+		for _, e := range c.EnvFrom {
+			if e.SecretRef != nil && e.SecretRef.Name == name {
+				return true
+			}
+		}
+		for _, e := range c.Env {
+			if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil && e.ValueFrom.SecretKeyRef.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
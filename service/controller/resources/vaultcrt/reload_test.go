@@ -0,0 +1,199 @@
+package vaultcrt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/vaultcrt"
+	"github.com/giantswarm/vaultpki/vaultpkitest"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/pkg/annotation"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+func Test_Resource_VaultCrt_restartWorkloads(t *testing.T) {
+	now := time.Now()
+
+	customObject := v1alpha1.CertConfig{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "al9qy-api",
+			Namespace: "default",
+			Annotations: map[string]string{
+				annotation.ReloadSelector: "app=apiserver-proxy",
+			},
+		},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				CommonName:       "api.al9qy.k8s.gigantic.io",
+				TTL:              "720h",
+			},
+		},
+	}
+
+	podSpec := func(mutate func(s *apiv1.PodSpec)) appsv1.DeploymentSpec {
+		s := apiv1.PodSpec{Containers: []apiv1.Container{{Name: "main"}}}
+		mutate(&s)
+		return appsv1.DeploymentSpec{Template: apiv1.PodTemplateSpec{Spec: s}}
+	}
+
+	objects := []runtime.Object{
+		&appsv1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{Name: "volume", Namespace: "default"},
+			Spec: podSpec(func(s *apiv1.PodSpec) {
+				s.Volumes = []apiv1.Volume{{Name: "certs", VolumeSource: apiv1.VolumeSource{Secret: &apiv1.SecretVolumeSource{SecretName: "al9qy-api"}}}}
+			}),
+		},
+		&appsv1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{Name: "env-from", Namespace: "default"},
+			Spec: podSpec(func(s *apiv1.PodSpec) {
+				s.Containers[0].EnvFrom = []apiv1.EnvFromSource{{SecretRef: &apiv1.SecretEnvSource{LocalObjectReference: apiv1.LocalObjectReference{Name: "al9qy-api"}}}}
+			}),
+		},
+		&appsv1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{Name: "selected", Namespace: "default", Labels: map[string]string{"app": "apiserver-proxy"}},
+			Spec:       podSpec(func(s *apiv1.PodSpec) {}),
+		},
+		&appsv1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{Name: "unrelated", Namespace: "default"},
+			Spec: podSpec(func(s *apiv1.PodSpec) {
+				s.Volumes = []apiv1.Volume{{Name: "certs", VolumeSource: apiv1.VolumeSource{Secret: &apiv1.SecretVolumeSource{SecretName: "al9qy-worker"}}}}
+			}),
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: apismetav1.ObjectMeta{Name: "projected", Namespace: "default"},
+			Spec: appsv1.StatefulSetSpec{Template: apiv1.PodTemplateSpec{Spec: apiv1.PodSpec{
+				Volumes: []apiv1.Volume{{Name: "certs", VolumeSource: apiv1.VolumeSource{Projected: &apiv1.ProjectedVolumeSource{
+					Sources: []apiv1.VolumeProjection{{Secret: &apiv1.SecretProjection{LocalObjectReference: apiv1.LocalObjectReference{Name: "al9qy-api"}}}},
+				}}}},
+			}}},
+		},
+		&appsv1.DaemonSet{
+			ObjectMeta: apismetav1.ObjectMeta{Name: "other-namespace", Namespace: "kube-system"},
+			Spec: appsv1.DaemonSetSpec{Template: apiv1.PodTemplateSpec{Spec: apiv1.PodSpec{
+				Volumes: []apiv1.Volume{{Name: "certs", VolumeSource: apiv1.VolumeSource{Secret: &apiv1.SecretVolumeSource{SecretName: "al9qy-api"}}}},
+			}}},
+		},
+	}
+
+	f := newFakeVaultCrt(t, now)
+	result, err := f.Create(vaultcrt.CreateConfig{CommonName: customObject.Spec.Cert.CommonName, TTL: customObject.Spec.Cert.TTL})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	secret := &apiv1.Secret{
+		ObjectMeta: apismetav1.ObjectMeta{Name: "al9qy-api"},
+		StringData: map[string]string{
+			key.CAID:  result.CA,
+			key.CrtID: result.Crt,
+			key.KeyID: result.Key,
+		},
+	}
+
+	testCases := []struct {
+		Name              string
+		ReloadWorkloads   bool
+		ExpectedRestarted map[string]bool
+	}{
+		{
+			Name:            "case 0: reload disabled",
+			ReloadWorkloads: false,
+			ExpectedRestarted: map[string]bool{
+				"volume":          false,
+				"env-from":        false,
+				"selected":        false,
+				"unrelated":       false,
+				"projected":       false,
+				"other-namespace": false,
+			},
+		},
+		{
+			Name:            "case 1: reload enabled",
+			ReloadWorkloads: true,
+			ExpectedRestarted: map[string]bool{
+				"volume":          true,
+				"env-from":        true,
+				"selected":        true,
+				"unrelated":       false,
+				"projected":       true,
+				"other-namespace": false,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			k8sClient := fake.NewSimpleClientset(objects...)
+
+			var newResource *Resource
+			{
+				c := DefaultConfig()
+
+				c.CurrentTimeFactory = func() time.Time { return now }
+				c.K8sClient = k8sClient
+				c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+				c.Logger = microloggertest.New()
+				c.VaultCrt = f
+				c.VaultPKI = vaultpkitest.New()
+
+				c.ExpirationThreshold = 24 * time.Hour
+				c.Namespace = "default"
+				c.ReloadWorkloads = tc.ReloadWorkloads
+
+				newResource, err = New(c)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			err := newResource.restartWorkloads(context.TODO(), customObject, secret)
+			if err != nil {
+				t.Fatal("expected", nil, "got", err)
+			}
+
+			restarted := map[string]bool{}
+			{
+				deployments, err := k8sClient.AppsV1().Deployments("default").List(context.TODO(), apismetav1.ListOptions{})
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+				for _, d := range deployments.Items {
+					restarted[d.Name] = d.Spec.Template.Annotations[annotation.RestartedForSerial] == result.SerialNumber
+				}
+
+				statefulSets, err := k8sClient.AppsV1().StatefulSets("default").List(context.TODO(), apismetav1.ListOptions{})
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+				for _, s := range statefulSets.Items {
+					restarted[s.Name] = s.Spec.Template.Annotations[annotation.RestartedForSerial] == result.SerialNumber
+				}
+
+				daemonSets, err := k8sClient.AppsV1().DaemonSets("kube-system").List(context.TODO(), apismetav1.ListOptions{})
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+				for _, d := range daemonSets.Items {
+					restarted[d.Name] = d.Spec.Template.Annotations[annotation.RestartedForSerial] == result.SerialNumber
+				}
+			}
+
+			for name, expected := range tc.ExpectedRestarted {
+				if restarted[name] != expected {
+					t.Fatalf("expected %#q restarted to be %t got %t", name, expected, restarted[name])
+				}
+			}
+		})
+	}
+}
@@ -1,16 +1,26 @@
 package vaultcrt
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/context/resourcecanceledcontext"
 	"github.com/giantswarm/vaultcrt"
+	"github.com/giantswarm/vaultpki"
 	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/giantswarm/cert-operator/v3/pkg/annotation"
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultintermediate"
+	"github.com/giantswarm/cert-operator/v3/service/controller/context/requeuecontext"
 	"github.com/giantswarm/cert-operator/v3/service/controller/key"
 )
 
@@ -23,12 +33,25 @@ const (
 	// representation of the cert config. This is used to identify changes of the
 	// config to trigger renewals.
 	ConfigHashAnnotation = "cert.giantswarm.io/config-hash"
+	// ConfigHashVersionAnnotation is the annotation key used to track the
+	// version of the algorithm used to compute the config hash. Secrets without
+	// this annotation carry a hash of key.ConfigHashVersionLegacy.
+	ConfigHashVersionAnnotation = "cert.giantswarm.io/config-hash-version"
 	// UpdateTimestampAnnotation is the annotation key used to track the last
 	// update timestamp of certificates contained in the Kubernetes secrets.
 	UpdateTimestampAnnotation = "giantswarm.io/update-timestamp"
 	// UpdateTimestampLayout is the time layout used to format and parse the
 	// update timestamps tracked in the annotations of the Kubernetes secrets.
 	UpdateTimestampLayout = "2006-01-02T15:04:05.000000Z"
+	// InvalidCertificateAnnotation is the annotation key used to flag current
+	// secrets of which the certificate material did not pass inspection. The
+	// annotation value is the inspection failure reason. It is only ever set on
+	// the in-memory current state and never written to the Kubernetes API.
+	InvalidCertificateAnnotation = "cert-operator.giantswarm.io/invalid-certificate"
+	// PreviousPruneTimestampAnnotation is the annotation key used to track the
+	// time after which the previous certificate material kept in the
+	// Kubernetes secrets gets pruned.
+	PreviousPruneTimestampAnnotation = "cert-operator.giantswarm.io/previous-prune-timestamp"
 )
 
 type Config struct {
@@ -37,9 +60,36 @@ type Config struct {
 	K8sClient          kubernetes.Interface
 	Logger             micrologger.Logger
 	VaultCrt           vaultcrt.Interface
+	VaultPKI           vaultpki.Interface
+	// VaultIntermediate is optional. When given, the CA of secrets holds the
+	// chain of the issuing CA up to its root instead of the issuing CA only.
+	VaultIntermediate vaultintermediate.Interface
 
 	ExpirationThreshold time.Duration
 	Namespace           string
+	// ReloadWorkloads defines whether to restart the Deployments, StatefulSets
+	// and DaemonSets consuming a certificate secret after its renewal.
+	ReloadWorkloads bool
+	// RenewalBudget is the maximum number of certificate renewals per minute
+	// across all tenant clusters, including the repairs of invalid
+	// certificates. Renewals exceeding the budget are deferred until the
+	// budget got refilled. Zero disables the budget.
+	RenewalBudget int
+	// RenewalBudgetPerCluster is the same as RenewalBudget but applies to each
+	// tenant cluster individually.
+	RenewalBudgetPerCluster int
+	// RenewalJitter is the maximum delay added to the renewal time of each
+	// certificate. The delay is derived from the certificate identity so that
+	// it stays the same across reconciliations. It must be smaller than
+	// ExpirationThreshold.
+	RenewalJitter time.Duration
+	// RenewalOverlap is the period during which the previous certificate
+	// material is kept in the secret after a renewal, so that consumers
+	// reloading lazily can keep on using it. Zero disables the overlap.
+	RenewalOverlap time.Duration
+	// TrustDomains maps cluster components to the trust domains whose PKI
+	// backend issues their certificates.
+	TrustDomains trustdomain.Mapping
 }
 
 func DefaultConfig() Config {
@@ -49,9 +99,17 @@ func DefaultConfig() Config {
 		K8sClient:          nil,
 		Logger:             nil,
 		VaultCrt:           nil,
+		VaultPKI:           nil,
+		VaultIntermediate:  nil,
 
-		ExpirationThreshold: 0,
-		Namespace:           "",
+		ExpirationThreshold:     0,
+		Namespace:               "",
+		ReloadWorkloads:         false,
+		RenewalBudget:           0,
+		RenewalBudgetPerCluster: 0,
+		RenewalJitter:           0,
+		RenewalOverlap:          0,
+		TrustDomains:            nil,
 	}
 }
 
@@ -61,9 +119,17 @@ type Resource struct {
 	k8sClient          kubernetes.Interface
 	logger             micrologger.Logger
 	vaultCrt           vaultcrt.Interface
+	vaultPKI           vaultpki.Interface
+	vaultIntermediate  vaultintermediate.Interface
 
 	expirationThreshold time.Duration
 	namespace           string
+	reloadWorkloads     bool
+	renewalBudget       *renewalBudget
+	renewalJitter       time.Duration
+	renewalOverlap      time.Duration
+	trustDomains        trustdomain.Mapping
+	verificationBackoff *verificationBackoff
 }
 
 func New(config Config) (*Resource, error) {
@@ -82,6 +148,9 @@ func New(config Config) (*Resource, error) {
 	if config.VaultCrt == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.VaultCrt must not be empty")
 	}
+	if config.VaultPKI == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.VaultPKI must not be empty")
+	}
 
 	if config.ExpirationThreshold == 0 {
 		return nil, microerror.Maskf(invalidConfigError, "config.ExpirationThreshold must not be empty")
@@ -89,6 +158,18 @@ func New(config Config) (*Resource, error) {
 	if config.Namespace == "" {
 		return nil, microerror.Maskf(invalidConfigError, "config.Namespace must not be empty")
 	}
+	if config.RenewalBudget < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "config.RenewalBudget must not be negative")
+	}
+	if config.RenewalBudgetPerCluster < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "config.RenewalBudgetPerCluster must not be negative")
+	}
+	if config.RenewalJitter < 0 || config.RenewalJitter >= config.ExpirationThreshold {
+		return nil, microerror.Maskf(invalidConfigError, "config.RenewalJitter must be within [0, config.ExpirationThreshold)")
+	}
+	if config.RenewalOverlap < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "config.RenewalOverlap must not be negative")
+	}
 
 	r := &Resource{
 		currentTimeFactory: config.CurrentTimeFactory,
@@ -97,10 +178,18 @@ func New(config Config) (*Resource, error) {
 		logger: config.Logger.With(
 			"resource", Name,
 		),
-		vaultCrt: config.VaultCrt,
+		vaultCrt:          config.VaultCrt,
+		vaultPKI:          config.VaultPKI,
+		vaultIntermediate: config.VaultIntermediate,
 
 		expirationThreshold: config.ExpirationThreshold,
 		namespace:           config.Namespace,
+		reloadWorkloads:     config.ReloadWorkloads,
+		renewalBudget:       newRenewalBudget(config.RenewalBudget, config.RenewalBudgetPerCluster),
+		renewalJitter:       config.RenewalJitter,
+		renewalOverlap:      config.RenewalOverlap,
+		trustDomains:        config.TrustDomains,
+		verificationBackoff: newVerificationBackoff(),
 	}
 
 	return r, nil
@@ -110,21 +199,74 @@ func (r *Resource) Name() string {
 	return Name
 }
 
-func (r *Resource) issueCertificate(customObject v1alpha1.CertConfig) (string, string, string, error) {
+func (r *Resource) issueCertificate(ctx context.Context, customObject v1alpha1.CertConfig) (string, string, string, error) {
+	ttl, err := r.certificateTTL(ctx, customObject)
+	if err != nil {
+		return "", "", "", microerror.Mask(err)
+	}
+
 	c := vaultcrt.CreateConfig{
 		AltNames:      key.AltNames(customObject),
 		CommonName:    key.CommonName(customObject),
-		ID:            key.ClusterID(customObject),
+		ID:            key.PKIID(customObject, r.trustDomains),
 		IPSANs:        key.IPSANs(customObject),
 		Organizations: key.Organizations(customObject),
-		TTL:           key.CrtTTL(customObject),
+		TTL:           ttl,
 	}
 	result, err := r.vaultCrt.Create(c)
 	if err != nil {
 		return "", "", "", microerror.Mask(err)
 	}
 
-	return result.CA, result.Crt, result.Key, nil
+	ca := result.CA
+	if r.vaultIntermediate != nil {
+		chain, err := r.vaultIntermediate.CAChain(c.ID)
+		if err != nil {
+			return "", "", "", microerror.Mask(err)
+		}
+		if chain != "" {
+			ca = chain
+		}
+	}
+
+	return ca, result.Crt, result.Key, nil
+}
+
+// removeForceRenew removes the force renew annotation and label from the given
+// cert config once its certificate got issued, so that the certificate is not
+// reissued over and over again.
+func (r *Resource) removeForceRenew(ctx context.Context, customObject v1alpha1.CertConfig) error {
+	if !key.ForceRenew(customObject) {
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "removing the force renew request from the cert config")
+
+	cc := customObject.DeepCopy()
+	patch := client.MergeFrom(customObject.DeepCopy())
+
+	delete(cc.Annotations, annotation.ForceRenew)
+	delete(cc.Labels, label.ForceRenew)
+
+	err := r.ctrlClient.Patch(ctx, cc, patch)
+	if apierrors.IsNotFound(err) {
+		// fall through
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "removed the force renew request from the cert config")
+
+	return nil
+}
+
+// deferIssuance defers the issuance of the certificate of the reconciled cert
+// config by the given delay, after which the cert config is reconciled again.
+func (r *Resource) deferIssuance(ctx context.Context, delay time.Duration, reason string) {
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deferring the issuance of the certificate by %s due to %s", delay, reason))
+	requeuecontext.SetRequeueAfter(ctx, delay)
+	r.logger.LogCtx(ctx, "level", "debug", "message", "canceling resource")
+	resourcecanceledcontext.SetCanceled(ctx)
 }
 
 func toSecret(v interface{}) (*apiv1.Secret, error) {
@@ -0,0 +1,87 @@
+package vaultcrt
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultpki"
+
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+// certificateTTL returns the TTL to request for the certificate of the given
+// cert config. It is the TTL of the cert config, capped at the remaining
+// lifetime of the CA of its PKI backend. Vault would otherwise either reject
+// the request or silently truncate the certificate at the expiration of the
+// CA, depending on its version. Cert configs without TTL get the default TTL
+// of their role.
+func (r *Resource) certificateTTL(ctx context.Context, customObject v1alpha1.CertConfig) (string, error) {
+	if key.CrtTTL(customObject) == "" {
+		return "", nil
+	}
+
+	ttl, err := time.ParseDuration(key.CrtTTL(customObject))
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	certificateAuthority, err := r.vaultPKI.GetCACertificate(key.PKIID(customObject, r.trustDomains))
+	if vaultpki.IsNotFound(err) {
+		return key.CrtTTL(customObject), nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	ca, err := parseCertificate(certificateAuthority.Certificate)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "not capping the certificate TTL due to the unparsable CA", "stack", fmt.Sprintf("%#v", err))
+		return key.CrtTTL(customObject), nil
+	}
+
+	capped := cappedTTL(ttl, ca.NotAfter, r.currentTimeFactory())
+	if capped == ttl {
+		return key.CrtTTL(customObject), nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("capping the certificate TTL at %s due to the expiration of the CA at %s", capped, ca.NotAfter.UTC().Format(time.RFC3339)))
+
+	return capped.String(), nil
+}
+
+// cappedTTL caps the given TTL at the remaining lifetime of a CA expiring at
+// the given time. In case the CA expired already, the TTL is returned as is
+// and issuing the certificate fails.
+func cappedTTL(ttl time.Duration, caNotAfter, now time.Time) time.Duration {
+	remaining := caNotAfter.Sub(now).Truncate(time.Second)
+	if remaining <= 0 || remaining >= ttl {
+		return ttl
+	}
+
+	return remaining
+}
+
+// isTruncatedAtIssuer returns whether the given certificate expires together
+// with the CA of the given PEM encoded CA bundle which issued it. The validity
+// of such certificates cannot be extended by renewing them.
+func isTruncatedAtIssuer(certificate *x509.Certificate, ca string) bool {
+	rest := []byte(ca)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return false
+		}
+
+		issuer, err := x509.ParseCertificate(block.Bytes)
+		if err != nil || certificate.CheckSignatureFrom(issuer) != nil {
+			continue
+		}
+
+		return !certificate.NotAfter.Before(issuer.NotAfter.Add(-TTLTolerance))
+	}
+}
@@ -0,0 +1,146 @@
+package vaultcrt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+func Test_Resource_VaultCrt_cappedTTL(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		ttl         time.Duration
+		caNotAfter  time.Time
+		expectedTTL time.Duration
+	}{
+		{
+			name:        "case 0: TTLs within the lifetime of the CA are not capped",
+			ttl:         720 * time.Hour,
+			caNotAfter:  now.Add(1000 * time.Hour),
+			expectedTTL: 720 * time.Hour,
+		},
+		{
+			name:        "case 1: TTLs exceeding the lifetime of the CA are capped",
+			ttl:         720 * time.Hour,
+			caNotAfter:  now.Add(240 * time.Hour),
+			expectedTTL: 240 * time.Hour,
+		},
+		{
+			name:        "case 2: TTLs of expired CAs are not capped",
+			ttl:         720 * time.Hour,
+			caNotAfter:  now.Add(-time.Hour),
+			expectedTTL: 720 * time.Hour,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ttl := cappedTTL(tc.ttl, tc.caNotAfter, now)
+			if ttl != tc.expectedTTL {
+				t.Fatalf("expected %s got %s", tc.expectedTTL, ttl)
+			}
+		})
+	}
+}
+
+// Test_Resource_VaultCrt_issueCertificate_caExpiry ensures that certificates
+// of CAs expiring before their TTL are issued until the expiration of the CA,
+// pass verification and are not renewed over and over again.
+func Test_Resource_VaultCrt_issueCertificate_caExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	caNotAfter := now.Add(240 * time.Hour)
+
+	customObject := v1alpha1.CertConfig{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "al9qy-api",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				CommonName:       "api.al9qy.k8s.gigantic.io",
+				TTL:              "720h",
+			},
+		},
+	}
+
+	var newResource *Resource
+	{
+		vaultCrt := newFakeVaultCrtWithCAExpiry(t, now, caNotAfter)
+
+		c := DefaultConfig()
+
+		c.CurrentTimeFactory = func() time.Time { return now }
+		c.K8sClient = fake.NewSimpleClientset()
+		c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+		c.Logger = microloggertest.New()
+		c.VaultCrt = vaultCrt
+		c.VaultPKI = newFakeVaultPKI(vaultCrt)
+
+		c.ExpirationThreshold = 24 * time.Hour
+		c.Namespace = "default"
+
+		var err error
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	ca, crt, k, err := newResource.issueCertificate(context.TODO(), customObject)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	certificate, err := parseCertificate(crt)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if !certificate.NotAfter.Equal(caNotAfter) {
+		t.Fatalf("expected certificate to expire at %s got %s", caNotAfter, certificate.NotAfter)
+	}
+
+	_, err = verifyCertificate(customObject, ca, crt, k, now)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	// Even within the expiration threshold of the certificate, renewing it
+	// would not extend its validity.
+	newResource.currentTimeFactory = func() time.Time { return caNotAfter.Add(-time.Hour) }
+
+	secret := &apiv1.Secret{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Annotations: map[string]string{
+				ConfigHashAnnotation:      "hash",
+				UpdateTimestampAnnotation: now.Format(UpdateTimestampLayout),
+			},
+		},
+		Data: map[string][]byte{
+			key.CAID:  []byte(ca),
+			key.CrtID: []byte(crt),
+			key.KeyID: []byte(k),
+		},
+	}
+
+	renew, err := newResource.shouldCertBeRenewed(customObject, secret, secret, 720*time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if renew {
+		t.Fatalf("expected certificate expiring with its CA not to be renewed")
+	}
+}
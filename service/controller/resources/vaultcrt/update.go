@@ -2,6 +2,7 @@ package vaultcrt
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
@@ -25,6 +26,12 @@ func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange inte
 		if err != nil {
 			return microerror.Mask(err)
 		}
+
+		err = r.verifySecret(ctx, customObject, secretToUpdate)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
 		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the secret in the Kubernetes API")
 
 		_, err = r.k8sClient.CoreV1().Secrets(customObject.GetNamespace()).Update(ctx, secretToUpdate, metav1.UpdateOptions{})
@@ -33,6 +40,16 @@ func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange inte
 		}
 
 		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the secret in the Kubernetes API")
+
+		err = r.restartWorkloads(ctx, customObject, secretToUpdate)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = r.removeForceRenew(ctx, customObject)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 	} else {
 		r.logger.LogCtx(ctx, "level", "debug", "message", "the secret does not need to be updated in the Kubernetes API")
 	}
@@ -88,16 +105,110 @@ func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desir
 			return nil, microerror.Mask(err)
 		}
 
+		// Explicitly requested renewals are neither subject to the renewal
+		// criteria nor to the renewal budget. They are delayed like all other
+		// renewals in case the previously issued certificate failed
+		// verification though.
+		forced := currentSecret != nil && key.ForceRenew(customObject)
+		if forced {
+			r.logger.LogCtx(ctx, "level", "debug", "message", "cert config asks for the certificate to be renewed")
+			renew = true
+		}
+
+		// Secrets with corrupted or tampered certificate material are repaired
+		// regardless of their expiration, unless the cert config asks to disable
+		// regeneration. Repairs are subject to the renewal budget all the same,
+		// since e.g. a replaced CA invalidates all certificates of a cluster at
+		// once.
+		repair := currentSecret != nil && currentSecret.Annotations[InvalidCertificateAnnotation] != ""
+		if repair {
+			if isRegenerationDisabled(customObject) {
+				r.logger.LogCtx(ctx, "level", "warning", "message", "not repairing the invalid certificate of the secret due to disabled regeneration")
+			} else {
+				r.logger.LogCtx(ctx, "level", "debug", "message", "repairing the invalid certificate of the secret")
+				renew = true
+			}
+		}
+
 		if renew {
-			ca, crt, k, err := r.issueCertificate(customObject)
-			if err != nil {
-				return nil, microerror.Mask(err)
+			now := r.currentTimeFactory()
+
+			var reservation *renewalReservation
+			var delay time.Duration
+			var reason string
+			{
+				delay = r.verificationBackoff.Delay(verificationBackoffID(customObject), now)
+				reason = "the failed verification of the previously issued certificate"
+			}
+			if delay == 0 && !forced {
+				var scope string
+				reservation, delay, scope = r.renewalBudget.Reserve(key.ClusterID(customObject), now)
+				renewalBudgetGauge.Set(r.renewalBudget.Available(now))
+
+				if delay != 0 {
+					reason = fmt.Sprintf("the exhausted %s renewal budget", scope)
+					renewalDeferredCounter.WithLabelValues(scope).Inc()
+				}
+			}
+
+			if delay == 0 {
+				ca, crt, k, err := r.issueCertificate(ctx, customObject)
+				if err != nil {
+					// Renewals which did not happen must not use up the budget.
+					reservation.Cancel(r.currentTimeFactory())
+					return nil, microerror.Mask(err)
+				}
+
+				secretToUpdate = desiredSecret
+				secretToUpdate.StringData[key.CAID] = ca
+				secretToUpdate.StringData[key.CrtID] = crt
+				secretToUpdate.StringData[key.KeyID] = k
+				r.addOverlap(currentSecret, secretToUpdate)
+				keepClusterAPIData(currentSecret, secretToUpdate)
+
+				renewalCounter.Inc()
+			} else {
+				// The renewal is not considered a failure. The cert config is
+				// reconciled again once the delay passed.
+				r.deferIssuance(ctx, delay, reason)
 			}
+		} else if currentSecret != nil {
+			// The certificates are still valid for the current config. The
+			// following updates only touch the current secret's metadata or the
+			// material it already holds.
+			secret := currentSecret.DeepCopy()
+			delete(secret.Annotations, InvalidCertificateAnnotation)
+
+			var changed bool
+
+			if shouldHashBeMigrated(currentSecret, desiredSecret) {
+				r.logger.LogCtx(ctx, "level", "debug", "message", "migrating the config hash annotations of the secret")
+
+				// We update the config hash annotations to the current hash
+				// version so that later changes of the hash algorithm do not
+				// require renewals.
+				secret.Annotations[ConfigHashAnnotation] = desiredSecret.Annotations[ConfigHashAnnotation]
+				secret.Annotations[ConfigHashVersionAnnotation] = desiredSecret.Annotations[ConfigHashVersionAnnotation]
+				changed = true
+			}
+
+			if r.shouldOverlapBeUpdated(currentSecret) {
+				r.logger.LogCtx(ctx, "level", "debug", "message", "updating the previous certificate material of the secret")
+
+				r.updateOverlap(secret)
+				changed = true
+			}
+
+			if shouldSecretBeAdopted(currentSecret) {
+				r.logger.LogCtx(ctx, "level", "debug", "message", "adopting the secret")
 
-			secretToUpdate = desiredSecret
-			secretToUpdate.StringData[key.CAID] = ca
-			secretToUpdate.StringData[key.CrtID] = crt
-			secretToUpdate.StringData[key.KeyID] = k
+				adoptSecret(customObject, secret)
+				changed = true
+			}
+
+			if changed {
+				secretToUpdate = secret
+			}
 		}
 	}
 
@@ -125,12 +236,7 @@ func (r *Resource) shouldCertBeRenewed(customObject v1alpha1.CertConfig, current
 
 	// Check if the cert configs ask to disable regeneration.
 	{
-		// TODO remove this hack once all cert configs are updated with the correct
-		// value for DisableRegeneration.
-		if customObject.Spec.Cert.ClusterComponent == string(certs.ServiceAccountCert) {
-			return false, nil
-		}
-		if customObject.Spec.Cert.DisableRegeneration {
+		if isRegenerationDisabled(customObject) {
 			return false, nil
 		}
 	}
@@ -147,12 +253,30 @@ func (r *Resource) shouldCertBeRenewed(customObject v1alpha1.CertConfig, current
 			return false, microerror.Mask(err)
 		}
 
-		if t.Add(TTL).Add(-threshold).Before(r.currentTimeFactory()) {
+		jitter := renewalJitter(key.ClusterID(customObject)+"/"+key.ClusterComponent(customObject), r.renewalJitter)
+
+		// Certificates expiring before their TTL got truncated, e.g. at the
+		// expiration of their CA, and are renewed based on their actual
+		// expiration. Certificates expiring together with their CA are not
+		// renewed due to their expiration, since the renewed certificate would
+		// be truncated all the same.
+		expiration := t.Add(TTL)
+		renewable := true
+		if certificate, err := parseCertificate(string(currentSecret.Data[key.CrtID])); err == nil && certificate.NotAfter.Before(expiration) {
+			expiration = certificate.NotAfter
+			renewable = !isTruncatedAtIssuer(certificate, string(currentSecret.Data[key.CAID]))
+		}
+
+		if renewable && expiration.Add(-threshold).Add(jitter).Before(r.currentTimeFactory()) {
+			renewalJitterHistogram.Observe(jitter.Seconds())
 			return true, nil
 		}
 	}
 
-	// Check the config hash annotation.
+	// Check the config hash annotation. In case the current secret carries a
+	// hash of another hash version than the desired secret, we compute the hash
+	// of the cert config using the hash version of the current secret. That way
+	// changes of the hash algorithm do not cause mass renewals.
 	{
 		c, ok := currentSecret.Annotations[ConfigHashAnnotation]
 		if !ok {
@@ -163,6 +287,20 @@ func (r *Resource) shouldCertBeRenewed(customObject v1alpha1.CertConfig, current
 			return false, microerror.Maskf(missingAnnotationError, "desired secret")
 		}
 
+		cv := currentSecret.Annotations[ConfigHashVersionAnnotation]
+		dv := desiredSecret.Annotations[ConfigHashVersionAnnotation]
+
+		if cv != dv {
+			h, err := key.ConfigHashForVersion(customObject, cv)
+			if key.IsUnknownHashVersion(err) {
+				return true, nil
+			} else if err != nil {
+				return false, microerror.Mask(err)
+			}
+
+			d = h
+		}
+
 		if c != d {
 			return true, nil
 		}
@@ -170,3 +308,55 @@ func (r *Resource) shouldCertBeRenewed(customObject v1alpha1.CertConfig, current
 
 	return false, nil
 }
+
+func isRegenerationDisabled(customObject v1alpha1.CertConfig) bool {
+	// TODO remove this hack once all cert configs are updated with the correct
+	// value for DisableRegeneration.
+	if customObject.Spec.Cert.ClusterComponent == string(certs.ServiceAccountCert) {
+		return true
+	}
+
+	return customObject.Spec.Cert.DisableRegeneration
+}
+
+// shouldHashBeMigrated returns true in case the config hash annotations of the
+// current secret do not match the ones of the desired secret. It is only
+// meaningful to call it after shouldCertBeRenewed returned false, which means
+// both hashes represent the same cert config.
+func shouldHashBeMigrated(currentSecret, desiredSecret *apiv1.Secret) bool {
+	if currentSecret == nil || currentSecret.Annotations == nil {
+		return false
+	}
+	if desiredSecret == nil || desiredSecret.Annotations == nil {
+		return false
+	}
+
+	d, ok := desiredSecret.Annotations[ConfigHashAnnotation]
+	if !ok {
+		return false
+	}
+	if currentSecret.Annotations[ConfigHashAnnotation] != d {
+		return true
+	}
+	if currentSecret.Annotations[ConfigHashVersionAnnotation] != desiredSecret.Annotations[ConfigHashVersionAnnotation] {
+		return true
+	}
+
+	return false
+}
+
+// keepClusterAPIData keeps the CA Cluster API reads from the tls.crt and
+// tls.key keys of the current secret in the given renewed secret. The etcd CA
+// secret of Cluster API has the name of the certificate secret of the etcd
+// cert config, so the capisecret resource merges the CA into it.
+func keepClusterAPIData(currentSecret, secret *apiv1.Secret) {
+	if currentSecret == nil {
+		return
+	}
+
+	for _, k := range []string{apiv1.TLSCertKey, apiv1.TLSPrivateKeyKey} {
+		if v, ok := currentSecret.Data[k]; ok {
+			secret.StringData[k] = string(v)
+		}
+	}
+}
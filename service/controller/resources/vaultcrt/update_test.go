@@ -1,19 +1,29 @@
 package vaultcrt
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
 	"github.com/giantswarm/certs/v4/pkg/certs"
 	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/context/resourcecanceledcontext"
+	"github.com/giantswarm/vaultcrt"
 	"github.com/giantswarm/vaultcrt/vaultcrttest"
+	"github.com/giantswarm/vaultpki/vaultpkitest"
 	apiv1 "k8s.io/api/core/v1"
 	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/pkg/annotation"
+	"github.com/giantswarm/cert-operator/v3/service/controller/context/requeuecontext"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
 )
 
 func Test_Resource_VaultCrt_shouldCertBeRenewed_expiration(t *testing.T) {
@@ -346,6 +356,7 @@ func Test_Resource_VaultCrt_shouldCertBeRenewed_expiration(t *testing.T) {
 			c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(scheme).Build()
 			c.Logger = microloggertest.New()
 			c.VaultCrt = vaultcrttest.New()
+			c.VaultPKI = vaultpkitest.New()
 
 			c.ExpirationThreshold = 24 * time.Hour
 			c.Namespace = "default"
@@ -517,6 +528,85 @@ func Test_Resource_VaultCrt_shouldCertBeRenewed_hash(t *testing.T) {
 			ErrorMatcher:   nil,
 			ExpectedResult: false,
 		},
+
+		// Test 6 ensures a current secret carrying a legacy config hash without
+		// hash version annotation, which matches the legacy hash of the cert
+		// config, does not cause the secret to be renewed even though the desired
+		// secret carries a hash of another version.
+		{
+			CustomObject: v1alpha1.CertConfig{},
+			CurrentSecret: &apiv1.Secret{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Annotations: map[string]string{
+						ConfigHashAnnotation:      "9a5b86b8bd8d3a2a8ce441e290c1db8d69ad8726",
+						UpdateTimestampAnnotation: time.Unix(10, 0).In(time.UTC).Format(UpdateTimestampLayout),
+					},
+				},
+			},
+			DesiredSecret: &apiv1.Secret{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Annotations: map[string]string{
+						ConfigHashAnnotation:        "f795ead93ec598284b246872fc6def0cc8c6f0c656f84d3080d5941b61f7e4c8",
+						ConfigHashVersionAnnotation: "2",
+						UpdateTimestampAnnotation:   time.Unix(10, 0).In(time.UTC).Format(UpdateTimestampLayout),
+					},
+				},
+			},
+			ErrorMatcher:   nil,
+			ExpectedResult: false,
+		},
+
+		// Test 7 is the same as 6 but with a legacy config hash not matching the
+		// cert config, which means the cert config changed and the secret has to
+		// be renewed.
+		{
+			CustomObject: v1alpha1.CertConfig{},
+			CurrentSecret: &apiv1.Secret{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Annotations: map[string]string{
+						ConfigHashAnnotation:      "outdated",
+						UpdateTimestampAnnotation: time.Unix(10, 0).In(time.UTC).Format(UpdateTimestampLayout),
+					},
+				},
+			},
+			DesiredSecret: &apiv1.Secret{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Annotations: map[string]string{
+						ConfigHashAnnotation:        "f795ead93ec598284b246872fc6def0cc8c6f0c656f84d3080d5941b61f7e4c8",
+						ConfigHashVersionAnnotation: "2",
+						UpdateTimestampAnnotation:   time.Unix(10, 0).In(time.UTC).Format(UpdateTimestampLayout),
+					},
+				},
+			},
+			ErrorMatcher:   nil,
+			ExpectedResult: true,
+		},
+
+		// Test 8 ensures an unknown hash version of the current secret causes the
+		// secret to be renewed.
+		{
+			CustomObject: v1alpha1.CertConfig{},
+			CurrentSecret: &apiv1.Secret{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Annotations: map[string]string{
+						ConfigHashAnnotation:        "f795ead93ec598284b246872fc6def0cc8c6f0c656f84d3080d5941b61f7e4c8",
+						ConfigHashVersionAnnotation: "99",
+						UpdateTimestampAnnotation:   time.Unix(10, 0).In(time.UTC).Format(UpdateTimestampLayout),
+					},
+				},
+			},
+			DesiredSecret: &apiv1.Secret{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Annotations: map[string]string{
+						ConfigHashAnnotation:        "f795ead93ec598284b246872fc6def0cc8c6f0c656f84d3080d5941b61f7e4c8",
+						ConfigHashVersionAnnotation: "2",
+						UpdateTimestampAnnotation:   time.Unix(10, 0).In(time.UTC).Format(UpdateTimestampLayout),
+					},
+				},
+			},
+			ErrorMatcher:   nil,
+			ExpectedResult: true,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -532,6 +622,7 @@ func Test_Resource_VaultCrt_shouldCertBeRenewed_hash(t *testing.T) {
 			c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(scheme).Build()
 			c.Logger = microloggertest.New()
 			c.VaultCrt = vaultcrttest.New()
+			c.VaultPKI = vaultpkitest.New()
 
 			c.ExpirationThreshold = 24 * time.Hour
 			c.Namespace = "default"
@@ -556,3 +647,514 @@ func Test_Resource_VaultCrt_shouldCertBeRenewed_hash(t *testing.T) {
 		}
 	}
 }
+
+func Test_Resource_VaultCrt_newUpdateChange_hashMigration(t *testing.T) {
+	var err error
+	var newResource *Resource
+	{
+		c := DefaultConfig()
+		scheme := runtime.NewScheme()
+		_ = capi.AddToScheme(scheme)
+
+		c.CurrentTimeFactory = func() time.Time { return time.Unix(20, 0).In(time.UTC) }
+		c.K8sClient = fake.NewSimpleClientset()
+		c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(scheme).Build()
+		c.Logger = microloggertest.New()
+		c.VaultCrt = vaultcrttest.New()
+		c.VaultPKI = vaultpkitest.New()
+
+		c.ExpirationThreshold = 24 * time.Hour
+		c.Namespace = "default"
+
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	customObject := &v1alpha1.CertConfig{
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				TTL: "720h",
+			},
+		},
+	}
+	legacyHash, err := key.CustomObjectHash(*customObject)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	currentSecret := &apiv1.Secret{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Annotations: map[string]string{
+				ConfigHashAnnotation:      legacyHash,
+				UpdateTimestampAnnotation: time.Unix(10, 0).In(time.UTC).Format(UpdateTimestampLayout),
+			},
+		},
+		Data: map[string][]byte{
+			"crt": []byte("current crt"),
+		},
+	}
+
+	desiredSecret, err := newResource.GetDesiredState(context.TODO(), customObject)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	result, err := newResource.newUpdateChange(context.TODO(), customObject, currentSecret, desiredSecret)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	secret, err := toSecret(result)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if secret == nil {
+		t.Fatal("expected", "secret with migrated annotations", "got", nil)
+	}
+
+	if secret.Annotations[ConfigHashVersionAnnotation] != key.ConfigHashVersion {
+		t.Fatalf("expected hash version %#q got %#q", key.ConfigHashVersion, secret.Annotations[ConfigHashVersionAnnotation])
+	}
+	if secret.Annotations[ConfigHashAnnotation] == legacyHash {
+		t.Fatalf("expected config hash to be migrated, got legacy hash %#q", legacyHash)
+	}
+	if secret.Annotations[UpdateTimestampAnnotation] != currentSecret.Annotations[UpdateTimestampAnnotation] {
+		t.Fatalf("expected update timestamp %#q got %#q", currentSecret.Annotations[UpdateTimestampAnnotation], secret.Annotations[UpdateTimestampAnnotation])
+	}
+	if string(secret.Data["crt"]) != "current crt" {
+		t.Fatalf("expected certificate to be kept, got %#q", secret.Data["crt"])
+	}
+}
+
+func Test_Resource_VaultCrt_forceRenew(t *testing.T) {
+	now := time.Now()
+
+	customObject := &v1alpha1.CertConfig{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "al9qy-api",
+			Namespace: "default",
+			Annotations: map[string]string{
+				annotation.ForceRenew: "true",
+			},
+		},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent:    "api",
+				ClusterID:           "al9qy",
+				DisableRegeneration: true,
+				TTL:                 "720h",
+			},
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		c := DefaultConfig()
+		scheme := runtime.NewScheme()
+		_ = capi.AddToScheme(scheme)
+		_ = v1alpha1.AddToScheme(scheme)
+
+		c.CurrentTimeFactory = func() time.Time { return now }
+		c.K8sClient = fake.NewSimpleClientset(&apiv1.Secret{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      "al9qy-api",
+				Namespace: "default",
+			},
+		})
+		c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(scheme).WithObjects(customObject.DeepCopy()).Build()
+		c.Logger = microloggertest.New()
+		c.VaultCrt = newFakeVaultCrt(t, now)
+		c.VaultPKI = vaultpkitest.New()
+
+		c.ExpirationThreshold = 24 * time.Hour
+		c.Namespace = "default"
+
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	desiredSecret, err := newResource.GetDesiredState(context.TODO(), customObject)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	currentSecret := desiredSecret.(*apiv1.Secret).DeepCopy()
+	currentSecret.Namespace = "default"
+	// The Cluster API CA merged into the secret by the capisecret resource
+	// must survive the renewal.
+	currentSecret.Data = map[string][]byte{
+		apiv1.TLSCertKey:       []byte("capi-ca"),
+		apiv1.TLSPrivateKeyKey: []byte("capi-ca-key"),
+	}
+
+	result, err := newResource.newUpdateChange(context.TODO(), customObject, currentSecret, desiredSecret)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	secret, err := toSecret(result)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if secret == nil || secret.StringData[key.CrtID] == "" {
+		t.Fatalf("expected renewed secret got %#v", secret)
+	}
+	if secret.StringData[apiv1.TLSCertKey] != "capi-ca" || secret.StringData[apiv1.TLSPrivateKeyKey] != "capi-ca-key" {
+		t.Fatalf("expected the Cluster API CA to be kept got %#v", secret.StringData)
+	}
+
+	err = newResource.ApplyUpdateChange(context.TODO(), customObject, secret)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	updated := &v1alpha1.CertConfig{}
+	err = newResource.ctrlClient.Get(context.TODO(), client.ObjectKeyFromObject(customObject), updated)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if _, ok := updated.Annotations[annotation.ForceRenew]; ok {
+		t.Fatalf("expected annotation %#q to be removed", annotation.ForceRenew)
+	}
+}
+
+// flakyVaultCrt fails to issue certificates as long as fail is set.
+type flakyVaultCrt struct {
+	*fakeVaultCrt
+
+	fail bool
+}
+
+func (f *flakyVaultCrt) Create(config vaultcrt.CreateConfig) (vaultcrt.CreateResult, error) {
+	if f.fail {
+		return vaultcrt.CreateResult{}, fmt.Errorf("vault unavailable")
+	}
+
+	return f.fakeVaultCrt.Create(config)
+}
+
+func Test_Resource_VaultCrt_newUpdateChange_budget(t *testing.T) {
+	now := time.Now()
+
+	customObject := &v1alpha1.CertConfig{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "al9qy-api",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				TTL:              "720h",
+			},
+		},
+	}
+
+	vaultCrt := &flakyVaultCrt{fakeVaultCrt: newFakeVaultCrt(t, now)}
+
+	var err error
+	var newResource *Resource
+	{
+		c := DefaultConfig()
+
+		c.CurrentTimeFactory = func() time.Time { return now }
+		c.K8sClient = fake.NewSimpleClientset()
+		c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+		c.Logger = microloggertest.New()
+		c.VaultCrt = vaultCrt
+		c.VaultPKI = vaultpkitest.New()
+
+		c.ExpirationThreshold = 24 * time.Hour
+		c.Namespace = "default"
+		c.RenewalBudget = 1
+
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	desiredSecret, err := newResource.GetDesiredState(context.TODO(), customObject)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	// The current secret got updated one TTL ago, so it is due for renewal.
+	currentSecret := desiredSecret.(*apiv1.Secret).DeepCopy()
+	currentSecret.Annotations[UpdateTimestampAnnotation] = now.Add(-720 * time.Hour).In(time.UTC).Format(UpdateTimestampLayout)
+
+	newContext := func() context.Context {
+		ctx := resourcecanceledcontext.NewContext(context.Background(), make(chan struct{}))
+		ctx = requeuecontext.NewContext(ctx)
+		return ctx
+	}
+
+	// Test 0 ensures failed renewals do not use up the budget.
+	{
+		vaultCrt.fail = true
+
+		_, err := newResource.newUpdateChange(newContext(), customObject, currentSecret, desiredSecret.(*apiv1.Secret).DeepCopy())
+		if err == nil {
+			t.Fatal("test 0 expected", "error", "got", nil)
+		}
+
+		vaultCrt.fail = false
+	}
+
+	// Test 1 ensures renewals within the budget are issued.
+	{
+		ctx := newContext()
+
+		result, err := newResource.newUpdateChange(ctx, customObject, currentSecret, desiredSecret.(*apiv1.Secret).DeepCopy())
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+		secret, err := toSecret(result)
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+		if secret == nil || secret.StringData[key.CrtID] == "" {
+			t.Fatalf("test 1 expected renewed secret got %#v", secret)
+		}
+		if _, ok := requeuecontext.RequeueAfter(ctx); ok {
+			t.Fatalf("test 1 expected no requeue")
+		}
+	}
+
+	// Test 2 ensures renewals exceeding the budget are deferred until the
+	// budget got refilled.
+	{
+		ctx := newContext()
+
+		result, err := newResource.newUpdateChange(ctx, customObject, currentSecret, desiredSecret.(*apiv1.Secret).DeepCopy())
+		if err != nil {
+			t.Fatal("test 2 expected", nil, "got", err)
+		}
+		secret, err := toSecret(result)
+		if err != nil {
+			t.Fatal("test 2 expected", nil, "got", err)
+		}
+		if secret != nil {
+			t.Fatalf("test 2 expected %#v got %#v", nil, secret)
+		}
+		if !resourcecanceledcontext.IsCanceled(ctx) {
+			t.Fatalf("test 2 expected resource to be canceled")
+		}
+		after, ok := requeuecontext.RequeueAfter(ctx)
+		if !ok || after != time.Minute {
+			t.Fatalf("test 2 expected requeue after %s got %s", time.Minute, after)
+		}
+	}
+}
+
+func Test_Resource_VaultCrt_newUpdateChange_repair(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		Name                string
+		DisableRegeneration bool
+		ExhaustBudget       bool
+		FailVerification    bool
+		ExpectRenewal       bool
+	}{
+		{
+			Name:          "case 0: invalid certificate is repaired",
+			ExpectRenewal: true,
+		},
+		{
+			Name:                "case 1: invalid certificate is not repaired in case regeneration is disabled",
+			DisableRegeneration: true,
+			ExpectRenewal:       false,
+		},
+		{
+			Name:          "case 2: invalid certificate is not repaired in case the renewal budget is exhausted",
+			ExhaustBudget: true,
+			ExpectRenewal: false,
+		},
+		{
+			Name:             "case 3: invalid certificate is not repaired in case the previously issued certificate failed verification",
+			FailVerification: true,
+			ExpectRenewal:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			customObject := &v1alpha1.CertConfig{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Name:      "al9qy-api",
+					Namespace: "default",
+				},
+				Spec: v1alpha1.CertConfigSpec{
+					Cert: v1alpha1.CertConfigSpecCert{
+						ClusterComponent:    "api",
+						ClusterID:           "al9qy",
+						DisableRegeneration: tc.DisableRegeneration,
+						TTL:                 "720h",
+					},
+				},
+			}
+
+			var err error
+			var newResource *Resource
+			{
+				c := DefaultConfig()
+
+				c.CurrentTimeFactory = func() time.Time { return now }
+				c.K8sClient = fake.NewSimpleClientset()
+				c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+				c.Logger = microloggertest.New()
+				c.VaultCrt = newFakeVaultCrt(t, now)
+				c.VaultPKI = vaultpkitest.New()
+
+				c.ExpirationThreshold = 24 * time.Hour
+				c.Namespace = "default"
+				c.RenewalBudget = 1
+
+				newResource, err = New(c)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			if tc.ExhaustBudget {
+				newResource.renewalBudget.Reserve("al9qy", now)
+			}
+			if tc.FailVerification {
+				secret := &apiv1.Secret{StringData: map[string]string{key.CrtID: "invalid crt"}}
+				err := newResource.verifySecret(context.TODO(), *customObject, secret)
+				if err == nil {
+					t.Fatal("expected", "error", "got", nil)
+				}
+			}
+
+			desiredSecret, err := newResource.GetDesiredState(context.TODO(), customObject)
+			if err != nil {
+				t.Fatal("expected", nil, "got", err)
+			}
+			currentSecret := desiredSecret.(*apiv1.Secret).DeepCopy()
+			currentSecret.Annotations[InvalidCertificateAnnotation] = verificationReasonKey
+
+			ctx := resourcecanceledcontext.NewContext(context.Background(), make(chan struct{}))
+			ctx = requeuecontext.NewContext(ctx)
+
+			result, err := newResource.newUpdateChange(ctx, customObject, currentSecret, desiredSecret)
+			if err != nil {
+				t.Fatal("expected", nil, "got", err)
+			}
+			secret, err := toSecret(result)
+			if err != nil {
+				t.Fatal("expected", nil, "got", err)
+			}
+
+			renewed := secret != nil && secret.StringData[key.CrtID] != ""
+			if renewed != tc.ExpectRenewal {
+				t.Fatalf("expected renewal %t got %t", tc.ExpectRenewal, renewed)
+			}
+			if secret != nil {
+				if _, ok := secret.Annotations[InvalidCertificateAnnotation]; ok {
+					t.Fatalf("expected annotation %#q not to be written", InvalidCertificateAnnotation)
+				}
+			}
+
+			deferred := tc.ExhaustBudget || tc.FailVerification
+			if _, ok := requeuecontext.RequeueAfter(ctx); ok != deferred {
+				t.Fatalf("expected requeue %t got %t", deferred, ok)
+			}
+		})
+	}
+}
+
+func Test_Resource_VaultCrt_newUpdateChange_adoption(t *testing.T) {
+	now := time.Now()
+
+	customObject := &v1alpha1.CertConfig{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "al9qy-api",
+			Namespace: "default",
+			UID:       "5f3c2a3e-5c1e-4b0a-9d0c-1b1c2a3d4e5f",
+		},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				TTL:              "720h",
+			},
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		c := DefaultConfig()
+
+		c.CurrentTimeFactory = func() time.Time { return now }
+		c.K8sClient = fake.NewSimpleClientset()
+		c.CtrlClient = fakectrl.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+		c.Logger = microloggertest.New()
+		c.VaultCrt = vaultcrttest.New()
+		c.VaultPKI = vaultpkitest.New()
+
+		c.ExpirationThreshold = 24 * time.Hour
+		c.Namespace = "default"
+
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	desiredSecret, err := newResource.GetDesiredState(context.TODO(), customObject)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	// Test 0 ensures secrets created by earlier versions of the operator get
+	// adopted without reissuing their certificates.
+	{
+		currentSecret := desiredSecret.(*apiv1.Secret).DeepCopy()
+		currentSecret.OwnerReferences = nil
+		currentSecret.StringData = nil
+		currentSecret.Data = map[string][]byte{key.CrtID: []byte("current crt")}
+
+		result, err := newResource.newUpdateChange(context.TODO(), customObject, currentSecret, desiredSecret)
+		if err != nil {
+			t.Fatal("test 0 expected", nil, "got", err)
+		}
+		secret, err := toSecret(result)
+		if err != nil {
+			t.Fatal("test 0 expected", nil, "got", err)
+		}
+		if secret == nil {
+			t.Fatal("test 0 expected", "adopted secret", "got", nil)
+		}
+
+		ref := apismetav1.GetControllerOf(secret)
+		if ref == nil || ref.UID != customObject.UID {
+			t.Fatalf("test 0 expected controller %#q got %#v", customObject.UID, ref)
+		}
+		if string(secret.Data[key.CrtID]) != "current crt" {
+			t.Fatalf("test 0 expected certificate to be kept, got %#q", secret.Data[key.CrtID])
+		}
+	}
+
+	// Test 1 ensures secrets already controlled by the cert config are left
+	// untouched.
+	{
+		currentSecret := desiredSecret.(*apiv1.Secret).DeepCopy()
+
+		result, err := newResource.newUpdateChange(context.TODO(), customObject, currentSecret, desiredSecret)
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+		secret, err := toSecret(result)
+		if err != nil {
+			t.Fatal("test 1 expected", nil, "got", err)
+		}
+		if secret != nil {
+			t.Fatalf("test 1 expected %#v got %#v", nil, secret)
+		}
+	}
+}
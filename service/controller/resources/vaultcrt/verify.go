@@ -0,0 +1,249 @@
+package vaultcrt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultpki"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+const (
+	// TTLTolerance is the tolerated difference between the TTL requested in the
+	// cert config and the validity period of the issued certificate. Vault
+	// backdates the start of the validity period in order to account for clock
+	// skew.
+	TTLTolerance = 5 * time.Minute
+)
+
+const (
+	verificationReasonChain         = "chain"
+	verificationReasonKey           = "key"
+	verificationReasonOrganizations = "organizations"
+	verificationReasonParse         = "parse"
+	verificationReasonSANs          = "sans"
+	verificationReasonTTL           = "ttl"
+)
+
+// verifySecret verifies the certificate material of the given secret before
+// it gets written to the Kubernetes API. Secrets without new certificate
+// material, e.g. secrets of which only the annotations got updated, are not
+// verified.
+func (r *Resource) verifySecret(ctx context.Context, customObject v1alpha1.CertConfig, secret *apiv1.Secret) error {
+	if secret.StringData == nil || secret.StringData[key.CrtID] == "" {
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "verifying the issued certificate")
+
+	now := r.currentTimeFactory()
+
+	reason, err := verifyCertificate(customObject, secret.StringData[key.CAID], secret.StringData[key.CrtID], secret.StringData[key.KeyID], now)
+	if err != nil {
+		verificationFailureCounter.WithLabelValues(reason).Inc()
+		r.verificationBackoff.Failed(verificationBackoffID(customObject), now)
+		return microerror.Mask(err)
+	}
+
+	r.verificationBackoff.Reset(verificationBackoffID(customObject))
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "verified the issued certificate")
+
+	return nil
+}
+
+// inspectSecret inspects the certificate material of the given current secret
+// and flags the secret using InvalidCertificateAnnotation in case the material
+// is corrupted, got tampered with or does not match the cert config anymore.
+// The certificate is inspected against the CA of the PKI backend of the cert
+// config's trust domain. In case the CA cannot be found, the CA bundled with the secret is
+// used instead.
+func (r *Resource) inspectSecret(ctx context.Context, customObject v1alpha1.CertConfig, secret *apiv1.Secret) error {
+	r.logger.LogCtx(ctx, "level", "debug", "message", "inspecting the certificate of the secret")
+
+	var ca string
+	{
+		certificateAuthority, err := r.vaultPKI.GetCACertificate(key.PKIID(customObject, r.trustDomains))
+		if vaultpki.IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		ca = certificateAuthority.Certificate
+		if ca == "" {
+			r.logger.LogCtx(ctx, "level", "debug", "message", "did not find the CA of the PKI backend, inspecting against the CA of the secret")
+			ca = string(secret.Data[key.CAID])
+		}
+	}
+
+	reason, err := inspectCertificate(customObject, ca, string(secret.Data[key.CrtID]), string(secret.Data[key.KeyID]), r.currentTimeFactory())
+	if IsInvalidCertificate(err) {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("found the certificate of the secret to be invalid due to %#q", reason), "stack", fmt.Sprintf("%#v", err))
+		invalidSecretCounter.WithLabelValues(reason).Inc()
+
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[InvalidCertificateAnnotation] = reason
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "inspected the certificate of the secret")
+
+	return nil
+}
+
+// verifyCertificate ensures the given certificate is signed by the given CA,
+// matches the given private key and was issued according to the given cert
+// config. In case the verification fails, the reason is returned along with
+// the error.
+func verifyCertificate(customObject v1alpha1.CertConfig, ca, crt, k string, now time.Time) (string, error) {
+	reason, err := inspectCertificate(customObject, ca, crt, k, now)
+	if err != nil {
+		return reason, microerror.Mask(err)
+	}
+
+	{
+		certificate, err := parseCertificate(crt)
+		if err != nil {
+			return verificationReasonParse, microerror.Mask(err)
+		}
+
+		expected := sortedCopy(key.Organizations(customObject))
+		actual := sortedCopy(certificate.Subject.Organization)
+
+		if fmt.Sprint(expected) != fmt.Sprint(actual) {
+			return verificationReasonOrganizations, microerror.Maskf(invalidCertificateError, "expected organizations %v got %v", expected, actual)
+		}
+
+		ttl, err := time.ParseDuration(key.CrtTTL(customObject))
+		if err != nil {
+			return verificationReasonTTL, microerror.Mask(err)
+		}
+
+		// Certificates issued near the expiration of their CA are capped at
+		// its remaining lifetime.
+		validity := certificate.NotAfter.Sub(certificate.NotBefore)
+		if validity < ttl-TTLTolerance && isTruncatedAtIssuer(certificate, ca) {
+			// fall through
+		} else if validity < ttl-TTLTolerance || validity > ttl+TTLTolerance {
+			return verificationReasonTTL, microerror.Maskf(invalidCertificateError, "expected validity period of %s got %s", ttl, validity)
+		}
+	}
+
+	return "", nil
+}
+
+// inspectCertificate checks the properties of the given certificate which
+// must hold during its whole lifetime. The certificate must be parsable, be
+// signed by the given CA, match the given private key and contain the SANs of
+// the given cert config. In case the inspection fails, the reason is returned
+// along with the error.
+func inspectCertificate(customObject v1alpha1.CertConfig, ca, crt, k string, now time.Time) (string, error) {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(ca)) {
+		return verificationReasonParse, microerror.Maskf(invalidCertificateError, "CA must contain PEM encoded certificates")
+	}
+
+	certificate, err := parseCertificate(crt)
+	if err != nil {
+		return verificationReasonParse, microerror.Mask(err)
+	}
+
+	{
+		o := x509.VerifyOptions{
+			CurrentTime: now,
+			KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+			Roots:       roots,
+		}
+
+		_, err := certificate.Verify(o)
+		if err != nil {
+			return verificationReasonChain, microerror.Maskf(invalidCertificateError, "certificate is not signed by the issuing CA: %s", err)
+		}
+	}
+
+	{
+		_, err := tls.X509KeyPair([]byte(crt), []byte(k))
+		if err != nil {
+			return verificationReasonKey, microerror.Maskf(invalidCertificateError, "private key does not match the certificate: %s", err)
+		}
+	}
+
+	{
+		if certificate.Subject.CommonName != key.CommonName(customObject) {
+			return verificationReasonSANs, microerror.Maskf(invalidCertificateError, "expected common name %#q got %#q", key.CommonName(customObject), certificate.Subject.CommonName)
+		}
+
+		for _, n := range key.AltNames(customObject) {
+			if !containsString(certificate.DNSNames, n) {
+				return verificationReasonSANs, microerror.Maskf(invalidCertificateError, "certificate is missing the DNS SAN %#q", n)
+			}
+		}
+
+		for _, s := range key.IPSANs(customObject) {
+			ip := net.ParseIP(s)
+			if ip == nil || !containsIP(certificate.IPAddresses, ip) {
+				return verificationReasonSANs, microerror.Maskf(invalidCertificateError, "certificate is missing the IP SAN %#q", s)
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func containsIP(l []net.IP, ip net.IP) bool {
+	for _, i := range l {
+		if i.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(l []string, s string) bool {
+	for _, i := range l {
+		if i == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseCertificate(crt string) (*x509.Certificate, error) {
+	b, _ := pem.Decode([]byte(crt))
+	if b == nil {
+		return nil, microerror.Maskf(invalidCertificateError, "certificate must be PEM encoded")
+	}
+
+	c, err := x509.ParseCertificate(b.Bytes)
+	if err != nil {
+		return nil, microerror.Maskf(invalidCertificateError, "certificate cannot be parsed: %s", err)
+	}
+
+	return c, nil
+}
+
+func sortedCopy(l []string) []string {
+	c := make([]string, len(l))
+	copy(c, l)
+	sort.Strings(c)
+
+	return c
+}
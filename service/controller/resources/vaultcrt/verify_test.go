@@ -0,0 +1,124 @@
+package vaultcrt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/vaultcrt"
+
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+func Test_Resource_VaultCrt_verifyCertificate(t *testing.T) {
+	now := time.Now()
+
+	customObject := v1alpha1.CertConfig{
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				AltNames:         []string{"kubernetes", "kubernetes.default"},
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				CommonName:       "api.al9qy.k8s.gigantic.io",
+				IPSANs:           []string{"172.31.0.1"},
+				Organizations:    []string{"system:masters"},
+				TTL:              "720h",
+			},
+		},
+	}
+
+	issue := func(f *fakeVaultCrt, c v1alpha1.CertConfig) vaultcrt.CreateResult {
+		r, err := f.Create(vaultcrt.CreateConfig{
+			AltNames:      c.Spec.Cert.AltNames,
+			CommonName:    c.Spec.Cert.CommonName,
+			IPSANs:        c.Spec.Cert.IPSANs,
+			Organizations: key.Organizations(c),
+			TTL:           c.Spec.Cert.TTL,
+		})
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+		return r
+	}
+
+	f := newFakeVaultCrt(t, now)
+	other := newFakeVaultCrt(t, now)
+
+	valid := issue(f, customObject)
+
+	withSpec := func(mutate func(c *v1alpha1.CertConfig)) vaultcrt.CreateResult {
+		c := *customObject.DeepCopy()
+		mutate(&c)
+		return issue(f, c)
+	}
+
+	mismatchingKey := valid
+	mismatchingKey.Key = issue(f, customObject).Key
+
+	otherCA := valid
+	otherCA.CA = other.caPEM
+
+	unparsable := valid
+	unparsable.Crt = "test crt"
+
+	testCases := []struct {
+		Name           string
+		Result         vaultcrt.CreateResult
+		ExpectedReason string
+	}{
+		{
+			Name:           "case 0: valid certificate",
+			Result:         valid,
+			ExpectedReason: "",
+		},
+		{
+			Name:           "case 1: unparsable certificate",
+			Result:         unparsable,
+			ExpectedReason: verificationReasonParse,
+		},
+		{
+			Name:           "case 2: certificate signed by another CA",
+			Result:         otherCA,
+			ExpectedReason: verificationReasonChain,
+		},
+		{
+			Name:           "case 3: mismatching private key",
+			Result:         mismatchingKey,
+			ExpectedReason: verificationReasonKey,
+		},
+		{
+			Name:           "case 4: missing SANs",
+			Result:         withSpec(func(c *v1alpha1.CertConfig) { c.Spec.Cert.AltNames = []string{"kubernetes"} }),
+			ExpectedReason: verificationReasonSANs,
+		},
+		{
+			Name:           "case 5: mismatching organizations",
+			Result:         withSpec(func(c *v1alpha1.CertConfig) { c.Spec.Cert.Organizations = []string{"giantswarm"} }),
+			ExpectedReason: verificationReasonOrganizations,
+		},
+		{
+			Name:           "case 6: truncated TTL",
+			Result:         withSpec(func(c *v1alpha1.CertConfig) { c.Spec.Cert.TTL = "24h" }),
+			ExpectedReason: verificationReasonTTL,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			reason, err := verifyCertificate(customObject, tc.Result.CA, tc.Result.Crt, tc.Result.Key, now)
+			if tc.ExpectedReason == "" {
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+				return
+			}
+
+			if !IsInvalidCertificate(err) {
+				t.Fatalf("expected %#v got %#v", invalidCertificateError, err)
+			}
+			if reason != tc.ExpectedReason {
+				t.Fatalf("expected reason %#q got %#q", tc.ExpectedReason, reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package vaultpki
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/backoff"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/project"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+// ensureCAKeySecret stores the given private key of the CA of the PKI backend
+// of the given cert config. Vault does not hand out the private key a second
+// time, so writing the secret is retried a few times before giving up. The
+// secret is not owned by the cert config, since the PKI backend outlives the
+// cert configs. It is deleted along with the PKI backend instead.
+func (r *Resource) ensureCAKeySecret(ctx context.Context, customObject v1alpha1.CertConfig, privateKey string) error {
+	name := types.NamespacedName{Name: key.CAKeySecretName(key.PKIID(customObject, r.trustDomains)), Namespace: customObject.GetNamespace()}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensuring CA key secret %#q", name.String()))
+
+	o := func() error {
+		current := &corev1.Secret{}
+		err := r.ctrlClient.Get(ctx, name, current)
+		if apierrors.IsNotFound(err) {
+			desired := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name.Name,
+					Namespace: name.Namespace,
+					Labels: map[string]string{
+						label.Cluster:   key.ClusterID(customObject),
+						label.ManagedBy: project.Name(),
+					},
+				},
+				Data: map[string][]byte{
+					caKeyDataName: []byte(privateKey),
+				},
+			}
+
+			err = r.ctrlClient.Create(ctx, desired)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// A secret left behind by an earlier PKI backend of the same ID is
+		// overwritten, since its key does not belong to the new CA.
+		current.Data = map[string][]byte{
+			caKeyDataName: []byte(privateKey),
+		}
+
+		err = r.ctrlClient.Update(ctx, current)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	err := backoff.Retry(o, backoff.NewMaxRetries(3, 1*time.Second))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensured CA key secret %#q", name.String()))
+
+	return nil
+}
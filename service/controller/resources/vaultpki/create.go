@@ -2,9 +2,13 @@ package vaultpki
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/vaultpki"
+	corev1 "k8s.io/api/core/v1"
 
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
 	"github.com/giantswarm/cert-operator/v3/service/controller/key"
 )
 
@@ -21,7 +25,7 @@ func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange inte
 	if vaultPKIStateToCreate.Backend != nil {
 		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the Vault PKI in the Vault API")
 
-		err := r.vaultPKI.CreateBackend(key.ClusterID(customObject))
+		err := r.vaultPKI.CreateBackend(key.PKIID(customObject, r.trustDomains))
 		if err != nil {
 			return microerror.Mask(err)
 		}
@@ -31,19 +35,88 @@ func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange inte
 		r.logger.LogCtx(ctx, "level", "debug", "message", "the Vault PKI does not need to be created in the Vault API")
 	}
 
+	// The constraints are derived from the cluster ID, so that the CAs of all
+	// trust domains of a cluster permit its names.
+	var constraints *caconstraint.Constraints
+	if r.caConstraints != nil {
+		c := r.caConstraints.Constraints(key.ClusterID(customObject))
+		constraints = &c
+	}
+
+	var caSecret *corev1.Secret
 	if vaultPKIStateToCreate.CACertificate != "" {
+		caSecret, err = r.findCASecret(ctx, customObject)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// The private key of the CA is only known in case it got imported or
+	// exported from Vault.
+	var privateKey string
+
+	if caSecret != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("importing the CA of secret %s/%s into the Vault PKI", caSecret.Namespace, caSecret.Name))
+
+		err := r.vaultImport.ImportCA(key.PKIID(customObject, r.trustDomains), string(caSecret.Data[caCrtDataName]), string(caSecret.Data[caKeyDataName]))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		privateKey = string(caSecret.Data[caKeyDataName])
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("imported the CA of secret %s/%s into the Vault PKI", caSecret.Namespace, caSecret.Name))
+	} else if vaultPKIStateToCreate.CACertificate != "" && r.vaultIntermediate != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the intermediate CA in the Vault PKI")
+
+		if r.exportCAKeys {
+			privateKey, err = r.vaultIntermediate.CreateCAWithPrivateKey(key.PKIID(customObject, r.trustDomains), constraints)
+		} else {
+			err = r.vaultIntermediate.CreateCA(key.PKIID(customObject, r.trustDomains), constraints)
+		}
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "created the intermediate CA in the Vault PKI")
+	} else if vaultPKIStateToCreate.CACertificate != "" && constraints != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the constrained root CA in the Vault PKI")
+
+		if r.exportCAKeys {
+			privateKey, err = r.vaultRoot.CreateCAWithPrivateKey(key.PKIID(customObject, r.trustDomains), *constraints)
+		} else {
+			err = r.vaultRoot.CreateCA(key.PKIID(customObject, r.trustDomains), *constraints)
+		}
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "created the constrained root CA in the Vault PKI")
+	} else if vaultPKIStateToCreate.CACertificate != "" {
 		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the root CA in the Vault PKI")
 
-		_, err := r.vaultPKI.CreateCA(key.ClusterID(customObject))
+		var ca vaultpki.CertificateAuthority
+		if r.exportCAKeys {
+			ca, err = r.vaultPKI.CreateCAWithPrivateKey(key.PKIID(customObject, r.trustDomains))
+		} else {
+			ca, err = r.vaultPKI.CreateCA(key.PKIID(customObject, r.trustDomains))
+		}
 		if err != nil {
 			return microerror.Mask(err)
 		}
+		privateKey = ca.PrivateKey
 
 		r.logger.LogCtx(ctx, "level", "debug", "message", "created the root CA in the Vault PKI")
 	} else {
 		r.logger.LogCtx(ctx, "level", "debug", "message", "the root CA does not need to be created in the Vault PKI")
 	}
 
+	if r.exportCAKeys && privateKey != "" {
+		err := r.ensureCAKeySecret(ctx, customObject, privateKey)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
 	return nil
 }
 
@@ -4,11 +4,25 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
 	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/vaultpki"
 	"github.com/giantswarm/vaultpki/vaultpkitest"
 	vaultapi "github.com/hashicorp/vault/api"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/pkg/annotation"
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
 )
 
 func Test_Resource_VaultPKI_NewCreateChange(t *testing.T) {
@@ -127,8 +141,11 @@ func Test_Resource_VaultPKI_NewCreateChange(t *testing.T) {
 	var newResource *Resource
 	{
 		c := Config{
-			Logger:   microloggertest.New(),
-			VaultPKI: vaultpkitest.New(),
+			CtrlClient:         fakectrl.NewClientBuilder().Build(),
+			CurrentTimeFactory: time.Now,
+			Logger:             microloggertest.New(),
+			VaultImport:        &fakeVaultImport{},
+			VaultPKI:           vaultpkitest.New(),
 		}
 
 		newResource, err = New(c)
@@ -148,3 +165,268 @@ func Test_Resource_VaultPKI_NewCreateChange(t *testing.T) {
 		}
 	}
 }
+
+func Test_Resource_VaultPKI_ApplyCreateChange(t *testing.T) {
+	testCases := []struct {
+		name             string
+		obj              *v1alpha1.CertConfig
+		objects          []client.Object
+		caConstraints    *caconstraint.Policy
+		exportCAKeys     bool
+		expectedCreateCA string
+		expectedImport   *importedCA
+		expectedRootCA   *rootCA
+		expectedCAKey    string
+		errorMatcher     func(error) bool
+	}{
+		{
+			name:             "case 0: create a CA when no secret is referenced",
+			obj:              newCertConfig(nil, ""),
+			objects:          []client.Object{newCluster(nil)},
+			expectedCreateCA: "foobar",
+		},
+		{
+			name:    "case 1: import the CA of the secret referenced by the cert config",
+			obj:     newCertConfig(map[string]string{annotation.CASecret: "foobar-ca"}, ""),
+			objects: []client.Object{newCluster(nil), newCASecret("foobar-ca")},
+			expectedImport: &importedCA{
+				ID:          "foobar",
+				Certificate: "foobar-ca-crt",
+				PrivateKey:  "foobar-ca-key",
+			},
+		},
+		{
+			name:    "case 2: import the CA of the secret referenced by the cluster",
+			obj:     newCertConfig(nil, ""),
+			objects: []client.Object{newCluster(map[string]string{annotation.CASecret: "cluster-ca"}), newCASecret("cluster-ca")},
+			expectedImport: &importedCA{
+				ID:          "foobar",
+				Certificate: "cluster-ca-crt",
+				PrivateKey:  "cluster-ca-key",
+			},
+		},
+		{
+			name:             "case 3: ignore the secret referenced by the cluster for other trust domains",
+			obj:              newCertConfig(nil, "etcd"),
+			objects:          []client.Object{newCluster(map[string]string{annotation.CASecret: "cluster-ca"}), newCASecret("cluster-ca")},
+			expectedCreateCA: "foobar-etcd",
+		},
+		{
+			name:         "case 4: fail when the referenced secret does not exist",
+			obj:          newCertConfig(map[string]string{annotation.CASecret: "foobar-ca"}, ""),
+			objects:      []client.Object{newCluster(nil)},
+			errorMatcher: IsCASecretNotFound,
+		},
+		{
+			name:          "case 5: create a constrained CA permitting the names of the cluster",
+			obj:           newCertConfig(nil, "etcd"),
+			objects:       []client.Object{newCluster(nil)},
+			caConstraints: newCAConstraints(t),
+			expectedRootCA: &rootCA{
+				ID: "foobar-etcd",
+				Constraints: caconstraint.Constraints{
+					PermittedDNSDomains: append([]string{"foobar.k8s.gigantic.io"}, caconstraint.KubernetesDNSNames...),
+				},
+			},
+		},
+		{
+			name:             "case 6: store the exported private key of a created CA",
+			obj:              newCertConfig(nil, ""),
+			objects:          []client.Object{newCluster(nil)},
+			exportCAKeys:     true,
+			expectedCreateCA: "foobar",
+			expectedCAKey:    "foobar-key",
+		},
+		{
+			name:          "case 7: store the exported private key of a constrained CA",
+			obj:           newCertConfig(nil, "etcd"),
+			objects:       []client.Object{newCluster(nil)},
+			caConstraints: newCAConstraints(t),
+			exportCAKeys:  true,
+			expectedRootCA: &rootCA{
+				ID: "foobar-etcd",
+				Constraints: caconstraint.Constraints{
+					PermittedDNSDomains: append([]string{"foobar.k8s.gigantic.io"}, caconstraint.KubernetesDNSNames...),
+				},
+			},
+			expectedCAKey: "foobar-etcd-key",
+		},
+		{
+			name:    "case 8: store the private key of an imported CA",
+			obj:     newCertConfig(map[string]string{annotation.CASecret: "foobar-ca"}, ""),
+			objects: []client.Object{newCluster(nil), newCASecret("foobar-ca")},
+			expectedImport: &importedCA{
+				ID:          "foobar",
+				Certificate: "foobar-ca-crt",
+				PrivateKey:  "foobar-ca-key",
+			},
+			exportCAKeys:  true,
+			expectedCAKey: "foobar-ca-key",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = corev1.AddToScheme(scheme)
+			_ = capi.AddToScheme(scheme)
+
+			vaultImport := &fakeVaultImport{}
+			vaultPKI := &fakeVaultPKI{VaultPKITest: vaultpkitest.New()}
+			vaultRoot := &fakeVaultRoot{}
+
+			ctrlClient := fakectrl.NewClientBuilder().WithScheme(scheme).WithObjects(tc.objects...).Build()
+
+			var r *Resource
+			{
+				c := Config{
+					CtrlClient:         ctrlClient,
+					CurrentTimeFactory: time.Now,
+					Logger:             microloggertest.New(),
+					VaultImport:        vaultImport,
+					VaultPKI:           vaultPKI,
+
+					CAConstraints: tc.caConstraints,
+					VaultRoot:     vaultRoot,
+
+					ExportCAKeys: tc.exportCAKeys,
+					TrustDomains: trustdomain.Mapping{"etcd": "etcd"},
+				}
+
+				var err error
+				r, err = New(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := r.ApplyCreateChange(context.Background(), tc.obj, VaultPKIState{CACertificate: "placeholder"})
+			if err != nil && tc.errorMatcher == nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			} else if err == nil && tc.errorMatcher != nil {
+				t.Fatalf("expected error got %#v", nil)
+			} else if err != nil && !tc.errorMatcher(err) {
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			if vaultPKI.createdCA != tc.expectedCreateCA {
+				t.Fatalf("expected CA %#q to be created got %#q", tc.expectedCreateCA, vaultPKI.createdCA)
+			}
+			if !reflect.DeepEqual(vaultImport.imported, tc.expectedImport) {
+				t.Fatalf("expected CA %#v to be imported got %#v", tc.expectedImport, vaultImport.imported)
+			}
+			if !reflect.DeepEqual(vaultRoot.created, tc.expectedRootCA) {
+				t.Fatalf("expected root CA %#v to be created got %#v", tc.expectedRootCA, vaultRoot.created)
+			}
+
+			secret := &corev1.Secret{}
+			err = ctrlClient.Get(context.Background(), client.ObjectKey{Name: key.CAKeySecretName(key.PKIID(*tc.obj, r.trustDomains)), Namespace: "default"}, secret)
+			if apierrors.IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if string(secret.Data[caKeyDataName]) != tc.expectedCAKey {
+				t.Fatalf("expected CA key %#q to be stored got %#q", tc.expectedCAKey, secret.Data[caKeyDataName])
+			}
+		})
+	}
+}
+
+type importedCA struct {
+	ID          string
+	Certificate string
+	PrivateKey  string
+}
+
+type fakeVaultImport struct {
+	imported *importedCA
+}
+
+func (f *fakeVaultImport) ImportCA(ID, certificate, privateKey string) error {
+	f.imported = &importedCA{ID: ID, Certificate: certificate, PrivateKey: privateKey}
+	return nil
+}
+
+type fakeVaultPKI struct {
+	*vaultpkitest.VaultPKITest
+
+	createdCA string
+}
+
+func (f *fakeVaultPKI) CreateCA(ID string) (vaultpki.CertificateAuthority, error) {
+	f.createdCA = ID
+	return vaultpki.DefaultCertificateAuthority(), nil
+}
+
+func (f *fakeVaultPKI) CreateCAWithPrivateKey(ID string) (vaultpki.CertificateAuthority, error) {
+	f.createdCA = ID
+	return vaultpki.CertificateAuthority{PrivateKey: ID + "-key"}, nil
+}
+
+type rootCA struct {
+	ID          string
+	Constraints caconstraint.Constraints
+}
+
+type fakeVaultRoot struct {
+	created *rootCA
+}
+
+func (f *fakeVaultRoot) CreateCA(ID string, constraints caconstraint.Constraints) error {
+	f.created = &rootCA{ID: ID, Constraints: constraints}
+	return nil
+}
+
+func (f *fakeVaultRoot) CreateCAWithPrivateKey(ID string, constraints caconstraint.Constraints) (string, error) {
+	f.created = &rootCA{ID: ID, Constraints: constraints}
+	return ID + "-key", nil
+}
+
+func newCAConstraints(t *testing.T) *caconstraint.Policy {
+	p, err := caconstraint.New(caconstraint.Config{CommonNameFormat: "%s.k8s.gigantic.io"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func newCertConfig(annotations map[string]string, component string) *v1alpha1.CertConfig {
+	return &v1alpha1.CertConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foobar-api",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+		Spec: v1alpha1.CertConfigSpec{
+			Cert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: component,
+				ClusterID:        "foobar",
+			},
+		},
+	}
+}
+
+func newCluster(annotations map[string]string) *capi.Cluster {
+	return &capi.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foobar",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+	}
+}
+
+func newCASecret(name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"tls.crt": []byte(name + "-crt"),
+			"tls.key": []byte(name + "-key"),
+		},
+	}
+}
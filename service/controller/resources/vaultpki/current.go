@@ -20,7 +20,7 @@ func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interf
 	{
 		r.logger.LogCtx(ctx, "level", "debug", "message", "looking for the Vault PKI in the Vault API")
 
-		backend, err := r.vaultPKI.GetBackend(key.ClusterID(customObject))
+		backend, err := r.vaultPKI.GetBackend(key.PKIID(customObject, r.trustDomains))
 		if vaultpki.IsNotFound(err) {
 			r.logger.LogCtx(ctx, "level", "debug", "message", "did not find the Vault PKI in the Vault API")
 		} else if err != nil {
@@ -35,15 +35,22 @@ func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interf
 	{
 		r.logger.LogCtx(ctx, "level", "debug", "message", "looking for the root CA in the Vault PKI")
 
-		caCertificate, err := r.vaultPKI.GetCACertificate(key.ClusterID(customObject))
+		caCertificate, err := r.vaultPKI.GetCACertificate(key.PKIID(customObject, r.trustDomains))
 		if vaultpki.IsNotFound(err) {
 			r.logger.LogCtx(ctx, "level", "debug", "message", "did not find the root CA in the Vault PKI")
+
+			DeleteCAExpiry(key.ClusterID(customObject), key.PKIID(customObject, r.trustDomains))
 		} else if err != nil {
 			return false, microerror.Mask(err)
 		} else {
 			r.logger.LogCtx(ctx, "level", "debug", "message", "found the root CA in the Vault PKI")
 
 			vaultPKIState.CACertificate = caCertificate.Certificate
+
+			err := r.updateCAExpiry(ctx, customObject, caCertificate.Certificate)
+			if err != nil {
+				return false, microerror.Mask(err)
+			}
 		}
 	}
 
@@ -31,10 +31,15 @@ func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteChange inte
 		return microerror.Mask(err)
 	}
 
+	// The expiration time of the CA is exported again by the reconciliation of
+	// the other cert configs of the PKI backend, so the series only remains
+	// gone once the last one got deleted.
+	DeleteCAExpiry(key.ClusterID(customObject), key.PKIID(customObject, r.trustDomains))
+
 	if vaultPKIStateToDelete.Backend != nil || vaultPKIStateToDelete.CACertificate != "" {
 		r.logger.LogCtx(ctx, "level", "debug", "message", "deleting the Vault PKI in the Vault API")
 
-		err := r.vaultPKI.DeleteBackend(key.ClusterID(customObject))
+		err := r.vaultPKI.DeleteBackend(key.PKIID(customObject, r.trustDomains))
 		if err != nil {
 			return microerror.Mask(err)
 		}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
 	"github.com/giantswarm/micrologger/microloggertest"
 	"github.com/giantswarm/vaultpki/vaultpkitest"
 	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
 )
 
 func Test_Resource_VaultPKI_NewDeleteChange(t *testing.T) {
@@ -91,8 +94,11 @@ func Test_Resource_VaultPKI_NewDeleteChange(t *testing.T) {
 	var newResource *Resource
 	{
 		c := Config{
-			Logger:   microloggertest.New(),
-			VaultPKI: vaultpkitest.New(),
+			CtrlClient:         fakectrl.NewClientBuilder().Build(),
+			CurrentTimeFactory: time.Now,
+			Logger:             microloggertest.New(),
+			VaultImport:        &fakeVaultImport{},
+			VaultPKI:           vaultpkitest.New(),
 		}
 
 		newResource, err = New(c)
@@ -112,3 +118,34 @@ func Test_Resource_VaultPKI_NewDeleteChange(t *testing.T) {
 		}
 	}
 }
+
+func Test_Resource_VaultPKI_ApplyDeleteChange_caExpiry(t *testing.T) {
+	var newResource *Resource
+	{
+		c := Config{
+			CtrlClient:         fakectrl.NewClientBuilder().Build(),
+			CurrentTimeFactory: time.Now,
+			Logger:             microloggertest.New(),
+			VaultImport:        &fakeVaultImport{},
+			VaultPKI:           vaultpkitest.New(),
+		}
+
+		var err error
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	caExpiryGauge.WithLabelValues("foobar", "foobar").Set(1)
+	count := testutil.CollectAndCount(caExpiryGauge)
+
+	err := newResource.ApplyDeleteChange(context.Background(), newCertConfig(nil, ""), VaultPKIState{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c := testutil.CollectAndCount(caExpiryGauge); c != count-1 {
+		t.Fatalf("expected %d CA expiry series got %d", count-1, c)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
 	"github.com/giantswarm/micrologger/microloggertest"
 	"github.com/giantswarm/vaultpki/vaultpkitest"
 	vaultapi "github.com/hashicorp/vault/api"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
 )
 
 func Test_Resource_VaultPKI_GetDesiredState(t *testing.T) {
@@ -55,8 +57,11 @@ func Test_Resource_VaultPKI_GetDesiredState(t *testing.T) {
 	var newResource *Resource
 	{
 		c := Config{
-			Logger:   microloggertest.New(),
-			VaultPKI: vaultpkitest.New(),
+			CtrlClient:         fakectrl.NewClientBuilder().Build(),
+			CurrentTimeFactory: time.Now,
+			Logger:             microloggertest.New(),
+			VaultImport:        &fakeVaultImport{},
+			VaultPKI:           vaultpkitest.New(),
 		}
 
 		newResource, err = New(c)
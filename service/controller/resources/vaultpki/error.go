@@ -21,3 +21,22 @@ var wrongTypeError = &microerror.Error{
 func IsWrongTypeError(err error) bool {
 	return microerror.Cause(err) == wrongTypeError
 }
+
+var caSecretNotFoundError = &microerror.Error{
+	Kind: "caSecretNotFoundError",
+	Desc: "The secret holding the CA to import into the Vault PKI backend could not be found.",
+}
+
+// IsCASecretNotFound asserts caSecretNotFoundError.
+func IsCASecretNotFound(err error) bool {
+	return microerror.Cause(err) == caSecretNotFoundError
+}
+
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
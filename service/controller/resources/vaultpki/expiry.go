@@ -0,0 +1,132 @@
+package vaultpki
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+const (
+	// CAExpiringSoonCondition is the condition set on CAPI clusters whose CA
+	// expires within the configured threshold.
+	CAExpiringSoonCondition capi.ConditionType = "CAExpiringSoon"
+
+	caExpiringReason = "CAExpiring"
+	caValidReason    = "CAValid"
+)
+
+// updateCAExpiry exports the expiration time of the given CA of the PKI
+// backend of the given cert config and, for the default trust domain, reflects
+// whether it expires soon in the condition of the CAPI cluster.
+func (r *Resource) updateCAExpiry(ctx context.Context, customObject v1alpha1.CertConfig, ca string) error {
+	notAfter, err := caNotAfter(ca)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "failed to parse the CA of the Vault PKI", "stack", fmt.Sprintf("%#v", err))
+		return nil
+	}
+
+	caExpiryGauge.WithLabelValues(key.ClusterID(customObject), key.PKIID(customObject, r.trustDomains)).Set(float64(notAfter.Unix()))
+
+	if r.caExpirationThreshold == 0 || key.TrustDomain(customObject, r.trustDomains) != trustdomain.Default {
+		return nil
+	}
+
+	cluster := &capi.Cluster{}
+	{
+		err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: key.ClusterID(customObject), Namespace: customObject.GetNamespace()}, cluster)
+		if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	desired := capi.Condition{
+		Type:    CAExpiringSoonCondition,
+		Status:  corev1.ConditionFalse,
+		Reason:  caValidReason,
+		Message: fmt.Sprintf("The CA expires at %s.", notAfter.UTC().Format(time.RFC3339)),
+	}
+	if notAfter.Before(r.currentTimeFactory().Add(r.caExpirationThreshold)) {
+		desired.Status = corev1.ConditionTrue
+		desired.Reason = caExpiringReason
+	}
+
+	patch := client.MergeFromWithOptions(cluster.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if !setCondition(cluster, desired, r.currentTimeFactory()) {
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("setting the %s condition of the cluster to %s", CAExpiringSoonCondition, desired.Status))
+
+	err = r.ctrlClient.Status().Patch(ctx, cluster, patch)
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		// The condition is set again with the next reconciliation.
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("set the %s condition of the cluster to %s", CAExpiringSoonCondition, desired.Status))
+
+	return nil
+}
+
+// setCondition sets the given condition on the given cluster and returns
+// whether it changed. The transition time only changes along with the status.
+func setCondition(cluster *capi.Cluster, condition capi.Condition, now time.Time) bool {
+	conditions := cluster.GetConditions()
+
+	for i, c := range conditions {
+		if c.Type != condition.Type {
+			continue
+		}
+
+		if c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+			return false
+		}
+
+		condition.LastTransitionTime = c.LastTransitionTime
+		if c.Status != condition.Status {
+			condition.LastTransitionTime = metav1.NewTime(now)
+		}
+		conditions[i] = condition
+		cluster.SetConditions(conditions)
+
+		return true
+	}
+
+	condition.LastTransitionTime = metav1.NewTime(now)
+	cluster.SetConditions(append(conditions, condition))
+
+	return true
+}
+
+func caNotAfter(ca string) (time.Time, error) {
+	block, _ := pem.Decode([]byte(ca))
+	if block == nil {
+		return time.Time{}, microerror.Maskf(executionFailedError, "CA must be PEM encoded")
+	}
+
+	crt, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, microerror.Mask(err)
+	}
+
+	return crt.NotAfter, nil
+}
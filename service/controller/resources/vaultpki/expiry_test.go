@@ -0,0 +1,184 @@
+package vaultpki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/vaultpki/vaultpkitest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+)
+
+func Test_Resource_VaultPKI_updateCAExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	ca := newCA(t, now.Add(10*24*time.Hour))
+
+	testCases := []struct {
+		name              string
+		component         string
+		conditions        capi.Conditions
+		threshold         time.Duration
+		expectedCondition *capi.Condition
+	}{
+		{
+			name:      "case 0: set the condition when the CA expires within the threshold",
+			threshold: 30 * 24 * time.Hour,
+			expectedCondition: &capi.Condition{
+				Type:               CAExpiringSoonCondition,
+				Status:             corev1.ConditionTrue,
+				Reason:             caExpiringReason,
+				Message:            "The CA expires at 2026-10-29T12:00:00Z.",
+				LastTransitionTime: metav1.NewTime(now),
+			},
+		},
+		{
+			name:      "case 1: unset the condition when the CA expires after the threshold",
+			threshold: 5 * 24 * time.Hour,
+			conditions: capi.Conditions{
+				{
+					Type:               CAExpiringSoonCondition,
+					Status:             corev1.ConditionTrue,
+					Reason:             caExpiringReason,
+					Message:            "The CA expires at 2026-10-29T12:00:00Z.",
+					LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+				},
+			},
+			expectedCondition: &capi.Condition{
+				Type:               CAExpiringSoonCondition,
+				Status:             corev1.ConditionFalse,
+				Reason:             caValidReason,
+				Message:            "The CA expires at 2026-10-29T12:00:00Z.",
+				LastTransitionTime: metav1.NewTime(now),
+			},
+		},
+		{
+			name:      "case 2: keep the transition time of unchanged conditions",
+			threshold: 30 * 24 * time.Hour,
+			conditions: capi.Conditions{
+				{
+					Type:               CAExpiringSoonCondition,
+					Status:             corev1.ConditionTrue,
+					Reason:             caExpiringReason,
+					Message:            "The CA expires at 2026-10-29T12:00:00Z.",
+					LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+				},
+			},
+			expectedCondition: &capi.Condition{
+				Type:               CAExpiringSoonCondition,
+				Status:             corev1.ConditionTrue,
+				Reason:             caExpiringReason,
+				Message:            "The CA expires at 2026-10-29T12:00:00Z.",
+				LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+			},
+		},
+		{
+			name:      "case 3: ignore the CAs of other trust domains",
+			component: "etcd",
+			threshold: 30 * 24 * time.Hour,
+		},
+		{
+			name:      "case 4: do not set the condition without threshold",
+			threshold: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = capi.AddToScheme(scheme)
+
+			cluster := newCluster(nil)
+			cluster.Status.Conditions = tc.conditions
+			ctrlClient := fakectrl.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+
+			var r *Resource
+			{
+				c := Config{
+					CtrlClient:         ctrlClient,
+					CurrentTimeFactory: func() time.Time { return now },
+					Logger:             microloggertest.New(),
+					VaultImport:        &fakeVaultImport{},
+					VaultPKI:           vaultpkitest.New(),
+
+					CAExpirationThreshold: tc.threshold,
+					TrustDomains:          trustdomain.Mapping{"etcd": "etcd"},
+				}
+
+				var err error
+				r, err = New(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := r.updateCAExpiry(context.Background(), *newCertConfig(nil, tc.component), ca)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			updated := &capi.Cluster{}
+			err = ctrlClient.Get(context.Background(), types.NamespacedName{Name: "foobar", Namespace: "default"}, updated)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var condition *capi.Condition
+			for i := range updated.Status.Conditions {
+				if updated.Status.Conditions[i].Type == CAExpiringSoonCondition {
+					condition = &updated.Status.Conditions[i]
+				}
+			}
+
+			if (condition == nil) != (tc.expectedCondition == nil) {
+				t.Fatalf("expected condition %#v got %#v", tc.expectedCondition, condition)
+			}
+			if condition == nil {
+				return
+			}
+			if condition.Status != tc.expectedCondition.Status || condition.Reason != tc.expectedCondition.Reason || condition.Message != tc.expectedCondition.Message {
+				t.Fatalf("expected condition %#v got %#v", tc.expectedCondition, condition)
+			}
+			if !condition.LastTransitionTime.Equal(&tc.expectedCondition.LastTransitionTime) {
+				t.Fatalf("expected transition time %s got %s", tc.expectedCondition.LastTransitionTime, condition.LastTransitionTime)
+			}
+		})
+	}
+}
+
+func newCA(t *testing.T, notAfter time.Time) string {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		NotAfter:              notAfter,
+		NotBefore:             notAfter.Add(-365 * 24 * time.Hour),
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "foobar.k8s.gigantic.io"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, k.Public(), k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
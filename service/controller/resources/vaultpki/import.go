@@ -0,0 +1,60 @@
+package vaultpki
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/annotation"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+const (
+	caCrtDataName = "tls.crt"
+	caKeyDataName = "tls.key"
+)
+
+// findCASecret returns the secret holding the CA to import into the PKI
+// backend of the given cert config, if any. The secret is referenced by the
+// cert config itself or, for the default trust domain only, by the CAPI
+// cluster of the cert config. Referenced secrets which cannot be found are
+// reported as error, so that no CA gets generated in their place.
+func (r *Resource) findCASecret(ctx context.Context, customObject v1alpha1.CertConfig) (*corev1.Secret, error) {
+	name := customObject.GetAnnotations()[annotation.CASecret]
+
+	if name == "" && key.TrustDomain(customObject, r.trustDomains) == trustdomain.Default {
+		cluster := &capi.Cluster{}
+		err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: key.ClusterID(customObject), Namespace: customObject.GetNamespace()}, cluster)
+		if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			// fall through
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		} else {
+			name = cluster.GetAnnotations()[annotation.CASecret]
+		}
+	}
+
+	if name == "" {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: name, Namespace: customObject.GetNamespace()}, secret)
+	if apierrors.IsNotFound(err) {
+		return nil, microerror.Maskf(caSecretNotFoundError, "secret %s/%s", customObject.GetNamespace(), name)
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found CA secret %s/%s", secret.Namespace, secret.Name))
+
+	return secret, nil
+}
@@ -0,0 +1,30 @@
+package vaultpki
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	PrometheusNamespace = "cert_operator"
+	PrometheusSubsystem = "vaultpki_resource"
+)
+
+var caExpiryGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: PrometheusSubsystem,
+		Name:      "ca_expiry_timestamp_seconds",
+		Help:      "A metric exposing the expiration time of the CA of a PKI backend as Unix timestamp, labeled by cluster and PKI backend ID.",
+	},
+	[]string{"cluster_id", "pki_id"},
+)
+
+func init() {
+	prometheus.MustRegister(caExpiryGauge)
+}
+
+// DeleteCAExpiry stops exporting the expiration time of the CA of the given PKI
+// backend of the given cluster, e.g. once the PKI backend got deleted.
+func DeleteCAExpiry(clusterID, pkiID string) {
+	caExpiryGauge.DeleteLabelValues(clusterID, pkiID)
+}
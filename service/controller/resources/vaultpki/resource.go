@@ -1,9 +1,18 @@
 package vaultpki
 
 import (
+	"time"
+
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/giantswarm/vaultpki"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/caconstraint"
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultimport"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultintermediate"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultroot"
 )
 
 const (
@@ -11,26 +20,84 @@ const (
 )
 
 type Config struct {
-	Logger   micrologger.Logger
-	VaultPKI vaultpki.Interface
+	CtrlClient         client.Client
+	CurrentTimeFactory func() time.Time
+	Logger             micrologger.Logger
+	VaultImport        vaultimport.Interface
+	VaultPKI           vaultpki.Interface
+	// VaultIntermediate is optional. When given, new CAs are created as
+	// intermediate CAs instead of self-signed root CAs.
+	VaultIntermediate vaultintermediate.Interface
+	// CAConstraints is optional. When given, new CAs embed the constraints
+	// it derives for their cluster. Root CAs are then created by VaultRoot,
+	// which must be given along with it.
+	CAConstraints *caconstraint.Policy
+	VaultRoot     vaultroot.Interface
+
+	// ExportCAKeys makes new CAs be created with their private key exported
+	// from Vault. The private key is stored in the CA key secret of the PKI
+	// backend, see key.CAKeySecretName, so that the Cluster API secrets can
+	// hold it.
+	ExportCAKeys bool
+	// CAExpirationThreshold is the remaining lifetime of the CA of the default
+	// trust domain below which the CAExpiringSoon condition of the CAPI
+	// cluster is set. Zero disables the condition.
+	CAExpirationThreshold time.Duration
+	// TrustDomains maps cluster components to the trust domains whose PKI
+	// backend issues their certificates.
+	TrustDomains trustdomain.Mapping
 }
 
 type Resource struct {
-	logger   micrologger.Logger
-	vaultPKI vaultpki.Interface
+	ctrlClient         client.Client
+	currentTimeFactory func() time.Time
+	logger             micrologger.Logger
+	vaultImport        vaultimport.Interface
+	vaultPKI           vaultpki.Interface
+	// vaultIntermediate is nil in case CAs are created as self-signed root CAs.
+	vaultIntermediate vaultintermediate.Interface
+	// caConstraints is nil in case CAs are created without constraints.
+	caConstraints *caconstraint.Policy
+	vaultRoot     vaultroot.Interface
+
+	exportCAKeys          bool
+	caExpirationThreshold time.Duration
+	trustDomains          trustdomain.Mapping
 }
 
 func New(config Config) (*Resource, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.CurrentTimeFactory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CurrentTimeFactory must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.VaultImport == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultImport must not be empty", config)
+	}
 	if config.VaultPKI == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.VaultPKI must not be empty", config)
 	}
+	if config.CAConstraints != nil && config.VaultRoot == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultRoot must not be empty when %T.CAConstraints is given", config, config)
+	}
 
 	r := &Resource{
-		logger:   config.Logger,
-		vaultPKI: config.VaultPKI,
+		ctrlClient:         config.CtrlClient,
+		currentTimeFactory: config.CurrentTimeFactory,
+		logger:             config.Logger,
+		vaultImport:        config.VaultImport,
+		vaultPKI:           config.VaultPKI,
+		vaultIntermediate:  config.VaultIntermediate,
+		caConstraints:      config.CAConstraints,
+		vaultRoot:          config.VaultRoot,
+
+		exportCAKeys:          config.ExportCAKeys,
+		caExpirationThreshold: config.CAExpirationThreshold,
+		trustDomains:          config.TrustDomains,
 	}
 
 	return r, nil
@@ -20,7 +20,7 @@ func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interf
 	var role *vaultrole.Role
 	{
 		c := vaultrole.SearchConfig{
-			ID:            key.ClusterID(customObject),
+			ID:            key.PKIID(customObject, r.trustDomains),
 			Organizations: key.Organizations(customObject),
 		}
 		result, err := r.vaultRole.Search(c)
@@ -2,6 +2,7 @@ package vaultrole
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/giantswarm/microerror"
@@ -23,11 +24,21 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 		return nil, microerror.Mask(err)
 	}
 
+	// The roles of the cluster's PKI backend allow the names below the common
+	// name of the cluster. The common names of trust domain PKI backends are
+	// made up of the backend ID instead, so their roles have to allow the
+	// names of the cluster explicitly.
+	altNames := key.AltNames(customObject)
+	id := key.PKIID(customObject, r.trustDomains)
+	if id != key.ClusterID(customObject) {
+		altNames = append([]string{fmt.Sprintf(r.commonNameFormat, key.ClusterID(customObject))}, altNames...)
+	}
+
 	role := &vaultrole.Role{
 		AllowBareDomains: key.AllowBareDomains(customObject),
 		AllowSubdomains:  AllowSubdomains,
-		AltNames:         key.AltNames(customObject),
-		ID:               key.ClusterID(customObject),
+		AltNames:         altNames,
+		ID:               id,
 		Organizations:    key.Organizations(customObject),
 		TTL:              TTL,
 	}
@@ -10,6 +10,8 @@ import (
 	"github.com/giantswarm/micrologger/microloggertest"
 	"github.com/giantswarm/vaultrole"
 	"github.com/giantswarm/vaultrole/vaultroletest"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
 )
 
 func Test_Resource_VaultRole_GetDesiredState(t *testing.T) {
@@ -83,6 +85,38 @@ func Test_Resource_VaultRole_GetDesiredState(t *testing.T) {
 				TTL: 8 * time.Hour,
 			},
 		},
+
+		// Case 2 ensures the role of a trust domain PKI backend allows the
+		// names of the cluster.
+		{
+			Obj: &v1alpha1.CertConfig{
+				Spec: v1alpha1.CertConfigSpec{
+					Cert: v1alpha1.CertConfigSpecCert{
+						AllowBareDomains: true,
+						AltNames: []string{
+							"etcd.kube-system.svc",
+						},
+						ClusterComponent: "etcd",
+						ClusterID:        "al9qy",
+						Organizations:    nil,
+						TTL:              "8h",
+					},
+				},
+			},
+			ExpectedRole: &vaultrole.Role{
+				AllowBareDomains: true,
+				AllowSubdomains:  true,
+				AltNames: []string{
+					"al9qy.k8s.gigantic.io",
+					"etcd.kube-system.svc",
+				},
+				ID: "al9qy-etcd",
+				Organizations: []string{
+					"etcd",
+				},
+				TTL: 8 * time.Hour,
+			},
+		},
 	}
 
 	var err error
@@ -93,6 +127,9 @@ func Test_Resource_VaultRole_GetDesiredState(t *testing.T) {
 		c.Logger = microloggertest.New()
 		c.VaultRole = vaultroletest.New()
 
+		c.CommonNameFormat = "%s.k8s.gigantic.io"
+		c.TrustDomains = trustdomain.Mapping{"etcd": "etcd"}
+
 		newResource, err = New(c)
 		if err != nil {
 			t.Fatal("expected", nil, "got", err)
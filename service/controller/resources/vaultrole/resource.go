@@ -4,6 +4,8 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/giantswarm/vaultrole"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/trustdomain"
 )
 
 const (
@@ -16,18 +18,30 @@ const (
 type Config struct {
 	Logger    micrologger.Logger
 	VaultRole vaultrole.Interface
+
+	// CommonNameFormat is the format of the Vault PKI common name of tenant
+	// clusters. It is only required with TrustDomains, because the roles of
+	// trust domain PKI backends have to allow the names of the cluster.
+	CommonNameFormat string
+	TrustDomains     trustdomain.Mapping
 }
 
 func DefaultConfig() Config {
 	return Config{
 		Logger:    nil,
 		VaultRole: nil,
+
+		CommonNameFormat: "",
+		TrustDomains:     nil,
 	}
 }
 
 type Resource struct {
 	logger    micrologger.Logger
 	vaultRole vaultrole.Interface
+
+	commonNameFormat string
+	trustDomains     trustdomain.Mapping
 }
 
 func New(config Config) (*Resource, error) {
@@ -38,11 +52,18 @@ func New(config Config) (*Resource, error) {
 		return nil, microerror.Maskf(invalidConfigError, "config.VaultRole must not be empty")
 	}
 
+	if len(config.TrustDomains) != 0 && config.CommonNameFormat == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.CommonNameFormat must not be empty when config.TrustDomains is given")
+	}
+
 	r := &Resource{
 		logger: config.Logger.With(
 			"resource", Name,
 		),
 		vaultRole: config.VaultRole,
+
+		commonNameFormat: config.CommonNameFormat,
+		trustDomains:     config.TrustDomains,
 	}
 
 	return r, nil
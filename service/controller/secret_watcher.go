@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+
+	corev1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/giantswarm/cert-operator/v3/service/controller/key"
+)
+
+type SecretWatcherConfig struct {
+	CtrlClient client.Reader
+	Logger     micrologger.Logger
+
+	// Selector is used to filter the certificate secrets to watch.
+	Selector labels.Selector
+}
+
+// SecretWatcher maps the certificate secrets controlled by CertConfigs to
+// their CertConfig. On deletion of a secret or a change of its data, the
+// controlling CertConfig is enqueued in the CertConfig controller, which
+// reconciles it right away instead of waiting for the next resync. The
+// CertConfig itself is left untouched. Paused CertConfigs are not enqueued.
+type SecretWatcher struct {
+	ctrlClient client.Reader
+	logger     micrologger.Logger
+
+	selector labels.Selector
+}
+
+func NewSecretWatcher(config SecretWatcherConfig) (*SecretWatcher, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.CtrlClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+
+	if config.Selector == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Selector must not be empty")
+	}
+
+	w := &SecretWatcher{
+		ctrlClient: config.CtrlClient,
+		logger:     config.Logger,
+
+		selector: config.Selector,
+	}
+
+	return w, nil
+}
+
+// Source returns the source of the secret events. Its cache is injected by
+// the manager the watch is registered with.
+func (w *SecretWatcher) Source() source.Source {
+	return &source.Kind{Type: &corev1.Secret{}}
+}
+
+// Handler returns the event handler enqueueing the CertConfig controlling the
+// secret of an event.
+func (w *SecretWatcher) Handler() handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(w.mapFunc)
+}
+
+// Predicate returns the filter for the secret events. Only deletions of
+// selected secrets and changes of their data are passed on.
+func (w *SecretWatcher) Predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return w.selector.Matches(labels.Set(e.Object.GetLabels()))
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !w.selector.Matches(labels.Set(e.ObjectNew.GetLabels())) {
+				return false
+			}
+
+			oldSecret, ok := e.ObjectOld.(*corev1.Secret)
+			if !ok {
+				return false
+			}
+			newSecret, ok := e.ObjectNew.(*corev1.Secret)
+			if !ok {
+				return false
+			}
+
+			return !reflect.DeepEqual(oldSecret.Data, newSecret.Data)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// mapFunc returns the request of the CertConfig controlling the given secret.
+// Secrets not controlled by a CertConfig, e.g. the ones not yet adopted, and
+// secrets of paused CertConfigs are ignored.
+func (w *SecretWatcher) mapFunc(obj client.Object) []reconcile.Request {
+	ctx := context.Background()
+
+	ref := metav1.GetControllerOf(obj)
+	if ref == nil {
+		return nil
+	}
+	if ref.APIVersion != corev1alpha1.SchemeGroupVersion.String() || ref.Kind != corev1alpha1.NewCertConfigTypeMeta().Kind {
+		return nil
+	}
+
+	name := types.NamespacedName{Namespace: obj.GetNamespace(), Name: ref.Name}
+
+	cc := &corev1alpha1.CertConfig{}
+	err := w.ctrlClient.Get(ctx, name, cc)
+	if errors.IsNotFound(err) {
+		// The CertConfig is gone, e.g. because its secret got deleted by the
+		// garbage collector. There is nothing to reconcile anymore.
+		return nil
+	} else if err != nil {
+		// The pause resource still skips paused CertConfigs, so we rather
+		// reconcile in vain than miss a change.
+		w.logger.Errorf(ctx, err, "failed to get CertConfig %#q", name.String())
+	} else if key.IsPaused(*cc) {
+		w.logger.Debugf(ctx, "not enqueueing paused CertConfig %#q", name.String())
+		return nil
+	}
+
+	w.logger.Debugf(ctx, "enqueueing CertConfig %#q due to a change of its secret", name.String())
+
+	return []reconcile.Request{
+		{NamespacedName: name},
+	}
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint:staticcheck // v0.6.4 has a deprecation on pkg/client/fake that was removed in later versions
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/giantswarm/cert-operator/v3/pkg/label"
+	"github.com/giantswarm/cert-operator/v3/pkg/project"
+)
+
+func Test_SecretWatcher(t *testing.T) {
+	certConfig := &corev1alpha1.CertConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "al9qy-api",
+			Namespace: "default",
+			UID:       "5f3c2a3e-5c1e-4b0a-9d0c-1b1c2a3d4e5f",
+		},
+	}
+
+	owned := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "al9qy-api",
+			Namespace: "default",
+			Labels: map[string]string{
+				label.Certificate:     "api",
+				label.OperatorVersion: project.Version(),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(certConfig, corev1alpha1.SchemeGroupVersion.WithKind("CertConfig")),
+			},
+		},
+		Data: map[string][]byte{"crt": []byte("crt")},
+	}
+
+	pausedCertConfig := certConfig.DeepCopy()
+	pausedCertConfig.Name = "al9qy-etcd"
+	pausedCertConfig.UID = "9a3f1c2e-7d4b-4e8a-b1c0-2d3e4f5a6b7c"
+	pausedCertConfig.Labels = map[string]string{label.Paused: "true"}
+
+	paused := owned.DeepCopy()
+	paused.Name = "al9qy-etcd"
+	paused.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(pausedCertConfig, corev1alpha1.SchemeGroupVersion.WithKind("CertConfig")),
+	}
+
+	unowned := owned.DeepCopy()
+	unowned.OwnerReferences = nil
+
+	unselected := owned.DeepCopy()
+	unselected.Labels = nil
+
+	tampered := owned.DeepCopy()
+	tampered.Data["crt"] = []byte("tampered crt")
+
+	var err error
+	var w *SecretWatcher
+	{
+		scheme := runtime.NewScheme()
+		_ = corev1alpha1.AddToScheme(scheme)
+
+		c := SecretWatcherConfig{
+			CtrlClient: fakectrl.NewClientBuilder().WithScheme(scheme).WithObjects(certConfig, pausedCertConfig).Build(),
+			Logger:     microloggertest.New(),
+
+			Selector: label.SecretSelector(),
+		}
+
+		w, err = NewSecretWatcher(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	p := w.Predicate()
+
+	// Test 0 ensures updates not touching the secret data are ignored.
+	if p.Update(event.UpdateEvent{ObjectOld: owned, ObjectNew: owned.DeepCopy()}) {
+		t.Fatalf("test 0 expected %t got %t", false, true)
+	}
+
+	// Test 1 ensures changes of the secret data and deletions of the secret
+	// are passed on.
+	if !p.Update(event.UpdateEvent{ObjectOld: owned, ObjectNew: tampered}) {
+		t.Fatalf("test 1 expected %t got %t", true, false)
+	}
+	if !p.Delete(event.DeleteEvent{Object: owned}) {
+		t.Fatalf("test 1 expected %t got %t", true, false)
+	}
+
+	// Test 2 ensures secrets not matching the selector are ignored.
+	if p.Delete(event.DeleteEvent{Object: unselected}) {
+		t.Fatalf("test 2 expected %t got %t", false, true)
+	}
+
+	// Test 3 ensures secrets not controlled by a CertConfig are ignored.
+	if requests := w.mapFunc(unowned); len(requests) != 0 {
+		t.Fatalf("test 3 expected %d requests got %d", 0, len(requests))
+	}
+
+	// Test 4 ensures secrets are mapped to their controlling CertConfig.
+	requests := w.mapFunc(owned)
+	if len(requests) != 1 {
+		t.Fatalf("test 4 expected %d requests got %d", 1, len(requests))
+	}
+	expected := types.NamespacedName{Namespace: "default", Name: "al9qy-api"}
+	if requests[0].NamespacedName != expected {
+		t.Fatalf("test 4 expected %#q got %#q", expected.String(), requests[0].NamespacedName.String())
+	}
+
+	// Test 5 ensures secrets of paused CertConfigs are ignored.
+	if requests := w.mapFunc(paused); len(requests) != 0 {
+		t.Fatalf("test 5 expected %d requests got %d", 0, len(requests))
+	}
+}
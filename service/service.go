@@ -20,7 +20,11 @@ import (
 
 	clientvault "github.com/giantswarm/cert-operator/v3/client/vault"
 	"github.com/giantswarm/cert-operator/v3/flag"
+	certoperatorv1alpha1 "github.com/giantswarm/cert-operator/v3/pkg/apis/certoperator/v1alpha1"
 	"github.com/giantswarm/cert-operator/v3/pkg/project"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultauth"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultbreaker"
+	"github.com/giantswarm/cert-operator/v3/pkg/vaultcache"
 	"github.com/giantswarm/cert-operator/v3/service/collector"
 	"github.com/giantswarm/cert-operator/v3/service/controller"
 )
@@ -45,6 +49,8 @@ type Service struct {
 
 	bootOnce          sync.Once
 	certController    *controller.Cert
+	clusterController *controller.Cluster
+	issuerController  *controller.Issuer
 	operatorCollector *collector.Set
 }
 
@@ -84,6 +90,7 @@ func New(config Config) (*Service, error) {
 		c := k8sclient.ClientsConfig{
 			SchemeBuilder: k8sclient.SchemeBuilder{
 				capi.AddToScheme,
+				certoperatorv1alpha1.AddToScheme,
 				corev1alpha1.AddToScheme,
 				providerv1alpha1.AddToScheme,
 			},
@@ -98,9 +105,27 @@ func New(config Config) (*Service, error) {
 		}
 	}
 
+	var vaultBreaker *vaultbreaker.Breaker
+	{
+		c := vaultbreaker.Config{
+			Logger: config.Logger,
+
+			FailureThreshold: config.Viper.GetInt(config.Flag.Service.Vault.Config.CircuitBreaker.FailureThreshold),
+			MaxOpenDuration:  config.Viper.GetDuration(config.Flag.Service.Vault.Config.CircuitBreaker.MaxOpenDuration),
+			OpenDuration:     config.Viper.GetDuration(config.Flag.Service.Vault.Config.CircuitBreaker.OpenDuration),
+		}
+
+		vaultBreaker, err = vaultbreaker.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var vaultClient *vaultapi.Client
 	{
 		vaultConfig := clientvault.Config{
+			VaultBreaker: vaultBreaker,
+
 			Flag:  config.Flag,
 			Viper: config.Viper,
 		}
@@ -111,20 +136,94 @@ func New(config Config) (*Service, error) {
 		}
 	}
 
-	var certController *controller.Cert
+	var vaultAuth *vaultauth.VaultAuth
 	{
-		c := controller.CertConfig{
-			K8sClient:   k8sClient,
+		c := vaultauth.Config{
 			Logger:      config.Logger,
 			VaultClient: vaultClient,
 
-			UniqueApp:           config.Viper.GetBool(config.Flag.Service.App.Unique),
-			CATTL:               config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.CA.TTL),
-			CRDLabelSelector:    config.Viper.GetString(config.Flag.Service.CRD.LabelSelector),
-			CommonNameFormat:    config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.CommonName.Format),
-			ExpirationThreshold: config.Viper.GetDuration(config.Flag.Service.Resource.VaultCrt.ExpirationThreshold),
-			Namespace:           config.Viper.GetString(config.Flag.Service.Resource.VaultCrt.Namespace),
-			ProjectName:         config.ProjectName,
+			AppRoleRoleID:       config.Viper.GetString(config.Flag.Service.Vault.Config.Auth.AppRole.RoleID),
+			AppRoleSecretIDFile: config.Viper.GetString(config.Flag.Service.Vault.Config.Auth.AppRole.SecretIDFile),
+			CertName:            config.Viper.GetString(config.Flag.Service.Vault.Config.Auth.Cert.Name),
+			Method:              config.Viper.GetString(config.Flag.Service.Vault.Config.Auth.Method),
+		}
+
+		switch c.Method {
+		case vaultauth.MethodAppRole:
+			c.MountPath = config.Viper.GetString(config.Flag.Service.Vault.Config.Auth.AppRole.MountPath)
+		case vaultauth.MethodCert:
+			c.MountPath = config.Viper.GetString(config.Flag.Service.Vault.Config.Auth.Cert.MountPath)
+		}
+
+		vaultAuth, err = vaultauth.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		// The controllers and collectors need a token right away. It is
+		// renewed and acquired again through vaultAuth later on.
+		err = vaultAuth.Login(context.Background())
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	// The cache is shared by all controllers, so that changes of one
+	// controller invalidate the cached lookups of the others.
+	var vaultCache *vaultcache.Cache
+	{
+		c := vaultcache.Config{
+			TTL: config.Viper.GetDuration(config.Flag.Service.Vault.Config.Cache.TTL),
+		}
+
+		vaultCache, err = vaultcache.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	// The names of the CertConfigs created for CAPI clusters must be
+	// permitted by the CA constraints.
+	var certConfigTemplate string
+	if config.Viper.GetBool(config.Flag.Service.CAPI.CertConfigs.Create) {
+		certConfigTemplate = config.Viper.GetString(config.Flag.Service.CAPI.CertConfigs.Template)
+	}
+
+	var certController *controller.Cert
+	{
+		c := controller.CertConfig{
+			K8sClient:    k8sClient,
+			Logger:       config.Logger,
+			VaultAuth:    vaultAuth,
+			VaultBreaker: vaultBreaker,
+			VaultCache:   vaultCache,
+			VaultClient:  vaultClient,
+
+			UniqueApp:               config.Viper.GetBool(config.Flag.Service.App.Unique),
+			CAPISecrets:             config.Viper.GetBool(config.Flag.Service.CAPI.Secrets),
+			CAExpirationThreshold:   config.Viper.GetDuration(config.Flag.Service.Vault.Config.PKI.CA.ExpirationThreshold),
+			CAConstraints:           config.Viper.GetBool(config.Flag.Service.Vault.Config.PKI.Constraints.Enabled),
+			CAMaxPathLength:         config.Viper.GetInt(config.Flag.Service.Vault.Config.PKI.Constraints.MaxPathLength),
+			CAPermittedDNSDomains:   config.Viper.GetStringSlice(config.Flag.Service.Vault.Config.PKI.Constraints.PermittedDNSDomains),
+			CAPermittedIPRanges:     config.Viper.GetStringSlice(config.Flag.Service.Vault.Config.PKI.Constraints.PermittedIPRanges),
+			CATTL:                   config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.CA.TTL),
+			CertConfigTemplate:      certConfigTemplate,
+			CRDLabelSelector:        config.Viper.GetString(config.Flag.Service.CRD.LabelSelector),
+			CommonNameFormat:        config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.CommonName.Format),
+			ExpirationThreshold:     config.Viper.GetDuration(config.Flag.Service.Resource.VaultCrt.ExpirationThreshold),
+			Intermediate:            config.Viper.GetBool(config.Flag.Service.Vault.Config.PKI.Intermediate.Enabled),
+			IntermediateParentCrt:   config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.Intermediate.ParentCrtFile),
+			IntermediateParentKey:   config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.Intermediate.ParentKeyFile),
+			IntermediateParentMount: config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.Intermediate.ParentMount),
+			Namespace:               config.Viper.GetString(config.Flag.Service.Resource.VaultCrt.Namespace),
+			ReloadWorkloads:         config.Viper.GetBool(config.Flag.Service.Resource.VaultCrt.ReloadWorkloads),
+			ProjectName:             config.ProjectName,
+			RenewalBudget:           config.Viper.GetInt(config.Flag.Service.Resource.VaultCrt.RenewalBudget.PerMinute),
+			RenewalBudgetPerCluster: config.Viper.GetInt(config.Flag.Service.Resource.VaultCrt.RenewalBudget.PerCluster),
+			RenewalJitter:           config.Viper.GetDuration(config.Flag.Service.Resource.VaultCrt.RenewalJitter),
+			RenewalOverlap:          config.Viper.GetDuration(config.Flag.Service.Resource.VaultCrt.RenewalOverlap),
+			TrustDomains:            config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.TrustDomains),
+			VaultNamespace:          config.Viper.GetString(config.Flag.Service.Vault.Config.Namespace),
 		}
 
 		certController, err = controller.NewCert(c)
@@ -133,11 +232,59 @@ func New(config Config) (*Service, error) {
 		}
 	}
 
+	var clusterController *controller.Cluster
+	if config.Viper.GetBool(config.Flag.Service.CAPI.CleanupPKI) || config.Viper.GetBool(config.Flag.Service.CAPI.CertConfigs.Create) {
+		c := controller.ClusterConfig{
+			K8sClient:    k8sClient,
+			Logger:       config.Logger,
+			VaultBreaker: vaultBreaker,
+			VaultCache:   vaultCache,
+			VaultClient:  vaultClient,
+
+			CATTL:              config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.CA.TTL),
+			CertConfigTemplate: config.Viper.GetString(config.Flag.Service.CAPI.CertConfigs.Template),
+			CleanupPKI:         config.Viper.GetBool(config.Flag.Service.CAPI.CleanupPKI),
+			CommonNameFormat:   config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.CommonName.Format),
+			CreateCertConfigs:  config.Viper.GetBool(config.Flag.Service.CAPI.CertConfigs.Create),
+			ProjectName:        config.ProjectName,
+			TrustDomains:       config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.TrustDomains),
+			UniqueApp:          config.Viper.GetBool(config.Flag.Service.App.Unique),
+			VaultNamespace:     config.Viper.GetString(config.Flag.Service.Vault.Config.Namespace),
+		}
+
+		clusterController, err = controller.NewCluster(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var issuerController *controller.Issuer
+	if config.Viper.GetBool(config.Flag.Service.Issuer.Enabled) {
+		c := controller.IssuerConfig{
+			K8sClient:    k8sClient,
+			Logger:       config.Logger,
+			VaultBreaker: vaultBreaker,
+			VaultCache:   vaultCache,
+			VaultClient:  vaultClient,
+
+			CATTL:            config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.CA.TTL),
+			CommonNameFormat: config.Viper.GetString(config.Flag.Service.Vault.Config.PKI.CommonName.Format),
+			ProjectName:      config.ProjectName,
+			VaultNamespace:   config.Viper.GetString(config.Flag.Service.Vault.Config.Namespace),
+		}
+
+		issuerController, err = controller.NewIssuer(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var operatorCollector *collector.Set
 	{
 		c := collector.SetConfig{
-			Logger:      config.Logger,
-			VaultClient: vaultClient,
+			Logger:       config.Logger,
+			VaultAuth:    vaultAuth,
+			VaultBreaker: vaultBreaker,
 		}
 
 		operatorCollector, err = collector.NewSet(c)
@@ -167,6 +314,8 @@ func New(config Config) (*Service, error) {
 
 		bootOnce:          sync.Once{},
 		certController:    certController,
+		clusterController: clusterController,
+		issuerController:  issuerController,
 		operatorCollector: operatorCollector,
 	}
 
@@ -177,6 +326,12 @@ func New(config Config) (*Service, error) {
 func (s *Service) Boot() {
 	s.bootOnce.Do(func() {
 		go s.certController.Boot(context.Background())
+		if s.clusterController != nil {
+			go s.clusterController.Boot(context.Background())
+		}
+		if s.issuerController != nil {
+			go s.issuerController.Boot(context.Background())
+		}
 		go s.operatorCollector.Boot(context.Background())
 	})
 }